@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS vehicle_commands (
+    id              SERIAL PRIMARY KEY,
+    vehicle_id      INT NOT NULL REFERENCES vehicles(id),
+    reservation_id  INT NOT NULL REFERENCES reservations(id),
+    user_id         INT NOT NULL,
+    command         VARCHAR(16) NOT NULL,                  -- Unlock, Lock
+    status          VARCHAR(16) NOT NULL DEFAULT 'Pending', -- Pending, Sent, Acknowledged, Failed, TimedOut
+    error_message   TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL,
+    sent_at         TIMESTAMP,
+    acknowledged_at TIMESTAMP,
+    expires_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicle_commands_queue
+    ON vehicle_commands (vehicle_id, status, created_at);
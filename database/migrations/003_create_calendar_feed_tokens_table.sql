@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    user_id    INT PRIMARY KEY,
+    token      VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- Bumped on every change so calendar clients replace the old invite
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS sequence INT NOT NULL DEFAULT 0;
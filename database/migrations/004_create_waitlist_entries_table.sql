@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+    id                 SERIAL PRIMARY KEY,
+    user_id            INT NOT NULL,
+    start_time         TIMESTAMP NOT NULL,
+    end_time           TIMESTAMP NOT NULL,
+    vehicle_type       VARCHAR(50),                            -- NULL matches any type
+    zone               VARCHAR(255),                           -- matched against vehicles.location, NULL matches any
+    status             VARCHAR(16) NOT NULL DEFAULT 'Waiting', -- Waiting, Offered, Accepted, Expired, Cancelled
+    offered_vehicle_id INT REFERENCES vehicles(id),
+    offer_expires_at   TIMESTAMP,
+    reservation_id     INT REFERENCES reservations(id),
+    created_at         TIMESTAMP NOT NULL,
+    updated_at         TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_status
+    ON waitlist_entries (status, created_at);
+
+-- Set when a vehicle is handed back, possibly before end_time
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS returned_at TIMESTAMP;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS reservation_series (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT NOT NULL,
+    vehicle_id INT NOT NULL REFERENCES vehicles(id),
+    rrule      VARCHAR(255) NOT NULL,
+    start_time TIMESTAMP NOT NULL, -- first occurrence
+    end_time   TIMESTAMP NOT NULL,
+    status     VARCHAR(16) NOT NULL DEFAULT 'Active', -- Active, Cancelled
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS series_id INT REFERENCES reservation_series(id);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS booking_holds (
+    id             SERIAL PRIMARY KEY,
+    user_id        INT NOT NULL,
+    vehicle_id     INT NOT NULL REFERENCES vehicles(id),
+    start_time     TIMESTAMP NOT NULL,
+    end_time       TIMESTAMP NOT NULL,
+    status         VARCHAR(16) NOT NULL DEFAULT 'Held', -- Held, Confirmed, Released, Expired
+    expires_at     TIMESTAMP NOT NULL,
+    reservation_id INT REFERENCES reservations(id),
+    created_at     TIMESTAMP NOT NULL,
+    updated_at     TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_holds_vehicle
+    ON booking_holds (vehicle_id, status, expires_at);
+
+-- Waitlist offers are now backed by a booking hold
+ALTER TABLE waitlist_entries ADD COLUMN IF NOT EXISTS hold_id INT REFERENCES booking_holds(id);
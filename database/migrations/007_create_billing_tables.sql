@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS invoices (
+    id             SERIAL PRIMARY KEY,
+    user_id        INT NOT NULL,
+    reservation_id INT NOT NULL UNIQUE REFERENCES reservations(id),
+    status         VARCHAR(16) NOT NULL DEFAULT 'Open', -- Open, Issued
+    total_cents    BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP NOT NULL,
+    updated_at     TIMESTAMP NOT NULL,
+    issued_at      TIMESTAMP
+);
+
+-- Lines are append-only; corrections are new lines, never edits
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    id           SERIAL PRIMARY KEY,
+    invoice_id   INT NOT NULL REFERENCES invoices(id),
+    kind         VARCHAR(32) NOT NULL,
+    description  TEXT NOT NULL,
+    amount_cents BIGINT NOT NULL, -- negative for discounts and credits
+    created_at   TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_lines_invoice ON invoice_lines (invoice_id);
+
+CREATE TABLE IF NOT EXISTS cancellation_policies (
+    tier                    VARCHAR(50) PRIMARY KEY,
+    free_until_hours        INT NOT NULL, -- free to cancel up to this many hours before start
+    late_cancel_fee_percent INT NOT NULL, -- of the rental price, inside the free window
+    no_show_fee_percent     INT NOT NULL, -- of the rental price, once the start time has passed
+    updated_at              TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO cancellation_policies (tier, free_until_hours, late_cancel_fee_percent, no_show_fee_percent) VALUES
+    ('Basic',   24, 50, 100),
+    ('Premium', 12, 25, 100),
+    ('VIP',      2,  0,  50)
+ON CONFLICT (tier) DO NOTHING;
+
+-- Reservations made before pickups were recorded have no picked_up_at even
+-- when the trip went ahead, so only newer ones can be cancelled as no-shows
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS tracks_pickup BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reservations ALTER COLUMN tracks_pickup SET DEFAULT TRUE;
@@ -0,0 +1,15 @@
+-- Time kept free around every booking so a vehicle can be cleaned and charged
+CREATE TABLE IF NOT EXISTS turnaround_buffers (
+    vehicle_type             VARCHAR(50) PRIMARY KEY,
+    buffer_minutes           INT NOT NULL,
+    low_charge_threshold     INT NOT NULL DEFAULT 0, -- charge level (%) below which the extra time applies
+    low_charge_extra_minutes INT NOT NULL DEFAULT 0,
+    updated_at               TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO turnaround_buffers (vehicle_type, buffer_minutes, low_charge_threshold, low_charge_extra_minutes) VALUES
+    ('Sedan',    15,  0,  0),
+    ('SUV',      20,  0,  0),
+    ('Van',      30,  0,  0),
+    ('Electric', 15, 30, 45)
+ON CONFLICT (vehicle_type) DO NOTHING;
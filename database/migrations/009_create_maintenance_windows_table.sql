@@ -0,0 +1,12 @@
+-- Planned downtime; entered by fleet operations and blocks bookings outright
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id         SERIAL PRIMARY KEY,
+    vehicle_id INT NOT NULL REFERENCES vehicles(id),
+    start_time TIMESTAMP NOT NULL,
+    end_time   TIMESTAMP NOT NULL,
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_vehicle
+    ON maintenance_windows (vehicle_id, start_time);
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS promotions (
+    id              SERIAL PRIMARY KEY,
+    code            VARCHAR(32) NOT NULL UNIQUE, -- stored upper case
+    description     TEXT NOT NULL DEFAULT '',
+    discount_type   VARCHAR(16) NOT NULL, -- percent, fixed
+    discount_value  BIGINT NOT NULL, -- percent, or cents off for fixed
+    starts_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMP,
+    max_redemptions INT, -- across all users; NULL for unlimited
+    per_user_limit  INT NOT NULL DEFAULT 1,
+    tiers           TEXT[] NOT NULL DEFAULT '{}', -- empty for every tier
+    first_ride_only BOOLEAN NOT NULL DEFAULT FALSE,
+    active          BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id             SERIAL PRIMARY KEY,
+    promotion_id   INT NOT NULL REFERENCES promotions(id),
+    user_id        INT NOT NULL,
+    reservation_id INT NOT NULL UNIQUE REFERENCES reservations(id),
+    discount_cents BIGINT NOT NULL,
+    status         VARCHAR(16) NOT NULL DEFAULT 'Applied', -- Applied, Reversed
+    created_at     TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_promotion
+    ON promotion_redemptions (promotion_id, user_id, status);
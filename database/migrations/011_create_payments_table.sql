@@ -0,0 +1,15 @@
+-- One payment per reservation: authorized at booking, then captured or voided
+CREATE TABLE IF NOT EXISTS payments (
+    id               SERIAL PRIMARY KEY,
+    reservation_id   INT NOT NULL UNIQUE REFERENCES reservations(id),
+    user_id          INT NOT NULL,
+    provider         VARCHAR(32) NOT NULL,
+    reference        VARCHAR(128) NOT NULL DEFAULT '', -- the provider's id for the payment
+    status           VARCHAR(16) NOT NULL, -- Authorized, Captured, Voided, Failed
+    authorized_cents BIGINT NOT NULL DEFAULT 0,
+    captured_cents   BIGINT NOT NULL DEFAULT 0,
+    refunded_cents   BIGINT NOT NULL DEFAULT 0,
+    error_message    TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMP NOT NULL,
+    updated_at       TIMESTAMP NOT NULL
+);
@@ -0,0 +1,42 @@
+-- Staff can act on other users' accounts, e.g. to issue goodwill credits
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user'; -- user, staff
+
+-- Double-entry ledger: every transaction's entries sum to zero, and an
+-- account's balance is the sum of its entries
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id         SERIAL PRIMARY KEY,
+    user_id    INT, -- NULL for the service's own accounts
+    kind       VARCHAR(32) NOT NULL, -- wallet, payments_clearing, goodwill, revenue
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, kind)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_system
+    ON ledger_accounts (kind) WHERE user_id IS NULL;
+
+INSERT INTO ledger_accounts (user_id, kind) VALUES
+    (NULL, 'payments_clearing'),
+    (NULL, 'goodwill'),
+    (NULL, 'revenue')
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id          SERIAL PRIMARY KEY,
+    kind        VARCHAR(32) NOT NULL, -- topup, goodwill, spend
+    description TEXT NOT NULL DEFAULT '',
+    reference   VARCHAR(128) NOT NULL DEFAULT '', -- payment reference or reservation
+    created_by  INT, -- staff member for goodwill credits
+    created_at  TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES ledger_transactions(id),
+    account_id     INT NOT NULL REFERENCES ledger_accounts(id),
+    amount_cents   BIGINT NOT NULL -- positive adds to the account's balance
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries (account_id);
+
+-- Part of a settled payment that came out of the user's wallet
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS wallet_cents BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,33 @@
+-- One statement per user and calendar month, built once the month is over
+CREATE TABLE IF NOT EXISTS monthly_statements (
+    id                  SERIAL PRIMARY KEY,
+    user_id             INT NOT NULL,
+    month               DATE NOT NULL, -- first day of the month
+    trip_count          INT NOT NULL,
+    minutes             INT NOT NULL,
+    rental_cents        BIGINT NOT NULL, -- before discounts
+    tier_savings_cents  BIGINT NOT NULL,
+    promo_savings_cents BIGINT NOT NULL,
+    fees_cents          BIGINT NOT NULL, -- cancellation and no-show fees
+    total_cents         BIGINT NOT NULL,
+    created_at          TIMESTAMP NOT NULL,
+    UNIQUE (user_id, month)
+);
+
+CREATE TABLE IF NOT EXISTS statement_trips (
+    id                  SERIAL PRIMARY KEY,
+    statement_id        INT NOT NULL REFERENCES monthly_statements(id),
+    reservation_id      INT NOT NULL REFERENCES reservations(id),
+    invoice_id          INT NOT NULL REFERENCES invoices(id),
+    vehicle_model       VARCHAR(100) NOT NULL,
+    vehicle_type        VARCHAR(50) NOT NULL,
+    start_time          TIMESTAMP NOT NULL,
+    returned_at         TIMESTAMP NOT NULL,
+    minutes             INT NOT NULL,
+    rental_cents        BIGINT NOT NULL,
+    tier_savings_cents  BIGINT NOT NULL,
+    promo_savings_cents BIGINT NOT NULL,
+    total_cents         BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_statement_trips_statement ON statement_trips (statement_id);
@@ -0,0 +1,38 @@
+-- Per-vehicle-type prices. Cards are never edited: a price change is a new
+-- version, and each invoice keeps the card it was priced with.
+CREATE TABLE IF NOT EXISTS rate_cards (
+    id                       SERIAL PRIMARY KEY,
+    vehicle_type             VARCHAR(50) NOT NULL,
+    version                  INT NOT NULL,
+    hourly_rate_cents        BIGINT NOT NULL,
+    per_km_cents             BIGINT NOT NULL,
+    included_km              INT NOT NULL,    -- free distance per trip
+    energy_cents_per_percent BIGINT NOT NULL, -- per percentage point of charge or fuel used
+    effective_from           TIMESTAMP NOT NULL,
+    created_by               INT,
+    created_at               TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (vehicle_type, version)
+);
+
+INSERT INTO rate_cards (vehicle_type, version, hourly_rate_cents, per_km_cents, included_km, energy_cents_per_percent, effective_from) VALUES
+    ('Sedan',    1, 1200, 25, 20, 30, '2024-01-01'),
+    ('SUV',      1, 1800, 35, 20, 40, '2024-01-01'),
+    ('Electric', 1, 1500, 20, 20, 10, '2024-01-01'),
+    ('Van',      1, 2000, 40, 20, 45, '2024-01-01')
+ON CONFLICT (vehicle_type, version) DO NOTHING;
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS rate_card_id INT REFERENCES rate_cards(id);
+
+-- Usage charged at trip end that the card couldn't cover is owed, not
+-- written off
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS balance_due_cents BIGINT NOT NULL DEFAULT 0;
+
+-- Reported by the vehicle agent alongside charge level
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS odometer_km INT NOT NULL DEFAULT 0;
+
+-- Readings taken when the trip actually starts (first unlock) and ends
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS picked_up_at TIMESTAMP;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS pickup_odometer_km INT;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS pickup_charge_level INT;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS return_odometer_km INT;
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS return_charge_level INT;
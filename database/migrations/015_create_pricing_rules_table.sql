@@ -0,0 +1,33 @@
+-- Time-of-day / day-of-week multipliers on the hourly rate. Minutes are
+-- local time; a rule covers [start_minute, end_minute) on each listed day.
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id                 SERIAL PRIMARY KEY,
+    name               VARCHAR(100) NOT NULL UNIQUE,
+    vehicle_type       VARCHAR(50), -- NULL for every type
+    days_of_week       INT[] NOT NULL, -- 0 = Sunday ... 6 = Saturday
+    start_minute       INT NOT NULL CHECK (start_minute >= 0 AND start_minute < 1440),
+    end_minute         INT NOT NULL CHECK (end_minute > start_minute AND end_minute <= 1440),
+    multiplier_percent INT NOT NULL CHECK (multiplier_percent > 0), -- 130 = 30% more
+    active             BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO pricing_rules (name, days_of_week, start_minute, end_minute, multiplier_percent) VALUES
+    ('Weekend morning peak', '{0,6}',        480,  720, 130),
+    ('Weekday rush hour',    '{1,2,3,4,5}', 1020, 1140, 115),
+    ('Late night',           '{0,1,2,3,4,5,6}', 1320, 1440, 85),
+    ('Early morning',        '{0,1,2,3,4,5,6}',    0,  360, 85)
+ON CONFLICT (name) DO NOTHING;
+
+-- Demand surcharges by how much of a zone's fleet is booked. The highest
+-- threshold reached applies; an empty table turns demand pricing off.
+CREATE TABLE IF NOT EXISTS demand_multipliers (
+    utilization_percent INT PRIMARY KEY CHECK (utilization_percent BETWEEN 0 AND 100),
+    multiplier_percent  INT NOT NULL CHECK (multiplier_percent >= 100)
+);
+
+INSERT INTO demand_multipliers (utilization_percent, multiplier_percent) VALUES
+    (70, 110),
+    (85, 125),
+    (95, 150)
+ON CONFLICT (utilization_percent) DO NOTHING;
@@ -0,0 +1,49 @@
+-- Fines charged automatically when a trip ends. threshold means grace
+-- minutes for late returns and the minimum charge level for low_charge.
+CREATE TABLE IF NOT EXISTS penalty_rules (
+    kind           VARCHAR(32) PRIMARY KEY, -- late_return, low_charge, dirty, out_of_zone
+    threshold      INT NOT NULL DEFAULT 0,
+    amount_cents   BIGINT NOT NULL DEFAULT 0, -- flat part
+    per_unit_cents BIGINT NOT NULL DEFAULT 0, -- per late minute beyond the grace period
+    max_cents      BIGINT,                    -- cap, NULL for none
+    active         BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO penalty_rules (kind, threshold, amount_cents, per_unit_cents, max_cents) VALUES
+    ('late_return', 15,    0,  50, 5000),
+    ('low_charge',  20, 1500,   0, NULL),
+    ('dirty',        0, 2500,   0, NULL),
+    ('out_of_zone',  0, 3000,   0, NULL)
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS fines (
+    id             SERIAL PRIMARY KEY,
+    reservation_id INT NOT NULL REFERENCES reservations(id),
+    invoice_id     INT NOT NULL REFERENCES invoices(id),
+    user_id        INT NOT NULL,
+    kind           VARCHAR(32) NOT NULL,
+    description    TEXT NOT NULL,
+    amount_cents   BIGINT NOT NULL,
+    status         VARCHAR(16) NOT NULL DEFAULT 'Charged', -- Charged, Disputed
+    dispute_reason TEXT NOT NULL DEFAULT '',
+    disputed_at    TIMESTAMP,
+    created_at     TIMESTAMP NOT NULL,
+    UNIQUE (reservation_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fines_user ON fines (user_id, created_at);
+
+-- The invoice line a fine was charged as; disputing a fine disputes the line
+ALTER TABLE fines ADD COLUMN IF NOT EXISTS invoice_line_id INT REFERENCES invoice_lines(id);
+
+UPDATE fines f SET invoice_line_id = l.id
+FROM invoice_lines l
+WHERE f.invoice_line_id IS NULL AND l.invoice_id = f.invoice_id AND l.kind = 'penalty'
+  AND l.description = f.description AND l.amount_cents = f.amount_cents;
+
+-- Where and in what state the trip started and ended, for out-of-zone
+-- and cleanliness fines
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS pickup_location VARCHAR(255);
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS return_location VARCHAR(255);
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS pickup_cleanliness VARCHAR(50);
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS return_cleanliness VARCHAR(50);
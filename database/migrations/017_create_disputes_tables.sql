@@ -0,0 +1,53 @@
+-- Users dispute a single invoice line; support resolves it, possibly with a
+-- refund to the card or the wallet
+CREATE TABLE IF NOT EXISTS invoice_disputes (
+    id              SERIAL PRIMARY KEY,
+    invoice_id      INT NOT NULL REFERENCES invoices(id),
+    invoice_line_id INT NOT NULL REFERENCES invoice_lines(id),
+    user_id         INT NOT NULL,
+    reason          TEXT NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'Open', -- Open, Refunding, Approved, Rejected
+    refund_cents    BIGINT NOT NULL DEFAULT 0,
+    resolved_by     INT,
+    resolution_note TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL,
+    resolved_at     TIMESTAMP
+);
+
+-- One unresolved dispute per line at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoice_disputes_open_line
+    ON invoice_disputes (invoice_line_id) WHERE status IN ('Open', 'Refunding');
+
+CREATE INDEX IF NOT EXISTS idx_invoice_disputes_status ON invoice_disputes (status, created_at);
+
+-- A refund is written as Pending before any money moves and completed once
+-- it has; a Pending refund is resumed, never paid out a second time
+CREATE TABLE IF NOT EXISTS refunds (
+    id                 SERIAL PRIMARY KEY,
+    dispute_id         INT NOT NULL REFERENCES invoice_disputes(id),
+    invoice_id         INT NOT NULL REFERENCES invoices(id),
+    user_id            INT NOT NULL,
+    amount_cents       BIGINT NOT NULL,
+    method             VARCHAR(16) NOT NULL, -- card, wallet
+    status             VARCHAR(16) NOT NULL DEFAULT 'Pending', -- Pending, Completed, Failed
+    provider_reference VARCHAR(128) NOT NULL DEFAULT '',
+    created_by         INT NOT NULL,
+    created_at         TIMESTAMP NOT NULL,
+    completed_at       TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_pending_dispute
+    ON refunds (dispute_id) WHERE status = 'Pending';
+
+-- Audit trail: everything that happened to a dispute, by whom
+CREATE TABLE IF NOT EXISTS dispute_events (
+    id           SERIAL PRIMARY KEY,
+    dispute_id   INT NOT NULL REFERENCES invoice_disputes(id),
+    actor_id     INT NOT NULL,
+    action       VARCHAR(32) NOT NULL, -- opened, approved, rejected, refunded, refund_failed
+    amount_cents BIGINT NOT NULL DEFAULT 0,
+    note         TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispute_events_dispute ON dispute_events (dispute_id, created_at);
@@ -0,0 +1,74 @@
+-- Tax is added on top of prices. Each region bills in one currency; a rate
+-- change is a new row with a later effective_from, and each invoice keeps
+-- the rate it was opened with.
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id             SERIAL PRIMARY KEY,
+    region         VARCHAR(32) NOT NULL,
+    currency       CHAR(3) NOT NULL,     -- ISO 4217
+    name           VARCHAR(32) NOT NULL, -- shown on invoices, e.g. GST
+    rate_bps       INT NOT NULL,         -- basis points, 900 = 9%
+    effective_from TIMESTAMP NOT NULL,
+    created_by     INT,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (region, effective_from)
+);
+
+INSERT INTO tax_rates (region, currency, name, rate_bps, effective_from) VALUES
+    ('SG', 'SGD', 'GST', 800, '2023-01-01'),
+    ('SG', 'SGD', 'GST', 900, '2024-01-01')
+ON CONFLICT (region, effective_from) DO NOTHING;
+
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS region VARCHAR(32) NOT NULL DEFAULT 'SG';
+
+-- Amounts everywhere are integer minor units of the row's currency
+ALTER TABLE rate_cards ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'SGD';
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'SGD';
+
+-- Fines are set per currency and only apply to invoices in it. Fixed
+-- promo discounts are in a currency too; percentages work in any.
+ALTER TABLE penalty_rules ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'SGD';
+ALTER TABLE penalty_rules DROP CONSTRAINT IF EXISTS penalty_rules_pkey;
+ALTER TABLE penalty_rules ADD PRIMARY KEY (kind, currency);
+
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS currency CHAR(3);
+UPDATE promotions SET currency = 'SGD' WHERE discount_type = 'fixed' AND currency IS NULL;
+
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'SGD';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS subtotal_cents BIGINT;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS tax_name VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS tax_rate_bps INT NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS tax_cents BIGINT NOT NULL DEFAULT 0;
+
+-- Invoices from before tax was charged stay untaxed
+UPDATE invoices SET subtotal_cents = total_cents WHERE subtotal_cents IS NULL;
+ALTER TABLE invoices ALTER COLUMN subtotal_cents SET DEFAULT 0;
+ALTER TABLE invoices ALTER COLUMN subtotal_cents SET NOT NULL;
+
+-- Statements total each currency separately. The single totals on
+-- monthly_statements are superseded; statements built before are carried
+-- over as SGD.
+ALTER TABLE statement_trips ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'SGD';
+
+CREATE TABLE IF NOT EXISTS statement_totals (
+    statement_id        INT NOT NULL REFERENCES monthly_statements(id),
+    currency            CHAR(3) NOT NULL,
+    trip_count          INT NOT NULL,
+    rental_cents        BIGINT NOT NULL,
+    tier_savings_cents  BIGINT NOT NULL,
+    promo_savings_cents BIGINT NOT NULL,
+    fees_cents          BIGINT NOT NULL,
+    total_cents         BIGINT NOT NULL,
+    PRIMARY KEY (statement_id, currency)
+);
+
+INSERT INTO statement_totals (statement_id, currency, trip_count, rental_cents, tier_savings_cents,
+                              promo_savings_cents, fees_cents, total_cents)
+SELECT s.id, 'SGD', s.trip_count, s.rental_cents, s.tier_savings_cents, s.promo_savings_cents, s.fees_cents, s.total_cents
+FROM monthly_statements s
+WHERE NOT EXISTS (SELECT 1 FROM statement_totals t WHERE t.statement_id = s.id);
+
+ALTER TABLE monthly_statements ALTER COLUMN rental_cents SET DEFAULT 0;
+ALTER TABLE monthly_statements ALTER COLUMN tier_savings_cents SET DEFAULT 0;
+ALTER TABLE monthly_statements ALTER COLUMN promo_savings_cents SET DEFAULT 0;
+ALTER TABLE monthly_statements ALTER COLUMN fees_cents SET DEFAULT 0;
+ALTER TABLE monthly_statements ALTER COLUMN total_cents SET DEFAULT 0;
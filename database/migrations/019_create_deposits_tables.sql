@@ -0,0 +1,37 @@
+-- Security deposits held on top of the rental price. A vehicle type and tier
+-- without a row in the currency takes no deposit.
+CREATE TABLE IF NOT EXISTS deposit_rules (
+    vehicle_type VARCHAR(50) NOT NULL,
+    tier         VARCHAR(50) NOT NULL,
+    currency     CHAR(3) NOT NULL DEFAULT 'SGD',
+    amount_cents BIGINT NOT NULL,
+    updated_by   INT,
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (vehicle_type, tier, currency)
+);
+
+INSERT INTO deposit_rules (vehicle_type, tier, currency, amount_cents) VALUES
+    ('SUV',      'Basic',   'SGD', 30000),
+    ('SUV',      'Premium', 'SGD', 15000),
+    ('Van',      'Basic',   'SGD', 30000),
+    ('Van',      'Premium', 'SGD', 15000),
+    ('Electric', 'Basic',   'SGD', 20000),
+    ('Electric', 'Premium', 'SGD', 10000)
+ON CONFLICT (vehicle_type, tier, currency) DO NOTHING;
+
+-- One deposit per reservation: authorized at booking, then released or
+-- partly captured when the trip ends
+CREATE TABLE IF NOT EXISTS deposits (
+    id               SERIAL PRIMARY KEY,
+    reservation_id   INT NOT NULL UNIQUE REFERENCES reservations(id),
+    user_id          INT NOT NULL,
+    provider         VARCHAR(32) NOT NULL,
+    reference        VARCHAR(128) NOT NULL DEFAULT '',
+    status           VARCHAR(16) NOT NULL, -- Authorized, Released, Captured, Failed
+    currency         CHAR(3) NOT NULL,
+    authorized_cents BIGINT NOT NULL DEFAULT 0,
+    captured_cents   BIGINT NOT NULL DEFAULT 0,
+    error_message    TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMP NOT NULL,
+    updated_at       TIMESTAMP NOT NULL
+);
@@ -0,0 +1,63 @@
+-- Companies whose employees book cars for work. Membership and invites are
+-- managed by the user service; cost centers, booking policies and company
+-- billing by the vehicle service.
+CREATE TABLE IF NOT EXISTS organizations (
+    id            SERIAL PRIMARY KEY,
+    name          VARCHAR(255) NOT NULL,
+    billing_email VARCHAR(255) NOT NULL, -- where company invoices go
+    created_by    INT NOT NULL REFERENCES users(id),
+    created_at    TIMESTAMP NOT NULL
+);
+
+-- A user belongs to at most one organization
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id INT NOT NULL REFERENCES organizations(id),
+    user_id         INT NOT NULL UNIQUE REFERENCES users(id),
+    role            VARCHAR(16) NOT NULL, -- Admin, Member
+    joined_at       TIMESTAMP NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS organization_invites (
+    id              SERIAL PRIMARY KEY,
+    organization_id INT NOT NULL REFERENCES organizations(id),
+    email           VARCHAR(255) NOT NULL,
+    role            VARCHAR(16) NOT NULL,
+    token           VARCHAR(64) NOT NULL UNIQUE,
+    status          VARCHAR(16) NOT NULL, -- Pending, Accepted, Revoked
+    invited_by      INT NOT NULL,
+    created_at      TIMESTAMP NOT NULL,
+    expires_at      TIMESTAMP NOT NULL,
+    accepted_at     TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_invites_org ON organization_invites (organization_id, status);
+
+CREATE TABLE IF NOT EXISTS cost_centers (
+    id              SERIAL PRIMARY KEY,
+    organization_id INT NOT NULL REFERENCES organizations(id),
+    code            VARCHAR(32) NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    active          BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMP NOT NULL,
+    UNIQUE (organization_id, code)
+);
+
+-- Limits on business bookings. NULL or empty means no limit.
+CREATE TABLE IF NOT EXISTS organization_policies (
+    organization_id       INT PRIMARY KEY REFERENCES organizations(id),
+    max_rental_hours      INT,
+    allowed_vehicle_types TEXT[] NOT NULL DEFAULT '{}',
+    weekdays_only         BOOLEAN NOT NULL DEFAULT FALSE,
+    require_cost_center   BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by            INT,
+    updated_at            TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Business bookings are billed to the organization instead of the driver
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS organization_id INT REFERENCES organizations(id);
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS cost_center_id INT REFERENCES cost_centers(id);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS organization_id INT REFERENCES organizations(id);
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS cost_center_id INT REFERENCES cost_centers(id);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_organization ON invoices (organization_id, created_at) WHERE organization_id IS NOT NULL;
@@ -0,0 +1,25 @@
+-- Every user has a code to share. A new user who signs up with it is
+-- recorded as a referral, and both users get wallet credit once the new
+-- user completes their first trip.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS referral_code VARCHAR(16) UNIQUE;
+
+UPDATE users SET referral_code = UPPER(SUBSTRING(MD5(id::text || email) FROM 1 FOR 8))
+WHERE referral_code IS NULL;
+
+CREATE TABLE IF NOT EXISTS referrals (
+    id                    SERIAL PRIMARY KEY,
+    referrer_id           INT NOT NULL REFERENCES users(id),
+    referee_id            INT NOT NULL UNIQUE REFERENCES users(id), -- a user is referred at most once
+    status                VARCHAR(16) NOT NULL, -- Pending, Rewarded, Rejected
+    reject_reason         TEXT NOT NULL DEFAULT '',
+    referrer_credit_cents BIGINT NOT NULL,      -- fixed at sign-up
+    referee_credit_cents  BIGINT NOT NULL,
+    created_at            TIMESTAMP NOT NULL,
+    rewarded_at           TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals (referrer_id);
+
+-- Referral credit is a marketing cost, paid out of its own account
+INSERT INTO ledger_accounts (user_id, kind) VALUES (NULL, 'referrals')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,27 @@
+-- Driver's licenses submitted for verification. A user may submit again,
+-- e.g. to renew; bookings are checked against their latest approved
+-- license. The holder's name, the license number and the images are
+-- encrypted by the user service (AES-GCM); only what bookings and the
+-- review queue need is kept in the clear.
+CREATE TABLE IF NOT EXISTS driver_licenses (
+    id                   SERIAL PRIMARY KEY,
+    user_id              INT NOT NULL REFERENCES users(id),
+    full_name_enc        BYTEA NOT NULL,
+    license_number_enc   BYTEA NOT NULL,
+    license_number_last4 VARCHAR(4) NOT NULL, -- shown masked
+    country              CHAR(2) NOT NULL,    -- ISO 3166-1 alpha-2
+    license_class        VARCHAR(16) NOT NULL DEFAULT '',
+    expires_on           DATE NOT NULL,       -- valid through the end of this day
+    front_image_enc      BYTEA NOT NULL,
+    front_image_type     VARCHAR(32) NOT NULL,
+    back_image_enc       BYTEA NOT NULL,
+    back_image_type      VARCHAR(32) NOT NULL,
+    status               VARCHAR(16) NOT NULL, -- Pending, Approved, Rejected
+    reject_reason        TEXT NOT NULL DEFAULT '',
+    submitted_at         TIMESTAMP NOT NULL,
+    reviewed_by          INT,
+    reviewed_at          TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_licenses_user ON driver_licenses (user_id, status);
+CREATE INDEX IF NOT EXISTS idx_driver_licenses_queue ON driver_licenses (status, submitted_at);
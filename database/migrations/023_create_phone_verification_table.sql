@@ -0,0 +1,45 @@
+-- Phone numbers are stored in E.164 form and verified with a one-time code
+-- sent by SMS. Changing the number clears the verification.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS phone_verification_codes (
+    id           SERIAL PRIMARY KEY,
+    user_id      INT NOT NULL REFERENCES users(id),
+    phone_number VARCHAR(16) NOT NULL,
+    code_hash    CHAR(64) NOT NULL, -- SHA-256, the code itself is never stored
+    attempts     INT NOT NULL DEFAULT 0,
+    created_at   TIMESTAMP NOT NULL,
+    expires_at   TIMESTAMP NOT NULL,
+    consumed_at  TIMESTAMP
+);
+
+-- Send limits are counted per user and per number
+CREATE INDEX IF NOT EXISTS idx_phone_codes_user ON phone_verification_codes (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_phone_codes_number ON phone_verification_codes (phone_number, created_at);
+
+-- Numbers saved before normalization was enforced are brought into E.164
+-- the same way phone.Normalize does it. Those that can't be converted are
+-- flagged so the user can be asked for the number again; saving a new
+-- number clears the flag.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_number_invalid BOOLEAN NOT NULL DEFAULT FALSE;
+
+WITH stripped AS (
+    SELECT id, regexp_replace(btrim(phone_number), '[ .()-]', '', 'g') AS number
+    FROM users
+    WHERE phone_number IS NOT NULL AND phone_number <> ''
+      AND phone_number !~ '^\+[1-9][0-9]{7,14}$'
+), converted AS (
+    SELECT id, CASE
+        WHEN number ~ '^\+[0-9]+$' THEN substr(number, 2)
+        WHEN number ~ '^00[0-9]+$' THEN substr(number, 3)
+        WHEN number ~ '^[0-9]+$' THEN '65' || regexp_replace(number, '^0', '')
+    END AS digits
+    FROM stripped
+)
+UPDATE users u SET phone_number = '+' || c.digits
+FROM converted c
+WHERE u.id = c.id AND c.digits ~ '^[1-9][0-9]{7,14}$';
+
+UPDATE users SET phone_number_invalid = TRUE
+WHERE phone_number IS NOT NULL AND phone_number <> ''
+  AND phone_number !~ '^\+[1-9][0-9]{7,14}$';
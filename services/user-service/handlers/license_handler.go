@@ -0,0 +1,287 @@
+// Path: services/user-service/handlers/license_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gorilla/mux"
+    "cnad-carsharinggo/services/user-service/models"
+    "cnad-carsharinggo/services/user-service/repository"
+)
+
+// Upload limits: each image may be up to 5 MB
+const (
+    maxLicenseImageBytes = 5 << 20
+    maxLicenseFormBytes  = 2*maxLicenseImageBytes + 1<<20
+)
+
+type LicenseHandler struct {
+    LicenseRepo *repository.LicenseRepository
+}
+
+func NewLicenseHandler(repo *repository.LicenseRepository) *LicenseHandler {
+    return &LicenseHandler{LicenseRepo: repo}
+}
+
+// SubmitLicense takes a multipart form with the license details and photos
+// of both sides (front_image, back_image; JPEG or PNG) and queues it for
+// review
+func (h *LicenseHandler) SubmitLicense(w http.ResponseWriter, r *http.Request) {
+    claims, ok := r.Context().Value("claims").(*Claims)
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    r.Body = http.MaxBytesReader(w, r.Body, maxLicenseFormBytes)
+    if err := r.ParseMultipartForm(maxLicenseFormBytes); err != nil {
+        http.Error(w, "Invalid form, expected multipart upload under 11 MB", http.StatusBadRequest)
+        return
+    }
+
+    sub := models.LicenseSubmission{
+        FullName:      strings.TrimSpace(r.FormValue("full_name")),
+        LicenseNumber: strings.ToUpper(strings.TrimSpace(r.FormValue("license_number"))),
+        Country:       strings.ToUpper(strings.TrimSpace(r.FormValue("country"))),
+        LicenseClass:  strings.ToUpper(strings.TrimSpace(r.FormValue("license_class"))),
+    }
+    if sub.FullName == "" || sub.LicenseNumber == "" {
+        http.Error(w, "full_name and license_number are required", http.StatusBadRequest)
+        return
+    }
+    if len(sub.Country) != 2 {
+        http.Error(w, "country must be a two-letter country code", http.StatusBadRequest)
+        return
+    }
+
+    expiresOn, err := time.Parse("2006-01-02", r.FormValue("expires_on"))
+    if err != nil {
+        http.Error(w, "Invalid expires_on, expected YYYY-MM-DD", http.StatusBadRequest)
+        return
+    }
+    sub.ExpiresOn = expiresOn
+
+    if sub.Front, ok = readLicenseImage(w, r, "front_image"); !ok {
+        return
+    }
+    if sub.Back, ok = readLicenseImage(w, r, "back_image"); !ok {
+        return
+    }
+
+    license, err := h.LicenseRepo.Submit(claims.UserID, sub)
+    switch err {
+    case nil:
+    case repository.ErrLicenseExpired:
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    case repository.ErrLicensePending:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to submit license: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(license)
+}
+
+// GetMyLicense shows the status of the caller's latest submission
+func (h *LicenseHandler) GetMyLicense(w http.ResponseWriter, r *http.Request) {
+    claims, ok := r.Context().Value("claims").(*Claims)
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    license, err := h.LicenseRepo.GetCurrentLicense(claims.UserID)
+    if err == repository.ErrLicenseNotFound {
+        http.Error(w, "No license submitted", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get license", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(license)
+}
+
+// GetLicenseQueue lists licenses for support, pending ones by default
+func (h *LicenseHandler) GetLicenseQueue(w http.ResponseWriter, r *http.Request) {
+    status := r.URL.Query().Get("status")
+    if status == "" {
+        status = models.LicensePending
+    }
+
+    licenses, err := h.LicenseRepo.GetLicensesByStatus(status)
+    if err != nil {
+        http.Error(w, "Failed to get licenses", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(licenses)
+}
+
+// GetLicenseDetails shows support the decrypted name and number
+func (h *LicenseHandler) GetLicenseDetails(w http.ResponseWriter, r *http.Request) {
+    licenseID, ok := licenseIDFromPath(w, r)
+    if !ok {
+        return
+    }
+
+    license, err := h.LicenseRepo.GetLicenseDetails(licenseID)
+    if err == repository.ErrLicenseNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get license: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Cache-Control", "no-store")
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(license)
+}
+
+// GetLicenseImage serves the decrypted front or back photo to support
+func (h *LicenseHandler) GetLicenseImage(w http.ResponseWriter, r *http.Request) {
+    licenseID, ok := licenseIDFromPath(w, r)
+    if !ok {
+        return
+    }
+
+    image, err := h.LicenseRepo.GetLicenseImage(licenseID, mux.Vars(r)["side"])
+    if err == repository.ErrLicenseNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get license image: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Cache-Control", "no-store")
+    w.Header().Set("Content-Type", image.ContentType)
+    w.Write(image.Data)
+}
+
+func (h *LicenseHandler) ApproveLicense(w http.ResponseWriter, r *http.Request) {
+    licenseID, ok := licenseIDFromPath(w, r)
+    if !ok {
+        return
+    }
+    claims := r.Context().Value("claims").(*Claims)
+
+    err := h.LicenseRepo.Approve(licenseID, claims.UserID)
+    switch err {
+    case nil:
+    case repository.ErrLicenseNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case repository.ErrLicenseNotPending, repository.ErrLicenseExpired:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to approve license: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    h.writeLicense(w, licenseID)
+}
+
+func (h *LicenseHandler) RejectLicense(w http.ResponseWriter, r *http.Request) {
+    licenseID, ok := licenseIDFromPath(w, r)
+    if !ok {
+        return
+    }
+    claims := r.Context().Value("claims").(*Claims)
+
+    var req models.RejectLicenseRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    req.Reason = strings.TrimSpace(req.Reason)
+    if req.Reason == "" {
+        http.Error(w, "A reason for the rejection is required", http.StatusBadRequest)
+        return
+    }
+
+    err := h.LicenseRepo.Reject(licenseID, claims.UserID, req.Reason)
+    switch err {
+    case nil:
+    case repository.ErrLicenseNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case repository.ErrLicenseNotPending:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to reject license: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    h.writeLicense(w, licenseID)
+}
+
+func (h *LicenseHandler) writeLicense(w http.ResponseWriter, licenseID int) {
+    license, err := h.LicenseRepo.GetLicense(licenseID, 0)
+    if err != nil {
+        http.Error(w, "Failed to get license", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(license)
+}
+
+func licenseIDFromPath(w http.ResponseWriter, r *http.Request) (int, bool) {
+    licenseID, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        http.Error(w, "Invalid license ID", http.StatusBadRequest)
+        return 0, false
+    }
+    return licenseID, true
+}
+
+// readLicenseImage reads one uploaded photo, accepting only JPEG and PNG
+func readLicenseImage(w http.ResponseWriter, r *http.Request, field string) (models.LicenseImage, bool) {
+    var image models.LicenseImage
+
+    file, _, err := r.FormFile(field)
+    if err != nil {
+        http.Error(w, field+" is required", http.StatusBadRequest)
+        return image, false
+    }
+    defer file.Close()
+
+    data, err := io.ReadAll(io.LimitReader(file, maxLicenseImageBytes+1))
+    if err != nil {
+        http.Error(w, "Failed to read "+field, http.StatusBadRequest)
+        return image, false
+    }
+    if len(data) > maxLicenseImageBytes {
+        http.Error(w, field+" must be 5 MB or smaller", http.StatusRequestEntityTooLarge)
+        return image, false
+    }
+
+    contentType := http.DetectContentType(data)
+    if contentType != "image/jpeg" && contentType != "image/png" {
+        http.Error(w, field+" must be a JPEG or PNG image", http.StatusBadRequest)
+        return image, false
+    }
+
+    image.ContentType = contentType
+    image.Data = data
+    return image, true
+}
@@ -0,0 +1,341 @@
+// Path: services/user-service/handlers/organization_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gorilla/mux"
+    "cnad-carsharinggo/services/user-service/models"
+    "cnad-carsharinggo/services/user-service/repository"
+)
+
+type OrganizationHandler struct {
+    OrgRepo *repository.OrganizationRepository
+}
+
+func NewOrganizationHandler(repo *repository.OrganizationRepository) *OrganizationHandler {
+    return &OrganizationHandler{OrgRepo: repo}
+}
+
+// CreateOrganization sets up a company account with the caller as its admin
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+    claims, ok := r.Context().Value("claims").(*Claims)
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    var req models.CreateOrganizationRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    req.Name = strings.TrimSpace(req.Name)
+    req.BillingEmail = strings.TrimSpace(req.BillingEmail)
+    if req.Name == "" || req.BillingEmail == "" {
+        http.Error(w, "Name and billing email are required", http.StatusBadRequest)
+        return
+    }
+
+    org := &models.Organization{Name: req.Name, BillingEmail: req.BillingEmail}
+    err := h.OrgRepo.CreateOrganization(org, claims.UserID)
+    if err == repository.ErrAlreadyInOrganization {
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to create organization: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(org)
+}
+
+func (h *OrganizationHandler) GetMyOrganization(w http.ResponseWriter, r *http.Request) {
+    claims, ok := r.Context().Value("claims").(*Claims)
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    membership, err := h.OrgRepo.GetMembership(claims.UserID)
+    if err == repository.ErrNotMember {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get organization", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(membership)
+}
+
+func (h *OrganizationHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+    orgID, _, ok := h.requireAdmin(w, r)
+    if !ok {
+        return
+    }
+
+    members, err := h.OrgRepo.GetMembers(orgID)
+    if err != nil {
+        http.Error(w, "Failed to get members", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(members)
+}
+
+func (h *OrganizationHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+    orgID, _, ok := h.requireAdmin(w, r)
+    if !ok {
+        return
+    }
+
+    memberID, err := strconv.Atoi(mux.Vars(r)["userID"])
+    if err != nil {
+        http.Error(w, "Invalid user ID", http.StatusBadRequest)
+        return
+    }
+
+    var req models.UpdateMemberRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    if !validOrgRole(req.Role) {
+        http.Error(w, "Role must be Admin or Member", http.StatusBadRequest)
+        return
+    }
+
+    err = h.OrgRepo.UpdateMemberRole(orgID, memberID, req.Role)
+    switch err {
+    case nil:
+    case repository.ErrNotMember:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case repository.ErrLastAdmin:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to update member: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Member updated successfully",
+    })
+}
+
+// RemoveMember lets an admin remove anyone, and any member leave on their own
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+    claims, ok := r.Context().Value("claims").(*Claims)
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    vars := mux.Vars(r)
+    orgID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid organization ID", http.StatusBadRequest)
+        return
+    }
+    memberID, err := strconv.Atoi(vars["userID"])
+    if err != nil {
+        http.Error(w, "Invalid user ID", http.StatusBadRequest)
+        return
+    }
+
+    if memberID != claims.UserID {
+        if _, _, ok := h.requireAdmin(w, r); !ok {
+            return
+        }
+    }
+
+    err = h.OrgRepo.RemoveMember(orgID, memberID)
+    switch err {
+    case nil:
+    case repository.ErrNotMember:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case repository.ErrLastAdmin:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to remove member: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Member removed successfully",
+    })
+}
+
+// InviteMember creates an invite for an email address. There is no mailer
+// yet, so the token is returned to the admin to pass on.
+func (h *OrganizationHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+    orgID, claims, ok := h.requireAdmin(w, r)
+    if !ok {
+        return
+    }
+
+    var req models.InviteRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    req.Email = strings.TrimSpace(req.Email)
+    if req.Email == "" {
+        http.Error(w, "Email is required", http.StatusBadRequest)
+        return
+    }
+    if req.Role == "" {
+        req.Role = models.OrgRoleMember
+    }
+    if !validOrgRole(req.Role) {
+        http.Error(w, "Role must be Admin or Member", http.StatusBadRequest)
+        return
+    }
+
+    invite := &models.OrganizationInvite{
+        OrganizationID: orgID,
+        Email:          req.Email,
+        Role:           req.Role,
+        InvitedBy:      claims.UserID,
+    }
+    err := h.OrgRepo.CreateInvite(invite)
+    if err == repository.ErrInvitePending {
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to create invite: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    log.Printf("Organization %d invited %s (invite %d)", orgID, invite.Email, invite.ID)
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(invite)
+}
+
+func (h *OrganizationHandler) GetInvites(w http.ResponseWriter, r *http.Request) {
+    orgID, _, ok := h.requireAdmin(w, r)
+    if !ok {
+        return
+    }
+
+    invites, err := h.OrgRepo.GetPendingInvites(orgID)
+    if err != nil {
+        http.Error(w, "Failed to get invites", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(invites)
+}
+
+func (h *OrganizationHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+    orgID, _, ok := h.requireAdmin(w, r)
+    if !ok {
+        return
+    }
+
+    inviteID, err := strconv.Atoi(mux.Vars(r)["inviteID"])
+    if err != nil {
+        http.Error(w, "Invalid invite ID", http.StatusBadRequest)
+        return
+    }
+
+    err = h.OrgRepo.RevokeInvite(orgID, inviteID)
+    if err == repository.ErrInviteNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to revoke invite: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Invite revoked",
+    })
+}
+
+func (h *OrganizationHandler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+    claims, ok := r.Context().Value("claims").(*Claims)
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    membership, err := h.OrgRepo.AcceptInvite(mux.Vars(r)["token"], claims.UserID)
+    switch err {
+    case nil:
+    case repository.ErrInviteNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case repository.ErrInviteExpired:
+        http.Error(w, err.Error(), http.StatusGone)
+        return
+    case repository.ErrInviteWrongUser:
+        http.Error(w, err.Error(), http.StatusForbidden)
+        return
+    case repository.ErrAlreadyInOrganization:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to accept invite: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(membership)
+}
+
+// requireAdmin checks that the caller is an admin of the organization in the
+// path and writes the error response if not
+func (h *OrganizationHandler) requireAdmin(w http.ResponseWriter, r *http.Request) (int, *Claims, bool) {
+    claims, ok := r.Context().Value("claims").(*Claims)
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return 0, nil, false
+    }
+
+    orgID, err := strconv.Atoi(mux.Vars(r)["id"])
+    if err != nil {
+        http.Error(w, "Invalid organization ID", http.StatusBadRequest)
+        return 0, nil, false
+    }
+
+    membership, err := h.OrgRepo.GetMembership(claims.UserID)
+    if err != nil && err != repository.ErrNotMember {
+        http.Error(w, "Failed to check membership", http.StatusInternalServerError)
+        return 0, nil, false
+    }
+    if membership == nil || membership.Organization.ID != orgID || membership.Role != models.OrgRoleAdmin {
+        http.Error(w, "Only organization admins can do this", http.StatusForbidden)
+        return 0, nil, false
+    }
+
+    return orgID, claims, true
+}
+
+func validOrgRole(role string) bool {
+    return role == models.OrgRoleAdmin || role == models.OrgRoleMember
+}
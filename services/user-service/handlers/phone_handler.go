@@ -0,0 +1,105 @@
+// Path: services/user-service/handlers/phone_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+    "strconv"
+
+    "cnad-carsharinggo/services/user-service/phone"
+    "cnad-carsharinggo/services/user-service/repository"
+    "cnad-carsharinggo/services/user-service/sms"
+)
+
+type PhoneHandler struct {
+    PhoneRepo *repository.PhoneRepository
+    SMS       sms.Sender
+}
+
+func NewPhoneHandler(repo *repository.PhoneRepository, sender sms.Sender) *PhoneHandler {
+    return &PhoneHandler{PhoneRepo: repo, SMS: sender}
+}
+
+type VerifyPhoneRequest struct {
+    Code string `json:"code"`
+}
+
+// SendCode texts a one-time code to the phone number on the caller's
+// profile
+func (h *PhoneHandler) SendCode(w http.ResponseWriter, r *http.Request) {
+    claims, ok := r.Context().Value("claims").(*Claims)
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    code, err := phone.NewCode()
+    if err != nil {
+        http.Error(w, "Failed to send code: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    number, err := h.PhoneRepo.CreateCode(claims.UserID, phone.HashCode(code))
+    if rl, ok := err.(*repository.RateLimitError); ok {
+        w.Header().Set("Retry-After", strconv.Itoa(int(rl.RetryAfter.Seconds()+0.5)))
+        http.Error(w, rl.Error(), http.StatusTooManyRequests)
+        return
+    }
+    if err == repository.ErrNoPhoneNumber || err == repository.ErrPhoneVerified || err == repository.ErrPhoneInvalid {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to send code: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    body := "Your CarSharingGO verification code is " + code + ". It expires in 10 minutes."
+    if err := h.SMS.Send(number, body); err != nil {
+        log.Printf("SMS send error for user %d: %v", claims.UserID, err)
+        http.Error(w, "Failed to send code: "+err.Error(), http.StatusBadGateway)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]string{
+        "message":      "Verification code sent",
+        "phone_number": number,
+    })
+}
+
+// VerifyCode checks the code the user received and marks their phone
+// number verified
+func (h *PhoneHandler) VerifyCode(w http.ResponseWriter, r *http.Request) {
+    claims, ok := r.Context().Value("claims").(*Claims)
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+
+    var req VerifyPhoneRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+        http.Error(w, "Invalid request body, code is required", http.StatusBadRequest)
+        return
+    }
+
+    err := h.PhoneRepo.VerifyCode(claims.UserID, phone.HashCode(req.Code))
+    switch err {
+    case nil:
+    case repository.ErrNoActiveCode, repository.ErrWrongCode:
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    case repository.ErrTooManyAttempts:
+        http.Error(w, err.Error(), http.StatusTooManyRequests)
+        return
+    default:
+        http.Error(w, "Failed to verify code: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Phone number verified",
+    })
+}
@@ -1,150 +1,192 @@
-// Path: services/user-service/main.go
-package main
-
-import (
-    "database/sql"
-    "fmt"
-    "log"
-    "net/http"
-    "os"
-    "time"
-    "path/filepath"
-
-    gorillaCORS "github.com/gorilla/handlers"
-    "github.com/gorilla/mux"
-    _ "github.com/lib/pq"
-
-    userHandlers "cnad-carsharinggo/services/user-service/handlers"
-    "cnad-carsharinggo/services/user-service/repository"
-    "cnad-carsharinggo/services/user-service/middleware"
-)
-
-// Configuration constants
-const (
-    defaultPort = ":8080"
-    dbConnRetries = 5
-    dbConnRetryDelay = 5 * time.Second
-)
-
-func initDB(connStr string) (*sql.DB, error) {
-    var db *sql.DB
-    var err error
-
-    // Try to connect to the database with retries
-    for i := 0; i < dbConnRetries; i++ {
-        db, err = sql.Open("postgres", connStr)
-        if err != nil {
-            log.Printf("Failed to open database connection (attempt %d/%d): %v", i+1, dbConnRetries, err)
-            time.Sleep(dbConnRetryDelay)
-            continue
-        }
-
-        // Test the connection
-        err = db.Ping()
-        if err == nil {
-            log.Println("Successfully connected to the database")
-            break
-        }
-
-        log.Printf("Failed to ping database (attempt %d/%d): %v", i+1, dbConnRetries, err)
-        db.Close()
-        time.Sleep(dbConnRetryDelay)
-    }
-
-    if err != nil {
-        return nil, fmt.Errorf("failed to establish database connection after %d attempts: %v", dbConnRetries, err)
-    }
-
-    // Configure connection pool
-    db.SetMaxOpenConns(25)
-    db.SetMaxIdleConns(25)
-    db.SetConnMaxLifetime(5 * time.Minute)
-
-    return db, nil
-}
-
-func setupRoutes(userHandler *userHandlers.UserHandler) *mux.Router {
-    r := mux.NewRouter()
-
-    // API routes
-    api := r.PathPrefix("/users").Subrouter()
-    api.HandleFunc("/register", userHandler.RegisterUser).Methods("POST", "OPTIONS")
-    api.HandleFunc("/login", userHandler.LoginUser).Methods("POST", "OPTIONS")
-    api.HandleFunc("/{id}/profile", middleware.AuthMiddleware(userHandler.UpdateUserProfile)).Methods("PUT", "OPTIONS")
-
-    // Create FileServer
-    fs := http.FileServer(http.Dir("frontend"))
-    
-    // This will serve index.html at the root path
-    r.HandleFunc("/", serveIndex)
-    
-    // Serve static files
-    r.PathPrefix("/").Handler(http.StripPrefix("/", fs))
-
-    return r
-}
-
-// serveIndex serves the index.html file
-func serveIndex(w http.ResponseWriter, r *http.Request) {
-    // Get the absolute path to the frontend directory
-    frontendDir := filepath.Join(".", "frontend")
-    
-    // Serve index.html
-    http.ServeFile(w, r, filepath.Join(frontendDir, "index.html"))
-}
-
-func setupCORS(handler http.Handler) http.Handler {
-    return gorillaCORS.CORS(
-        gorillaCORS.AllowedOrigins([]string{"*"}),
-        gorillaCORS.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-        gorillaCORS.AllowedHeaders([]string{"Content-Type", "Authorization"}),
-        gorillaCORS.MaxAge(86400), // 24 hours
-    )(handler)
-}
-
-func main() {
-    // Setup logging
-    log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-    
-    // Database connection string
-    connStr := "postgres://postgres.wjdhhzmaclmsvaiszagk:22KC6282t04@@aws-0-ap-southeast-1.pooler.supabase.com:6543/postgres"
-    
-    // Initialize database connection
-    db, err := initDB(connStr)
-    if err != nil {
-        log.Fatal("Failed to initialize database:", err)
-    }
-    defer db.Close()
-
-    // Initialize repositories and handlers
-    userRepo := repository.NewUserRepository(db)
-    userHandler := userHandlers.NewUserHandler(userRepo)
-
-    // Setup routes
-    router := setupRoutes(userHandler)
-
-    // Setup CORS
-    corsHandler := setupCORS(router)
-
-    // Determine port
-    port := os.Getenv("PORT")
-    if port == "" {
-        port = defaultPort
-    }
-
-    // Create server
-    server := &http.Server{
-        Addr:         port,
-        Handler:      corsHandler,
-        ReadTimeout:  15 * time.Second,
-        WriteTimeout: 15 * time.Second,
-        IdleTimeout:  60 * time.Second,
-    }
-
-    // Start server
-    log.Printf("User Service starting on port %s\n", port)
-    log.Printf("Serving frontend from: %s\n", filepath.Join(".", "frontend"))
-    if err := server.ListenAndServe(); err != nil {
-        log.Fatal("Server failed to start:", err)
-    }
-}
\ No newline at end of file
+// Path: services/user-service/main.go
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "time"
+    "path/filepath"
+
+    gorillaCORS "github.com/gorilla/handlers"
+    "github.com/gorilla/mux"
+    _ "github.com/lib/pq"
+
+    userHandlers "cnad-carsharinggo/services/user-service/handlers"
+    "cnad-carsharinggo/services/user-service/repository"
+    "cnad-carsharinggo/services/user-service/middleware"
+    "cnad-carsharinggo/services/user-service/secure"
+    "cnad-carsharinggo/services/user-service/sms"
+)
+
+// Configuration constants
+const (
+    defaultPort = ":8080"
+    dbConnRetries = 5
+    dbConnRetryDelay = 5 * time.Second
+)
+
+func initDB(connStr string) (*sql.DB, error) {
+    var db *sql.DB
+    var err error
+
+    // Try to connect to the database with retries
+    for i := 0; i < dbConnRetries; i++ {
+        db, err = sql.Open("postgres", connStr)
+        if err != nil {
+            log.Printf("Failed to open database connection (attempt %d/%d): %v", i+1, dbConnRetries, err)
+            time.Sleep(dbConnRetryDelay)
+            continue
+        }
+
+        // Test the connection
+        err = db.Ping()
+        if err == nil {
+            log.Println("Successfully connected to the database")
+            break
+        }
+
+        log.Printf("Failed to ping database (attempt %d/%d): %v", i+1, dbConnRetries, err)
+        db.Close()
+        time.Sleep(dbConnRetryDelay)
+    }
+
+    if err != nil {
+        return nil, fmt.Errorf("failed to establish database connection after %d attempts: %v", dbConnRetries, err)
+    }
+
+    // Configure connection pool
+    db.SetMaxOpenConns(25)
+    db.SetMaxIdleConns(25)
+    db.SetConnMaxLifetime(5 * time.Minute)
+
+    return db, nil
+}
+
+func setupRoutes(userHandler *userHandlers.UserHandler, orgHandler *userHandlers.OrganizationHandler, licenseHandler *userHandlers.LicenseHandler, phoneHandler *userHandlers.PhoneHandler, userRepo *repository.UserRepository) *mux.Router {
+    r := mux.NewRouter()
+
+    // API routes
+    api := r.PathPrefix("/users").Subrouter()
+    api.HandleFunc("/register", userHandler.RegisterUser).Methods("POST", "OPTIONS")
+    api.HandleFunc("/login", userHandler.LoginUser).Methods("POST", "OPTIONS")
+    api.HandleFunc("/{id}/profile", middleware.AuthMiddleware(userHandler.UpdateUserProfile)).Methods("PUT", "OPTIONS")
+    api.HandleFunc("/referrals", middleware.AuthMiddleware(userHandler.GetReferrals)).Methods("GET", "OPTIONS")
+
+    // Phone verification routes
+    api.HandleFunc("/phone/send-code", middleware.AuthMiddleware(phoneHandler.SendCode)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/phone/verify", middleware.AuthMiddleware(phoneHandler.VerifyCode)).Methods("POST", "OPTIONS")
+
+    // Driver's license routes
+    api.HandleFunc("/license", middleware.AuthMiddleware(licenseHandler.SubmitLicense)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/license", middleware.AuthMiddleware(licenseHandler.GetMyLicense)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/support/licenses", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, licenseHandler.GetLicenseQueue))).Methods("GET", "OPTIONS")
+    api.HandleFunc("/support/licenses/{id}", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, licenseHandler.GetLicenseDetails))).Methods("GET", "OPTIONS")
+    api.HandleFunc("/support/licenses/{id}/images/{side}", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, licenseHandler.GetLicenseImage))).Methods("GET", "OPTIONS")
+    api.HandleFunc("/support/licenses/{id}/approve", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, licenseHandler.ApproveLicense))).Methods("POST", "OPTIONS")
+    api.HandleFunc("/support/licenses/{id}/reject", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, licenseHandler.RejectLicense))).Methods("POST", "OPTIONS")
+
+    // Organization routes
+    api.HandleFunc("/organizations", middleware.AuthMiddleware(orgHandler.CreateOrganization)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/organizations/mine", middleware.AuthMiddleware(orgHandler.GetMyOrganization)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/organizations/invites/{token}/accept", middleware.AuthMiddleware(orgHandler.AcceptInvite)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/organizations/{id}/members", middleware.AuthMiddleware(orgHandler.GetMembers)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/organizations/{id}/members/{userID}", middleware.AuthMiddleware(orgHandler.UpdateMember)).Methods("PUT", "OPTIONS")
+    api.HandleFunc("/organizations/{id}/members/{userID}", middleware.AuthMiddleware(orgHandler.RemoveMember)).Methods("DELETE", "OPTIONS")
+    api.HandleFunc("/organizations/{id}/invites", middleware.AuthMiddleware(orgHandler.InviteMember)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/organizations/{id}/invites", middleware.AuthMiddleware(orgHandler.GetInvites)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/organizations/{id}/invites/{inviteID}", middleware.AuthMiddleware(orgHandler.RevokeInvite)).Methods("DELETE", "OPTIONS")
+
+    // Create FileServer
+    fs := http.FileServer(http.Dir("frontend"))
+    
+    // This will serve index.html at the root path
+    r.HandleFunc("/", serveIndex)
+    
+    // Serve static files
+    r.PathPrefix("/").Handler(http.StripPrefix("/", fs))
+
+    return r
+}
+
+// serveIndex serves the index.html file
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+    // Get the absolute path to the frontend directory
+    frontendDir := filepath.Join(".", "frontend")
+    
+    // Serve index.html
+    http.ServeFile(w, r, filepath.Join(frontendDir, "index.html"))
+}
+
+func setupCORS(handler http.Handler) http.Handler {
+    return gorillaCORS.CORS(
+        gorillaCORS.AllowedOrigins([]string{"*"}),
+        gorillaCORS.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+        gorillaCORS.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+        gorillaCORS.MaxAge(86400), // 24 hours
+    )(handler)
+}
+
+func main() {
+    // Setup logging
+    log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+    
+    // Database connection string
+    connStr := "postgres://postgres.wjdhhzmaclmsvaiszagk:22KC6282t04@@aws-0-ap-southeast-1.pooler.supabase.com:6543/postgres"
+    
+    // Initialize database connection
+    db, err := initDB(connStr)
+    if err != nil {
+        log.Fatal("Failed to initialize database:", err)
+    }
+    defer db.Close()
+
+    // Initialize repositories and handlers
+    userRepo := repository.NewUserRepository(db)
+    referralRepo := repository.NewReferralRepository(db)
+    userHandler := userHandlers.NewUserHandler(userRepo, referralRepo)
+    orgRepo := repository.NewOrganizationRepository(db)
+    orgHandler := userHandlers.NewOrganizationHandler(orgRepo)
+
+    sealer, err := secure.NewSealerFromEnv()
+    if err != nil {
+        log.Fatal("Failed to set up data encryption:", err)
+    }
+    licenseRepo := repository.NewLicenseRepository(db, sealer)
+    licenseHandler := userHandlers.NewLicenseHandler(licenseRepo)
+
+    // Verification codes go to the service log until an SMS provider is
+    // configured
+    phoneRepo := repository.NewPhoneRepository(db)
+    phoneHandler := userHandlers.NewPhoneHandler(phoneRepo, &sms.LogSender{})
+
+    // Setup routes
+    router := setupRoutes(userHandler, orgHandler, licenseHandler, phoneHandler, userRepo)
+
+    // Setup CORS
+    corsHandler := setupCORS(router)
+
+    // Determine port
+    port := os.Getenv("PORT")
+    if port == "" {
+        port = defaultPort
+    }
+
+    // Create server
+    server := &http.Server{
+        Addr:         port,
+        Handler:      corsHandler,
+        ReadTimeout:  15 * time.Second,
+        WriteTimeout: 15 * time.Second,
+        IdleTimeout:  60 * time.Second,
+    }
+
+    // Start server
+    log.Printf("User Service starting on port %s\n", port)
+    log.Printf("Serving frontend from: %s\n", filepath.Join(".", "frontend"))
+    if err := server.ListenAndServe(); err != nil {
+        log.Fatal("Server failed to start:", err)
+    }
+}
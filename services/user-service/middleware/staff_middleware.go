@@ -0,0 +1,35 @@
+// Path: services/user-service/middleware/staff_middleware.go
+package middleware
+
+import (
+    "net/http"
+
+    "cnad-carsharinggo/services/user-service/handlers"
+)
+
+// RoleLookup returns the role stored for a user
+type RoleLookup func(userID int) (string, error)
+
+// StaffMiddleware only lets staff members through. It must sit inside
+// AuthMiddleware, which provides the claims.
+func StaffMiddleware(lookup RoleLookup, next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        claims, ok := r.Context().Value("claims").(*handlers.Claims)
+        if !ok {
+            http.Error(w, "Unauthorized", http.StatusUnauthorized)
+            return
+        }
+
+        role, err := lookup(claims.UserID)
+        if err != nil {
+            http.Error(w, "Failed to check permissions", http.StatusInternalServerError)
+            return
+        }
+        if role != "staff" {
+            http.Error(w, "Staff only", http.StatusForbidden)
+            return
+        }
+
+        next.ServeHTTP(w, r)
+    }
+}
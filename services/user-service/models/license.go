@@ -0,0 +1,50 @@
+// Path: services/user-service/models/license.go
+package models
+
+import "time"
+
+const (
+    LicensePending  = "Pending"
+    LicenseApproved = "Approved"
+    LicenseRejected = "Rejected"
+)
+
+// DriverLicense is a license submitted for verification. FullName and
+// LicenseNumber are only filled in for support; users see the masked number.
+type DriverLicense struct {
+    ID            int        `json:"id"`
+    UserID        int        `json:"user_id"`
+    FullName      string     `json:"full_name,omitempty"`
+    LicenseNumber string     `json:"license_number,omitempty"`
+    MaskedNumber  string     `json:"masked_number"` // e.g. ****1234
+    Country       string     `json:"country"`
+    LicenseClass  string     `json:"license_class,omitempty"`
+    ExpiresOn     time.Time  `json:"expires_on"`
+    Expired       bool       `json:"expired"`
+    Status        string     `json:"status"` // Pending, Approved, Rejected
+    RejectReason  string     `json:"reject_reason,omitempty"`
+    SubmittedAt   time.Time  `json:"submitted_at"`
+    ReviewedBy    *int       `json:"reviewed_by,omitempty"`
+    ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// LicenseImage is a decrypted photo of one side of a license
+type LicenseImage struct {
+    ContentType string
+    Data        []byte
+}
+
+// LicenseSubmission is what the user uploads
+type LicenseSubmission struct {
+    FullName      string
+    LicenseNumber string
+    Country       string
+    LicenseClass  string
+    ExpiresOn     time.Time
+    Front         LicenseImage
+    Back          LicenseImage
+}
+
+type RejectLicenseRequest struct {
+    Reason string `json:"reason"`
+}
@@ -0,0 +1,64 @@
+// Path: services/user-service/models/organization.go
+package models
+
+import "time"
+
+const (
+    OrgRoleAdmin  = "Admin"
+    OrgRoleMember = "Member"
+)
+
+const (
+    InvitePending  = "Pending"
+    InviteAccepted = "Accepted"
+    InviteRevoked  = "Revoked"
+)
+
+type Organization struct {
+    ID           int       `json:"id"`
+    Name         string    `json:"name"`
+    BillingEmail string    `json:"billing_email"`
+    CreatedBy    int       `json:"created_by"`
+    CreatedAt    time.Time `json:"created_at"`
+}
+
+// Membership is the caller's place in their organization
+type Membership struct {
+    Organization Organization `json:"organization"`
+    Role         string       `json:"role"` // Admin, Member
+    JoinedAt     time.Time    `json:"joined_at"`
+}
+
+type OrganizationMember struct {
+    UserID   int       `json:"user_id"`
+    Email    string    `json:"email"`
+    Role     string    `json:"role"`
+    JoinedAt time.Time `json:"joined_at"`
+}
+
+type OrganizationInvite struct {
+    ID             int        `json:"id"`
+    OrganizationID int        `json:"organization_id"`
+    Email          string     `json:"email"`
+    Role           string     `json:"role"`
+    Token          string     `json:"token,omitempty"` // only shown to the admin who sent it
+    Status         string     `json:"status"`          // Pending, Accepted, Revoked
+    InvitedBy      int        `json:"invited_by"`
+    CreatedAt      time.Time  `json:"created_at"`
+    ExpiresAt      time.Time  `json:"expires_at"`
+    AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+}
+
+type CreateOrganizationRequest struct {
+    Name         string `json:"name"`
+    BillingEmail string `json:"billing_email"`
+}
+
+type InviteRequest struct {
+    Email string `json:"email"`
+    Role  string `json:"role"` // defaults to Member
+}
+
+type UpdateMemberRequest struct {
+    Role string `json:"role"`
+}
@@ -0,0 +1,29 @@
+// Path: services/user-service/models/referral.go
+package models
+
+import "time"
+
+const (
+    ReferralPending  = "Pending"
+    ReferralRewarded = "Rewarded"
+    ReferralRejected = "Rejected"
+)
+
+// Referral records a user who signed up with someone else's referral code
+type Referral struct {
+    ID                  int        `json:"id"`
+    ReferrerID          int        `json:"referrer_id"`
+    RefereeID           int        `json:"referee_id"`
+    Status              string     `json:"status"` // Pending, Rewarded, Rejected
+    RejectReason        string     `json:"reject_reason,omitempty"`
+    ReferrerCreditCents int64      `json:"referrer_credit_cents"`
+    RefereeCreditCents  int64      `json:"referee_credit_cents"`
+    CreatedAt           time.Time  `json:"created_at"`
+    RewardedAt          *time.Time `json:"rewarded_at,omitempty"`
+}
+
+// ReferralSummary is the user's own code and the people they referred
+type ReferralSummary struct {
+    Code      string     `json:"code"`
+    Referrals []Referral `json:"referrals"`
+}
@@ -0,0 +1,66 @@
+// Path: services/user-service/phone/phone.go
+package phone
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "math/big"
+    "strings"
+)
+
+// Numbers typed without a country code are taken to be Singaporean
+const DefaultCountryCode = "65"
+
+var ErrInvalid = errors.New("phone number is not valid; use international format, e.g. +6591234567")
+
+// Normalize turns a number as typed by the user into E.164 form: a plus
+// sign followed by up to 15 digits, the first of which is the country code.
+// Spaces, dashes, dots and brackets are ignored, and a leading 00 is read as
+// the international prefix.
+func Normalize(raw string) (string, error) {
+    var digits strings.Builder
+    international := false
+    for i, r := range strings.TrimSpace(raw) {
+        switch {
+        case r >= '0' && r <= '9':
+            digits.WriteRune(r)
+        case r == '+' && i == 0:
+            international = true
+        case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+        default:
+            return "", ErrInvalid
+        }
+    }
+
+    number := digits.String()
+    switch {
+    case international:
+    case strings.HasPrefix(number, "00"):
+        number = number[2:]
+    default:
+        number = DefaultCountryCode + strings.TrimPrefix(number, "0")
+    }
+
+    if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+        return "", ErrInvalid
+    }
+    return "+" + number, nil
+}
+
+// NewCode returns a random six-digit one-time code
+func NewCode() (string, error) {
+    n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+    if err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// HashCode is how a code is stored and compared
+func HashCode(code string) string {
+    sum := sha256.Sum256([]byte(strings.TrimSpace(code)))
+    return hex.EncodeToString(sum[:])
+}
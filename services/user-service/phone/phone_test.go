@@ -0,0 +1,41 @@
+// Path: services/user-service/phone/phone_test.go
+package phone
+
+import (
+    "testing"
+)
+
+func TestNormalize(t *testing.T) {
+    tests := []struct {
+        name    string
+        raw     string
+        want    string
+        wantErr bool
+    }{
+        {"e164", "+6591234567", "+6591234567", false},
+        {"spaces and dashes", " +65 9123-4567 ", "+6591234567", false},
+        {"brackets and dots", "+1 (415) 555.0100", "+14155550100", false},
+        {"international prefix", "0044 20 7946 0958", "+442079460958", false},
+        {"local number", "9123 4567", "+6591234567", false},
+        {"local with trunk zero", "091234567", "+6591234567", false},
+        {"longest", "+123456789012345", "+123456789012345", false},
+        {"too long", "+1234567890123456", "", true},
+        {"too short", "+6512345", "", true},
+        {"country code starts with zero", "+0591234567", "", true},
+        {"letters", "+65 9123 ABCD", "", true},
+        {"plus in the middle", "65+91234567", "", true},
+        {"empty", "", "", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := Normalize(tt.raw)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("Normalize(%q) error = %v, want error %v", tt.raw, err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("Normalize(%q) = %q, want %q", tt.raw, got, tt.want)
+            }
+        })
+    }
+}
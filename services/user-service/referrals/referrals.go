@@ -0,0 +1,62 @@
+// Path: services/user-service/referrals/referrals.go
+package referrals
+
+import (
+    "strings"
+    "unicode"
+
+    "cnad-carsharinggo/services/user-service/models"
+)
+
+// Shortest phone number compared on its trailing digits, so numbers with and
+// without a country code still match
+const minPhoneDigits = 8
+
+// SelfReferralReason explains why a sign-up looks like the referrer
+// referring themselves, or returns "" if it doesn't
+func SelfReferralReason(referrer, user *models.User) string {
+    if normalizeEmail(referrer.Email) == normalizeEmail(user.Email) {
+        return "email matches the referrer's"
+    }
+    if samePhone(referrer.PhoneNumber, user.PhoneNumber) {
+        return "phone number matches the referrer's"
+    }
+    return ""
+}
+
+// normalizeEmail reduces an address to the mailbox it is delivered to:
+// lower-cased, without a +tag, and for Gmail without dots
+func normalizeEmail(email string) string {
+    email = strings.ToLower(strings.TrimSpace(email))
+    at := strings.LastIndex(email, "@")
+    if at < 0 {
+        return email
+    }
+
+    local, domain := email[:at], email[at+1:]
+    if plus := strings.Index(local, "+"); plus >= 0 {
+        local = local[:plus]
+    }
+    if domain == "gmail.com" || domain == "googlemail.com" {
+        local = strings.ReplaceAll(local, ".", "")
+        domain = "gmail.com"
+    }
+    return local + "@" + domain
+}
+
+func samePhone(a, b string) bool {
+    a, b = phoneDigits(a), phoneDigits(b)
+    if len(a) < minPhoneDigits || len(b) < minPhoneDigits {
+        return false
+    }
+    return strings.HasSuffix(a, b) || strings.HasSuffix(b, a)
+}
+
+func phoneDigits(phone string) string {
+    return strings.Map(func(r rune) rune {
+        if unicode.IsDigit(r) {
+            return r
+        }
+        return -1
+    }, phone)
+}
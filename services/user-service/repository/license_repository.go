@@ -0,0 +1,316 @@
+// Path: services/user-service/repository/license_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "cnad-carsharinggo/services/user-service/models"
+    "cnad-carsharinggo/services/user-service/secure"
+)
+
+const licenseDateFormat = "2006-01-02"
+
+var (
+    ErrLicenseNotFound   = errors.New("license not found")
+    ErrLicenseNotPending = errors.New("license has already been reviewed")
+    ErrLicensePending    = errors.New("a license is already waiting for review")
+    ErrLicenseExpired    = errors.New("license has expired")
+)
+
+const licenseColumns = `
+    id, user_id, license_number_last4, country, license_class, expires_on, status,
+    reject_reason, submitted_at, reviewed_by, reviewed_at
+`
+
+// LicenseRepository stores driver's licenses with the holder's name, the
+// number and the images encrypted
+type LicenseRepository struct {
+    DB     *sql.DB
+    Sealer *secure.Sealer
+}
+
+func NewLicenseRepository(db *sql.DB, sealer *secure.Sealer) *LicenseRepository {
+    return &LicenseRepository{DB: db, Sealer: sealer}
+}
+
+// Submit stores a license for review. Only one submission per user can be
+// waiting at a time.
+func (r *LicenseRepository) Submit(userID int, sub models.LicenseSubmission) (*models.DriverLicense, error) {
+    if isExpired(sub.ExpiresOn, time.Now()) {
+        return nil, ErrLicenseExpired
+    }
+
+    name, err := r.seal(userID, "full_name", []byte(sub.FullName))
+    if err != nil {
+        return nil, err
+    }
+    number, err := r.seal(userID, "license_number", []byte(sub.LicenseNumber))
+    if err != nil {
+        return nil, err
+    }
+    front, err := r.seal(userID, "front_image", sub.Front.Data)
+    if err != nil {
+        return nil, err
+    }
+    back, err := r.seal(userID, "back_image", sub.Back.Data)
+    if err != nil {
+        return nil, err
+    }
+
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    // Serialize submissions per user on their row
+    if _, err := tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", userID); err != nil {
+        return nil, err
+    }
+
+    var pending bool
+    err = tx.QueryRow(
+        "SELECT EXISTS (SELECT 1 FROM driver_licenses WHERE user_id = $1 AND status = $2)",
+        userID, models.LicensePending,
+    ).Scan(&pending)
+    if err != nil {
+        return nil, err
+    }
+    if pending {
+        return nil, ErrLicensePending
+    }
+
+    var id int
+    err = tx.QueryRow(`
+        INSERT INTO driver_licenses (user_id, full_name_enc, license_number_enc, license_number_last4, country,
+                                     license_class, expires_on, front_image_enc, front_image_type,
+                                     back_image_enc, back_image_type, status, submitted_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        RETURNING id
+    `, userID, name, number, lastFour(sub.LicenseNumber), sub.Country, sub.LicenseClass,
+        sub.ExpiresOn.Format(licenseDateFormat), front, sub.Front.ContentType, back, sub.Back.ContentType,
+        models.LicensePending, time.Now(),
+    ).Scan(&id)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+    return r.GetLicense(id, userID)
+}
+
+// GetCurrentLicense returns the user's latest submission
+func (r *LicenseRepository) GetCurrentLicense(userID int) (*models.DriverLicense, error) {
+    return scanLicense(r.DB.QueryRow(
+        "SELECT "+licenseColumns+" FROM driver_licenses WHERE user_id = $1 ORDER BY submitted_at DESC, id DESC LIMIT 1",
+        userID,
+    ))
+}
+
+// GetLicense returns a license without its encrypted details. A userID of
+// 0 skips the ownership check, for support.
+func (r *LicenseRepository) GetLicense(id int, userID int) (*models.DriverLicense, error) {
+    return scanLicense(r.DB.QueryRow(
+        "SELECT "+licenseColumns+" FROM driver_licenses WHERE id = $1 AND ($2 = 0 OR user_id = $2)",
+        id, userID,
+    ))
+}
+
+// GetLicensesByStatus lists licenses for the review queue, oldest first
+func (r *LicenseRepository) GetLicensesByStatus(status string) ([]models.DriverLicense, error) {
+    rows, err := r.DB.Query(
+        "SELECT "+licenseColumns+" FROM driver_licenses WHERE status = $1 ORDER BY submitted_at",
+        status,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    licenses := []models.DriverLicense{}
+    for rows.Next() {
+        license, err := scanLicense(rows)
+        if err != nil {
+            return nil, err
+        }
+        licenses = append(licenses, *license)
+    }
+
+    return licenses, nil
+}
+
+// GetLicenseDetails returns a license with the holder's name and the full
+// number decrypted, for support to check against the images
+func (r *LicenseRepository) GetLicenseDetails(id int) (*models.DriverLicense, error) {
+    license, err := r.GetLicense(id, 0)
+    if err != nil {
+        return nil, err
+    }
+
+    var name, number []byte
+    err = r.DB.QueryRow(
+        "SELECT full_name_enc, license_number_enc FROM driver_licenses WHERE id = $1", id,
+    ).Scan(&name, &number)
+    if err != nil {
+        return nil, err
+    }
+
+    if name, err = r.open(license.UserID, "full_name", name); err != nil {
+        return nil, err
+    }
+    if number, err = r.open(license.UserID, "license_number", number); err != nil {
+        return nil, err
+    }
+    license.FullName = string(name)
+    license.LicenseNumber = string(number)
+
+    return license, nil
+}
+
+// GetLicenseImage decrypts the front or back image of a license
+func (r *LicenseRepository) GetLicenseImage(id int, side string) (*models.LicenseImage, error) {
+    if side != "front" && side != "back" {
+        return nil, ErrLicenseNotFound
+    }
+
+    var userID int
+    var image models.LicenseImage
+    var sealed []byte
+    err := r.DB.QueryRow(
+        fmt.Sprintf("SELECT user_id, %[1]s_image_enc, %[1]s_image_type FROM driver_licenses WHERE id = $1", side),
+        id,
+    ).Scan(&userID, &sealed, &image.ContentType)
+    if err == sql.ErrNoRows {
+        return nil, ErrLicenseNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if image.Data, err = r.open(userID, side+"_image", sealed); err != nil {
+        return nil, err
+    }
+    return &image, nil
+}
+
+// Approve verifies a pending license. One that expired while waiting can't
+// be approved.
+func (r *LicenseRepository) Approve(id int, staffID int) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var status string
+    var expiresOn time.Time
+    err = tx.QueryRow(
+        "SELECT status, expires_on FROM driver_licenses WHERE id = $1 FOR UPDATE", id,
+    ).Scan(&status, &expiresOn)
+    if err == sql.ErrNoRows {
+        return ErrLicenseNotFound
+    }
+    if err != nil {
+        return err
+    }
+    if status != models.LicensePending {
+        return ErrLicenseNotPending
+    }
+    if isExpired(expiresOn, time.Now()) {
+        return ErrLicenseExpired
+    }
+
+    _, err = tx.Exec(
+        "UPDATE driver_licenses SET status = $1, reviewed_by = $2, reviewed_at = $3 WHERE id = $4",
+        models.LicenseApproved, staffID, time.Now(), id,
+    )
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+func (r *LicenseRepository) Reject(id int, staffID int, reason string) error {
+    result, err := r.DB.Exec(`
+        UPDATE driver_licenses SET status = $1, reject_reason = $2, reviewed_by = $3, reviewed_at = $4
+        WHERE id = $5 AND status = $6
+    `, models.LicenseRejected, reason, staffID, time.Now(), id, models.LicensePending)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        if _, err := r.GetLicense(id, 0); err != nil {
+            return err
+        }
+        return ErrLicenseNotPending
+    }
+    return nil
+}
+
+func (r *LicenseRepository) seal(userID int, field string, plaintext []byte) ([]byte, error) {
+    return r.Sealer.Seal(plaintext, fmt.Sprintf("driver_license/%d/%s", userID, field))
+}
+
+func (r *LicenseRepository) open(userID int, field string, sealed []byte) ([]byte, error) {
+    return r.Sealer.Open(sealed, fmt.Sprintf("driver_license/%d/%s", userID, field))
+}
+
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+func scanLicense(row rowScanner) (*models.DriverLicense, error) {
+    var license models.DriverLicense
+    var last4 string
+    var reviewedBy sql.NullInt64
+    var reviewedAt sql.NullTime
+
+    err := row.Scan(
+        &license.ID, &license.UserID, &last4, &license.Country, &license.LicenseClass, &license.ExpiresOn,
+        &license.Status, &license.RejectReason, &license.SubmittedAt, &reviewedBy, &reviewedAt,
+    )
+    if err == sql.ErrNoRows {
+        return nil, ErrLicenseNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    license.MaskedNumber = "****" + last4
+    license.Expired = isExpired(license.ExpiresOn, time.Now())
+    if reviewedBy.Valid {
+        id := int(reviewedBy.Int64)
+        license.ReviewedBy = &id
+    }
+    if reviewedAt.Valid {
+        license.ReviewedAt = &reviewedAt.Time
+    }
+
+    return &license, nil
+}
+
+// isExpired compares calendar dates: a license is valid through the whole
+// of its expiry day
+func isExpired(expiresOn time.Time, now time.Time) bool {
+    return now.Format(licenseDateFormat) > expiresOn.Format(licenseDateFormat)
+}
+
+func lastFour(number string) string {
+    number = strings.ReplaceAll(number, " ", "")
+    if len(number) <= 4 {
+        return number
+    }
+    return number[len(number)-4:]
+}
@@ -0,0 +1,381 @@
+// Path: services/user-service/repository/organization_repository.go
+package repository
+
+import (
+    "crypto/rand"
+    "database/sql"
+    "encoding/hex"
+    "errors"
+    "strings"
+    "time"
+
+    "cnad-carsharinggo/services/user-service/models"
+)
+
+const inviteTTL = 7 * 24 * time.Hour
+
+var (
+    ErrNotMember             = errors.New("not a member of this organization")
+    ErrAlreadyInOrganization = errors.New("user already belongs to an organization")
+    ErrInviteNotFound        = errors.New("invite not found or no longer valid")
+    ErrInviteExpired         = errors.New("invite has expired")
+    ErrInviteWrongUser       = errors.New("invite was sent to a different email address")
+    ErrInvitePending         = errors.New("an invite for this email is already pending")
+    ErrLastAdmin             = errors.New("an organization needs at least one admin")
+)
+
+type OrganizationRepository struct {
+    DB *sql.DB
+}
+
+func NewOrganizationRepository(db *sql.DB) *OrganizationRepository {
+    return &OrganizationRepository{DB: db}
+}
+
+// CreateOrganization sets up a new organization with its creator as admin
+func (r *OrganizationRepository) CreateOrganization(org *models.Organization, ownerID int) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := checkNotMember(tx, ownerID); err != nil {
+        return err
+    }
+
+    org.CreatedBy = ownerID
+    org.CreatedAt = time.Now()
+    err = tx.QueryRow(`
+        INSERT INTO organizations (name, billing_email, created_by, created_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `, org.Name, org.BillingEmail, ownerID, org.CreatedAt).Scan(&org.ID)
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(`
+        INSERT INTO organization_members (organization_id, user_id, role, joined_at)
+        VALUES ($1, $2, $3, $4)
+    `, org.ID, ownerID, models.OrgRoleAdmin, org.CreatedAt)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+func (r *OrganizationRepository) GetMembership(userID int) (*models.Membership, error) {
+    var m models.Membership
+    err := r.DB.QueryRow(`
+        SELECT o.id, o.name, o.billing_email, o.created_by, o.created_at, m.role, m.joined_at
+        FROM organization_members m
+        JOIN organizations o ON o.id = m.organization_id
+        WHERE m.user_id = $1
+    `, userID).Scan(
+        &m.Organization.ID, &m.Organization.Name, &m.Organization.BillingEmail,
+        &m.Organization.CreatedBy, &m.Organization.CreatedAt, &m.Role, &m.JoinedAt,
+    )
+    if err == sql.ErrNoRows {
+        return nil, ErrNotMember
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &m, nil
+}
+
+func (r *OrganizationRepository) GetMembers(orgID int) ([]models.OrganizationMember, error) {
+    rows, err := r.DB.Query(`
+        SELECT m.user_id, u.email, m.role, m.joined_at
+        FROM organization_members m
+        JOIN users u ON u.id = m.user_id
+        WHERE m.organization_id = $1
+        ORDER BY m.joined_at
+    `, orgID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    members := []models.OrganizationMember{}
+    for rows.Next() {
+        var m models.OrganizationMember
+        if err := rows.Scan(&m.UserID, &m.Email, &m.Role, &m.JoinedAt); err != nil {
+            return nil, err
+        }
+        members = append(members, m)
+    }
+
+    return members, nil
+}
+
+// UpdateMemberRole promotes or demotes a member. The last admin can't be
+// demoted.
+func (r *OrganizationRepository) UpdateMemberRole(orgID int, userID int, role string) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    current, err := lockMember(tx, orgID, userID)
+    if err != nil {
+        return err
+    }
+    if current == models.OrgRoleAdmin && role != models.OrgRoleAdmin {
+        if err := checkOtherAdmin(tx, orgID, userID); err != nil {
+            return err
+        }
+    }
+
+    _, err = tx.Exec(
+        "UPDATE organization_members SET role = $1 WHERE organization_id = $2 AND user_id = $3",
+        role, orgID, userID,
+    )
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// RemoveMember takes a user out of the organization. Their past business
+// bookings stay billed to it.
+func (r *OrganizationRepository) RemoveMember(orgID int, userID int) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    current, err := lockMember(tx, orgID, userID)
+    if err != nil {
+        return err
+    }
+    if current == models.OrgRoleAdmin {
+        if err := checkOtherAdmin(tx, orgID, userID); err != nil {
+            return err
+        }
+    }
+
+    _, err = tx.Exec("DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2", orgID, userID)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// CreateInvite issues a single-use token the invited user accepts to join
+func (r *OrganizationRepository) CreateInvite(invite *models.OrganizationInvite) error {
+    token, err := newInviteToken()
+    if err != nil {
+        return err
+    }
+
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var pending bool
+    err = tx.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM organization_invites
+            WHERE organization_id = $1 AND LOWER(email) = LOWER($2) AND status = $3 AND expires_at > $4
+        )
+    `, invite.OrganizationID, invite.Email, models.InvitePending, time.Now()).Scan(&pending)
+    if err != nil {
+        return err
+    }
+    if pending {
+        return ErrInvitePending
+    }
+
+    invite.Token = token
+    invite.Status = models.InvitePending
+    invite.CreatedAt = time.Now()
+    invite.ExpiresAt = invite.CreatedAt.Add(inviteTTL)
+    err = tx.QueryRow(`
+        INSERT INTO organization_invites (organization_id, email, role, token, status, invited_by, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id
+    `, invite.OrganizationID, invite.Email, invite.Role, invite.Token, invite.Status,
+        invite.InvitedBy, invite.CreatedAt, invite.ExpiresAt,
+    ).Scan(&invite.ID)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// GetPendingInvites lists invites that can still be accepted, without their
+// tokens
+func (r *OrganizationRepository) GetPendingInvites(orgID int) ([]models.OrganizationInvite, error) {
+    rows, err := r.DB.Query(`
+        SELECT id, organization_id, email, role, status, invited_by, created_at, expires_at
+        FROM organization_invites
+        WHERE organization_id = $1 AND status = $2 AND expires_at > $3
+        ORDER BY created_at
+    `, orgID, models.InvitePending, time.Now())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    invites := []models.OrganizationInvite{}
+    for rows.Next() {
+        var i models.OrganizationInvite
+        err := rows.Scan(&i.ID, &i.OrganizationID, &i.Email, &i.Role, &i.Status, &i.InvitedBy, &i.CreatedAt, &i.ExpiresAt)
+        if err != nil {
+            return nil, err
+        }
+        invites = append(invites, i)
+    }
+
+    return invites, nil
+}
+
+func (r *OrganizationRepository) RevokeInvite(orgID int, inviteID int) error {
+    result, err := r.DB.Exec(
+        "UPDATE organization_invites SET status = $1 WHERE id = $2 AND organization_id = $3 AND status = $4",
+        models.InviteRevoked, inviteID, orgID, models.InvitePending,
+    )
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return ErrInviteNotFound
+    }
+    return nil
+}
+
+// AcceptInvite adds the user to the inviting organization. The invite only
+// works for the account registered with the invited email.
+func (r *OrganizationRepository) AcceptInvite(token string, userID int) (*models.Membership, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    var invite models.OrganizationInvite
+    err = tx.QueryRow(`
+        SELECT id, organization_id, email, role, status, expires_at
+        FROM organization_invites WHERE token = $1
+        FOR UPDATE
+    `, token).Scan(&invite.ID, &invite.OrganizationID, &invite.Email, &invite.Role, &invite.Status, &invite.ExpiresAt)
+    if err == sql.ErrNoRows {
+        return nil, ErrInviteNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    if invite.Status != models.InvitePending {
+        return nil, ErrInviteNotFound
+    }
+    if now.After(invite.ExpiresAt) {
+        return nil, ErrInviteExpired
+    }
+
+    var email string
+    if err := tx.QueryRow("SELECT email FROM users WHERE id = $1", userID).Scan(&email); err != nil {
+        return nil, err
+    }
+    if !strings.EqualFold(email, invite.Email) {
+        return nil, ErrInviteWrongUser
+    }
+
+    if err := checkNotMember(tx, userID); err != nil {
+        return nil, err
+    }
+
+    _, err = tx.Exec(`
+        INSERT INTO organization_members (organization_id, user_id, role, joined_at)
+        VALUES ($1, $2, $3, $4)
+    `, invite.OrganizationID, userID, invite.Role, now)
+    if err != nil {
+        return nil, err
+    }
+
+    _, err = tx.Exec(
+        "UPDATE organization_invites SET status = $1, accepted_at = $2 WHERE id = $3",
+        models.InviteAccepted, now, invite.ID,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+    return r.GetMembership(userID)
+}
+
+func checkNotMember(tx *sql.Tx, userID int) error {
+    var exists bool
+    err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM organization_members WHERE user_id = $1)", userID).Scan(&exists)
+    if err != nil {
+        return err
+    }
+    if exists {
+        return ErrAlreadyInOrganization
+    }
+    return nil
+}
+
+// lockMember returns the member's current role, locking the organization's
+// admins so two of them can't demote each other at the same time
+func lockMember(tx *sql.Tx, orgID int, userID int) (string, error) {
+    _, err := tx.Exec(
+        "SELECT user_id FROM organization_members WHERE organization_id = $1 AND role = $2 FOR UPDATE",
+        orgID, models.OrgRoleAdmin,
+    )
+    if err != nil {
+        return "", err
+    }
+
+    var role string
+    err = tx.QueryRow(
+        "SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2",
+        orgID, userID,
+    ).Scan(&role)
+    if err == sql.ErrNoRows {
+        return "", ErrNotMember
+    }
+    return role, err
+}
+
+func checkOtherAdmin(tx *sql.Tx, orgID int, userID int) error {
+    var others int
+    err := tx.QueryRow(
+        "SELECT COUNT(*) FROM organization_members WHERE organization_id = $1 AND role = $2 AND user_id <> $3",
+        orgID, models.OrgRoleAdmin, userID,
+    ).Scan(&others)
+    if err != nil {
+        return err
+    }
+    if others == 0 {
+        return ErrLastAdmin
+    }
+    return nil
+}
+
+func newInviteToken() (string, error) {
+    b := make([]byte, 24)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,189 @@
+// Path: services/user-service/repository/phone_repository.go
+package repository
+
+import (
+    "crypto/subtle"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+)
+
+// Verification codes expire after codeTTL and allow maxCodeAttempts
+// guesses. Sends are limited per user and per number so the endpoint can't
+// be used to flood someone with texts.
+const (
+    codeTTL           = 10 * time.Minute
+    maxCodeAttempts   = 5
+    minSendInterval   = time.Minute
+    maxSendsPerHour   = 5
+    maxSendsPerNumber = 10 // per day, across all users
+)
+
+var (
+    ErrNoPhoneNumber   = errors.New("add a phone number to your profile first")
+    ErrPhoneVerified   = errors.New("phone number is already verified")
+    ErrPhoneInvalid    = errors.New("the phone number on your profile is not valid; enter it again in international format")
+    ErrNoActiveCode    = errors.New("no active verification code; request a new one")
+    ErrWrongCode       = errors.New("verification code is incorrect")
+    ErrTooManyAttempts = errors.New("too many incorrect attempts; request a new code")
+)
+
+// RateLimitError is returned when a code was sent too recently or too often
+type RateLimitError struct {
+    RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+    return fmt.Sprintf("too many verification codes requested; try again in %d seconds", int(e.RetryAfter.Seconds()+0.5))
+}
+
+type PhoneRepository struct {
+    DB *sql.DB
+}
+
+func NewPhoneRepository(db *sql.DB) *PhoneRepository {
+    return &PhoneRepository{DB: db}
+}
+
+// CreateCode records a new code for the user's current number, replacing
+// any earlier one, and returns the number to send it to
+func (r *PhoneRepository) CreateCode(userID int, codeHash string) (string, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return "", err
+    }
+    defer tx.Rollback()
+
+    var number sql.NullString
+    var verifiedAt sql.NullTime
+    var invalid bool
+    err = tx.QueryRow(
+        "SELECT phone_number, phone_verified_at, phone_number_invalid FROM users WHERE id = $1 FOR UPDATE", userID,
+    ).Scan(&number, &verifiedAt, &invalid)
+    if err != nil {
+        return "", err
+    }
+    if number.String == "" {
+        return "", ErrNoPhoneNumber
+    }
+    if invalid {
+        return "", ErrPhoneInvalid
+    }
+    if verifiedAt.Valid {
+        return "", ErrPhoneVerified
+    }
+
+    now := time.Now()
+    if err := checkSendLimits(tx, userID, number.String, now); err != nil {
+        return "", err
+    }
+
+    _, err = tx.Exec(
+        "UPDATE phone_verification_codes SET consumed_at = $1 WHERE user_id = $2 AND consumed_at IS NULL",
+        now, userID,
+    )
+    if err != nil {
+        return "", err
+    }
+
+    _, err = tx.Exec(`
+        INSERT INTO phone_verification_codes (user_id, phone_number, code_hash, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `, userID, number.String, codeHash, now, now.Add(codeTTL))
+    if err != nil {
+        return "", err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return "", err
+    }
+    return number.String, nil
+}
+
+// VerifyCode checks a code against the latest one sent to the user's
+// current number and marks the number verified if it matches
+func (r *PhoneRepository) VerifyCode(userID int, codeHash string) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    now := time.Now()
+    var id, attempts int
+    var storedHash string
+    err = tx.QueryRow(`
+        SELECT c.id, c.code_hash, c.attempts
+        FROM phone_verification_codes c
+        JOIN users u ON u.id = c.user_id AND u.phone_number = c.phone_number
+        WHERE c.user_id = $1 AND c.consumed_at IS NULL AND c.expires_at > $2
+        ORDER BY c.created_at DESC
+        LIMIT 1
+        FOR UPDATE OF c
+    `, userID, now).Scan(&id, &storedHash, &attempts)
+    if err == sql.ErrNoRows {
+        return ErrNoActiveCode
+    }
+    if err != nil {
+        return err
+    }
+    if attempts >= maxCodeAttempts {
+        return ErrTooManyAttempts
+    }
+
+    if subtle.ConstantTimeCompare([]byte(storedHash), []byte(codeHash)) != 1 {
+        if _, err := tx.Exec("UPDATE phone_verification_codes SET attempts = attempts + 1 WHERE id = $1", id); err != nil {
+            return err
+        }
+        if err := tx.Commit(); err != nil {
+            return err
+        }
+        if attempts+1 >= maxCodeAttempts {
+            return ErrTooManyAttempts
+        }
+        return ErrWrongCode
+    }
+
+    if _, err := tx.Exec("UPDATE phone_verification_codes SET consumed_at = $1 WHERE id = $2", now, id); err != nil {
+        return err
+    }
+    if _, err := tx.Exec("UPDATE users SET phone_verified_at = $1 WHERE id = $2", now, userID); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+func checkSendLimits(tx *sql.Tx, userID int, number string, now time.Time) error {
+    var last sql.NullTime
+    var lastHour int
+    err := tx.QueryRow(`
+        SELECT MAX(created_at), COUNT(*) FILTER (WHERE created_at > $2)
+        FROM phone_verification_codes
+        WHERE user_id = $1
+    `, userID, now.Add(-time.Hour)).Scan(&last, &lastHour)
+    if err != nil {
+        return err
+    }
+    if last.Valid && now.Sub(last.Time) < minSendInterval {
+        return &RateLimitError{RetryAfter: minSendInterval - now.Sub(last.Time)}
+    }
+    if lastHour >= maxSendsPerHour {
+        return &RateLimitError{RetryAfter: time.Hour}
+    }
+
+    var lastDay int
+    err = tx.QueryRow(
+        "SELECT COUNT(*) FROM phone_verification_codes WHERE phone_number = $1 AND created_at > $2",
+        number, now.Add(-24*time.Hour),
+    ).Scan(&lastDay)
+    if err != nil {
+        return err
+    }
+    if lastDay >= maxSendsPerNumber {
+        return &RateLimitError{RetryAfter: 24 * time.Hour}
+    }
+
+    return nil
+}
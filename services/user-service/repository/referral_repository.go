@@ -0,0 +1,115 @@
+// Path: services/user-service/repository/referral_repository.go
+package repository
+
+import (
+    "crypto/rand"
+    "database/sql"
+    "errors"
+    "strings"
+    "time"
+
+    "cnad-carsharinggo/services/user-service/models"
+)
+
+// Credit paid into each wallet once the referred user completes their first
+// trip, in cents of the default currency
+const (
+    ReferrerCreditCents = 1000
+    RefereeCreditCents  = 1000
+)
+
+// Unambiguous characters only, so codes survive being read out loud
+const referralAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var ErrReferralCodeNotFound = errors.New("referral code not found")
+
+type ReferralRepository struct {
+    DB *sql.DB
+}
+
+func NewReferralRepository(db *sql.DB) *ReferralRepository {
+    return &ReferralRepository{DB: db}
+}
+
+// FindReferrer returns the user a referral code belongs to
+func (r *ReferralRepository) FindReferrer(code string) (*models.User, error) {
+    var user models.User
+    err := r.DB.QueryRow(
+        "SELECT id, email, phone_number, referral_code FROM users WHERE referral_code = $1",
+        strings.ToUpper(strings.TrimSpace(code)),
+    ).Scan(&user.ID, &user.Email, &user.PhoneNumber, &user.ReferralCode)
+    if err == sql.ErrNoRows {
+        return nil, ErrReferralCodeNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &user, nil
+}
+
+// CreateReferral records a sign-up with a referral code. Rejected referrals
+// are kept so fraud attempts can be reviewed.
+func (r *ReferralRepository) CreateReferral(referral *models.Referral) error {
+    referral.ReferrerCreditCents = ReferrerCreditCents
+    referral.RefereeCreditCents = RefereeCreditCents
+    referral.CreatedAt = time.Now()
+
+    return r.DB.QueryRow(`
+        INSERT INTO referrals (referrer_id, referee_id, status, reject_reason, referrer_credit_cents,
+                               referee_credit_cents, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `, referral.ReferrerID, referral.RefereeID, referral.Status, referral.RejectReason,
+        referral.ReferrerCreditCents, referral.RefereeCreditCents, referral.CreatedAt,
+    ).Scan(&referral.ID)
+}
+
+// GetSummary returns the user's referral code and everyone who signed up
+// with it
+func (r *ReferralRepository) GetSummary(userID int) (*models.ReferralSummary, error) {
+    var code sql.NullString
+    if err := r.DB.QueryRow("SELECT referral_code FROM users WHERE id = $1", userID).Scan(&code); err != nil {
+        return nil, err
+    }
+
+    rows, err := r.DB.Query(`
+        SELECT id, referrer_id, referee_id, status, reject_reason, referrer_credit_cents,
+               referee_credit_cents, created_at, rewarded_at
+        FROM referrals WHERE referrer_id = $1
+        ORDER BY created_at DESC
+    `, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    summary := &models.ReferralSummary{Code: code.String, Referrals: []models.Referral{}}
+    for rows.Next() {
+        var ref models.Referral
+        var rewardedAt sql.NullTime
+        err := rows.Scan(
+            &ref.ID, &ref.ReferrerID, &ref.RefereeID, &ref.Status, &ref.RejectReason,
+            &ref.ReferrerCreditCents, &ref.RefereeCreditCents, &ref.CreatedAt, &rewardedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        if rewardedAt.Valid {
+            ref.RewardedAt = &rewardedAt.Time
+        }
+        summary.Referrals = append(summary.Referrals, ref)
+    }
+
+    return summary, nil
+}
+
+func newReferralCode() (string, error) {
+    b := make([]byte, 8)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    for i := range b {
+        b[i] = referralAlphabet[int(b[i])%len(referralAlphabet)]
+    }
+    return string(b), nil
+}
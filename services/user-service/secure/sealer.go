@@ -0,0 +1,170 @@
+// Path: services/user-service/secure/sealer.go
+package secure
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "strconv"
+    "strings"
+)
+
+var ErrCiphertext = errors.New("ciphertext is invalid or was encrypted for something else")
+
+// Sealer encrypts personal data before it is stored, with AES-256-GCM. Each
+// value is bound to a context string such as the field and owner it belongs
+// to, so ciphertexts can't be swapped between rows or columns.
+//
+// Every ciphertext starts with the ID of the key that sealed it. New values
+// are sealed with the current key; retired keys are only kept to open what
+// they sealed, so keys can be rotated without re-encrypting everything at
+// once.
+type Sealer struct {
+    current byte
+    keys    map[byte]cipher.AEAD
+}
+
+// NewSealer takes the ID and 32-byte key to seal with
+func NewSealer(id byte, key []byte) (*Sealer, error) {
+    s := &Sealer{current: id, keys: make(map[byte]cipher.AEAD)}
+    if err := s.AddKey(id, key); err != nil {
+        return nil, err
+    }
+    return s, nil
+}
+
+// AddKey registers a retired key so values it sealed can still be opened
+func (s *Sealer) AddKey(id byte, key []byte) error {
+    if id == 0 {
+        return errors.New("key ID must be between 1 and 255")
+    }
+    if _, ok := s.keys[id]; ok {
+        return fmt.Errorf("key ID %d is used twice", id)
+    }
+    if len(key) != 32 {
+        return fmt.Errorf("key %d must be 32 bytes, got %d", id, len(key))
+    }
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        return err
+    }
+
+    aead, err := cipher.NewGCM(block)
+    if err != nil {
+        return err
+    }
+    s.keys[id] = aead
+    return nil
+}
+
+// NewSealerFromEnv reads the sealing keys from the environment:
+//
+//	DATA_ENCRYPTION_KEY      base64 32-byte key new values are sealed with
+//	DATA_ENCRYPTION_KEY_ID   its ID, 1-255 (default 1)
+//	DATA_ENCRYPTION_OLD_KEYS retired keys still needed to open old values,
+//	                         as "id:base64key" separated by commas
+//	DATA_ENCRYPTION_DEV      set to "true" to run without a key, using a fixed
+//	                         development key; never in production
+func NewSealerFromEnv() (*Sealer, error) {
+    encoded := os.Getenv("DATA_ENCRYPTION_KEY")
+    if encoded == "" {
+        if os.Getenv("DATA_ENCRYPTION_DEV") != "true" {
+            return nil, errors.New("DATA_ENCRYPTION_KEY is not set; set DATA_ENCRYPTION_DEV=true to use the development key")
+        }
+        log.Println("DATA_ENCRYPTION_DEV is set, encrypting personal data with the development key")
+        key := sha256.Sum256([]byte("your-data-encryption-key"))
+        return NewSealer(1, key[:])
+    }
+
+    id := byte(1)
+    if v := os.Getenv("DATA_ENCRYPTION_KEY_ID"); v != "" {
+        n, err := parseKeyID(v)
+        if err != nil {
+            return nil, fmt.Errorf("DATA_ENCRYPTION_KEY_ID: %v", err)
+        }
+        id = n
+    }
+
+    key, err := base64.StdEncoding.DecodeString(encoded)
+    if err != nil {
+        return nil, fmt.Errorf("DATA_ENCRYPTION_KEY is not valid base64: %v", err)
+    }
+    sealer, err := NewSealer(id, key)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, entry := range strings.Split(os.Getenv("DATA_ENCRYPTION_OLD_KEYS"), ",") {
+        if entry = strings.TrimSpace(entry); entry == "" {
+            continue
+        }
+        parts := strings.SplitN(entry, ":", 2)
+        if len(parts) != 2 {
+            return nil, fmt.Errorf("DATA_ENCRYPTION_OLD_KEYS entry %q is not id:key", entry)
+        }
+        oldID, err := parseKeyID(parts[0])
+        if err != nil {
+            return nil, fmt.Errorf("DATA_ENCRYPTION_OLD_KEYS: %v", err)
+        }
+        oldKey, err := base64.StdEncoding.DecodeString(parts[1])
+        if err != nil {
+            return nil, fmt.Errorf("DATA_ENCRYPTION_OLD_KEYS key %d is not valid base64: %v", oldID, err)
+        }
+        if err := sealer.AddKey(oldID, oldKey); err != nil {
+            return nil, err
+        }
+    }
+
+    return sealer, nil
+}
+
+func parseKeyID(value string) (byte, error) {
+    n, err := strconv.Atoi(strings.TrimSpace(value))
+    if err != nil || n < 1 || n > 255 {
+        return 0, fmt.Errorf("invalid key ID %q, must be between 1 and 255", value)
+    }
+    return byte(n), nil
+}
+
+// Seal encrypts plaintext for context with the current key. The key ID and
+// the random nonce are stored in front of the ciphertext.
+func (s *Sealer) Seal(plaintext []byte, context string) ([]byte, error) {
+    aead := s.keys[s.current]
+    header := make([]byte, 1+aead.NonceSize())
+    header[0] = s.current
+    nonce := header[1:]
+    if _, err := rand.Read(nonce); err != nil {
+        return nil, err
+    }
+    return aead.Seal(header, nonce, plaintext, []byte(context)), nil
+}
+
+// Open decrypts what Seal produced for the same context, with whichever key
+// sealed it
+func (s *Sealer) Open(sealed []byte, context string) ([]byte, error) {
+    if len(sealed) < 1 {
+        return nil, ErrCiphertext
+    }
+    aead, ok := s.keys[sealed[0]]
+    if !ok {
+        return nil, ErrCiphertext
+    }
+
+    size := aead.NonceSize()
+    sealed = sealed[1:]
+    if len(sealed) < size {
+        return nil, ErrCiphertext
+    }
+
+    plaintext, err := aead.Open(nil, sealed[:size], sealed[size:], []byte(context))
+    if err != nil {
+        return nil, ErrCiphertext
+    }
+    return plaintext, nil
+}
@@ -0,0 +1,134 @@
+// Path: services/user-service/secure/sealer_test.go
+package secure
+
+import (
+    "bytes"
+    "testing"
+)
+
+func testKey(b byte) []byte {
+    return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestSealOpen(t *testing.T) {
+    sealer, err := NewSealer(1, testKey(1))
+    if err != nil {
+        t.Fatal(err)
+    }
+    other, err := NewSealer(1, testKey(2))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    plaintext := []byte("S1234567D")
+    sealed, err := sealer.Seal(plaintext, "driver_license/7/number")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if sealed[0] != 1 {
+        t.Errorf("key ID = %d, want 1", sealed[0])
+    }
+    if bytes.Contains(sealed, plaintext) {
+        t.Error("ciphertext contains the plaintext")
+    }
+
+    tampered := append([]byte(nil), sealed...)
+    tampered[len(tampered)-1] ^= 1
+
+    tests := []struct {
+        name    string
+        sealer  *Sealer
+        sealed  []byte
+        context string
+        wantErr bool
+    }{
+        {"same context", sealer, sealed, "driver_license/7/number", false},
+        {"other field", sealer, sealed, "driver_license/7/name", true},
+        {"other user", sealer, sealed, "driver_license/8/number", true},
+        {"other key with the same ID", other, sealed, "driver_license/7/number", true},
+        {"tampered", sealer, tampered, "driver_license/7/number", true},
+        {"truncated", sealer, sealed[:10], "driver_license/7/number", true},
+        {"empty", sealer, nil, "driver_license/7/number", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := tt.sealer.Open(tt.sealed, tt.context)
+            if tt.wantErr {
+                if err != ErrCiphertext {
+                    t.Fatalf("Open() error = %v, want ErrCiphertext", err)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Open() error = %v", err)
+            }
+            if !bytes.Equal(got, plaintext) {
+                t.Errorf("Open() = %q, want %q", got, plaintext)
+            }
+        })
+    }
+}
+
+func TestKeyRotation(t *testing.T) {
+    old, err := NewSealer(1, testKey(1))
+    if err != nil {
+        t.Fatal(err)
+    }
+    sealedOld, err := old.Seal([]byte("old"), "ctx")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // Key 2 takes over and key 1 is kept to open what it sealed
+    rotated, err := NewSealer(2, testKey(2))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := rotated.AddKey(1, testKey(1)); err != nil {
+        t.Fatal(err)
+    }
+
+    got, err := rotated.Open(sealedOld, "ctx")
+    if err != nil || string(got) != "old" {
+        t.Fatalf("Open(old value) = %q, %v, want %q", got, err, "old")
+    }
+
+    sealedNew, err := rotated.Seal([]byte("new"), "ctx")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if sealedNew[0] != 2 {
+        t.Errorf("new value sealed with key %d, want 2", sealedNew[0])
+    }
+
+    // Without the new key the old sealer can't read new values
+    if _, err := old.Open(sealedNew, "ctx"); err != ErrCiphertext {
+        t.Errorf("Open(new value) with retired key only: error = %v, want ErrCiphertext", err)
+    }
+}
+
+func TestAddKey(t *testing.T) {
+    sealer, err := NewSealer(1, testKey(1))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name string
+        id   byte
+        key  []byte
+    }{
+        {"zero ID", 0, testKey(2)},
+        {"ID used twice", 1, testKey(2)},
+        {"short key", 2, testKey(2)[:16]},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if err := sealer.AddKey(tt.id, tt.key); err == nil {
+                t.Errorf("AddKey(%d) succeeded, want an error", tt.id)
+            }
+        })
+    }
+}
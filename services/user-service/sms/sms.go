@@ -0,0 +1,21 @@
+// Path: services/user-service/sms/sms.go
+package sms
+
+import (
+    "log"
+)
+
+// Sender delivers text messages to phone numbers in E.164 form. Plug in a
+// provider by implementing it.
+type Sender interface {
+    Send(to string, body string) error
+}
+
+// LogSender writes messages to the service log instead of sending them, so
+// local development needs no SMS provider
+type LogSender struct{}
+
+func (s *LogSender) Send(to string, body string) error {
+    log.Printf("SMS to %s: %s", to, body)
+    return nil
+}
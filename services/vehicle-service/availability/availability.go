@@ -0,0 +1,110 @@
+// Path: services/vehicle-service/availability/availability.go
+package availability
+
+import (
+    "sort"
+    "time"
+
+    "vehicle-service/models"
+)
+
+// Higher wins when periods overlap on the timeline
+var priority = map[string]int{
+    models.SlotFree:        0,
+    models.SlotBuffer:      1,
+    models.SlotHold:        2,
+    models.SlotReservation: 3,
+    models.SlotMaintenance: 4,
+}
+
+type block struct {
+    models.AvailabilityInterval
+    source int
+}
+
+// Build turns a vehicle's busy periods into a gap-free timeline over
+// [from, to): every busy period and its turnaround buffers, with the gaps
+// in between marked free. The next open slot is the first free stretch
+// after now that is at least minSlot long.
+func Build(vehicleID int, from, to, now time.Time, busy []models.AvailabilityInterval, bufferMinutes int, minSlot time.Duration) *models.VehicleCalendar {
+    buffer := time.Duration(bufferMinutes) * time.Minute
+
+    var blocks []block
+    for i, p := range busy {
+        blocks = append(blocks, block{p, i})
+        if buffer > 0 && p.Kind != models.SlotMaintenance {
+            blocks = append(blocks,
+                block{models.AvailabilityInterval{Kind: models.SlotBuffer, StartTime: p.StartTime.Add(-buffer), EndTime: p.StartTime}, -1},
+                block{models.AvailabilityInterval{Kind: models.SlotBuffer, StartTime: p.EndTime, EndTime: p.EndTime.Add(buffer)}, -1},
+            )
+        }
+    }
+
+    // Cut the range at every edge, then give each piece the kind of the
+    // strongest block covering it
+    edges := []time.Time{from, to}
+    for _, b := range blocks {
+        for _, t := range []time.Time{b.StartTime, b.EndTime} {
+            if t.After(from) && t.Before(to) {
+                edges = append(edges, t)
+            }
+        }
+    }
+    sort.Slice(edges, func(i, j int) bool { return edges[i].Before(edges[j]) })
+
+    calendar := &models.VehicleCalendar{
+        VehicleID:     vehicleID,
+        From:          from,
+        To:            to,
+        BufferMinutes: bufferMinutes,
+        Intervals:     []models.AvailabilityInterval{},
+    }
+
+    lastSource := -1
+    for i := 0; i+1 < len(edges); i++ {
+        start, end := edges[i], edges[i+1]
+        if !start.Before(end) {
+            continue
+        }
+
+        piece := block{models.AvailabilityInterval{Kind: models.SlotFree}, -1}
+        for _, b := range blocks {
+            if b.StartTime.Before(end) && b.EndTime.After(start) && priority[b.Kind] > priority[piece.Kind] {
+                piece = b
+            }
+        }
+
+        n := len(calendar.Intervals)
+        if n > 0 {
+            prev := &calendar.Intervals[n-1]
+            if prev.Kind == piece.Kind && prev.Own == piece.Own && (piece.source < 0 || piece.source == lastSource) {
+                prev.EndTime = end
+                continue
+            }
+        }
+
+        calendar.Intervals = append(calendar.Intervals, models.AvailabilityInterval{
+            Kind:      piece.Kind,
+            StartTime: start,
+            EndTime:   end,
+            Own:       piece.Own,
+        })
+        lastSource = piece.source
+    }
+
+    for _, iv := range calendar.Intervals {
+        if iv.Kind != models.SlotFree || !iv.EndTime.After(now) {
+            continue
+        }
+        start := iv.StartTime
+        if start.Before(now) {
+            start = now
+        }
+        if iv.EndTime.Sub(start) >= minSlot {
+            calendar.NextOpenSlot = &models.AvailabilityInterval{Kind: models.SlotFree, StartTime: start, EndTime: iv.EndTime}
+            break
+        }
+    }
+
+    return calendar
+}
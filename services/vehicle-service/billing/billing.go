@@ -0,0 +1,692 @@
+// Path: services/vehicle-service/billing/billing.go
+package billing
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/payments"
+    "vehicle-service/penalties"
+    "vehicle-service/pricing"
+    "vehicle-service/promotions"
+    "vehicle-service/repository"
+    "vehicle-service/tiers"
+)
+
+// ErrNoRateCard means the vehicle's region bills in a currency the vehicle
+// type has no prices in yet
+var ErrNoRateCard = errors.New("vehicle type has no rate card in this region's currency")
+
+// Digits after the decimal point for currencies that don't use two
+var minorUnits = map[string]int{
+    "JPY": 0,
+    "KRW": 0,
+    "VND": 0,
+    "BHD": 3,
+    "KWD": 3,
+}
+
+// Used when a tier has no row in cancellation_policies
+var defaultCancellationPolicies = map[string]models.CancellationPolicy{
+    tiers.Basic:   {Tier: tiers.Basic, FreeUntilHours: 24, LateCancelFeePercent: 50, NoShowFeePercent: 100},
+    tiers.Premium: {Tier: tiers.Premium, FreeUntilHours: 12, LateCancelFeePercent: 25, NoShowFeePercent: 100},
+    tiers.VIP:     {Tier: tiers.VIP, FreeUntilHours: 2, LateCancelFeePercent: 0, NoShowFeePercent: 50},
+}
+
+// Service keeps each reservation's invoice and payment in step with the
+// booking
+type Service struct {
+    Repo            *repository.BillingRepository
+    ReservationRepo *repository.ReservationRepository
+    UserRepo        *repository.UserRepository
+    PromotionRepo   *repository.PromotionRepository
+    PaymentRepo     *repository.PaymentRepository
+    WalletRepo      *repository.WalletRepository
+    PricingRepo     *repository.PricingRepository
+    PenaltyRepo     *repository.PenaltyRepository
+    OrgRepo         *repository.OrganizationRepository
+    Payments        payments.PaymentProvider
+}
+
+func NewService(bRepo *repository.BillingRepository, rRepo *repository.ReservationRepository, uRepo *repository.UserRepository, pRepo *repository.PromotionRepository, payRepo *repository.PaymentRepository, wRepo *repository.WalletRepository, priceRepo *repository.PricingRepository, penRepo *repository.PenaltyRepository, oRepo *repository.OrganizationRepository, provider payments.PaymentProvider) *Service {
+    return &Service{
+        Repo:            bRepo,
+        ReservationRepo: rRepo,
+        UserRepo:        uRepo,
+        PromotionRepo:   pRepo,
+        PaymentRepo:     payRepo,
+        WalletRepo:      wRepo,
+        PricingRepo:     priceRepo,
+        PenaltyRepo:     penRepo,
+        OrgRepo:         oRepo,
+        Payments:        provider,
+    }
+}
+
+// CheckPriceable returns ErrNoRateCard when the vehicle can't be priced in
+// its region's currency, so the booking can be turned down before it is made
+func (s *Service) CheckPriceable(vehicle *models.Vehicle) error {
+    tax, err := s.taxRate(vehicle.ID)
+    if err != nil {
+        return err
+    }
+
+    _, err = s.currentRateCard(vehicle.Type, tax.Currency)
+    return err
+}
+
+// PreviewPromotion prices a prospective booking with and without the code.
+// A code that can't be used comes back as an invalid preview, not an error.
+func (s *Service) PreviewPromotion(code string, userID int, vehicle *models.Vehicle, start, end time.Time) (*models.PromoPreview, error) {
+    tier, err := s.UserRepo.GetMembershipTier(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    tax, err := s.taxRate(vehicle.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    card, err := s.currentRateCard(vehicle.Type, tax.Currency)
+    if err != nil {
+        return nil, err
+    }
+
+    quote := pricing.Estimate(card, tier, end.Sub(start))
+    preview := &models.PromoPreview{
+        Code:        promotions.Normalize(code),
+        Currency:    card.Currency,
+        RentalCents: quote.TotalCents,
+        TotalCents:  quote.TotalCents,
+    }
+
+    promo, err := s.PromotionRepo.CheckPromotion(code, userID, tier, card.Currency, 0)
+    var rejection *promotions.Rejection
+    if errors.As(err, &rejection) {
+        preview.Reason = rejection.Reason
+        return preview, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    preview.Valid = true
+    preview.Description = promo.Description
+    preview.DiscountCents = promotions.Discount(promo, quote.TotalCents)
+    preview.TotalCents -= preview.DiscountCents
+    return preview, nil
+}
+
+// ReservationBooked opens the invoice for a new reservation, priced with the
+// vehicle type's current rate card in the region's currency at the booked
+// duration with the user's tier discount and promo code, if any, plus the
+// region's tax, and pre-authorizes the total along with the security
+// deposit for the vehicle type and tier. Business bookings are invoiced to
+// the organization, so nothing is authorized on the user's card. A booking
+// that can't be billed doesn't stand: on any error, including a promo code
+// turned down as a *promotions.Rejection and ErrPaymentDeclined or
+// ErrDepositDeclined, the reservation is cancelled again.
+func (s *Service) ReservationBooked(reservationID int, userID int, promoCode string) (*models.Invoice, error) {
+    invoice, err := s.openInvoice(reservationID, userID, promoCode)
+    if err != nil {
+        if err := s.ReservationRepo.CancelReservation(reservationID, userID); err != nil {
+            log.Printf("Billing: failed to cancel unbilled reservation %d: %v", reservationID, err)
+        }
+        return nil, err
+    }
+    if invoice.OrganizationID != nil {
+        return invoice, nil
+    }
+
+    if err := s.authorize(invoice); err != nil {
+        s.abandonBooking(invoice)
+        return nil, err
+    }
+    if err := s.holdDeposit(invoice); err != nil {
+        s.abandonBooking(invoice)
+        return nil, err
+    }
+
+    return invoice, nil
+}
+
+// openInvoice prices the booking and creates its invoice, redeeming the
+// promo code if there is one
+func (s *Service) openInvoice(reservationID int, userID int, promoCode string) (*models.Invoice, error) {
+    reservation, tier, err := s.load(reservationID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    tax, err := s.taxRate(reservation.VehicleID)
+    if err != nil {
+        return nil, err
+    }
+
+    card, err := s.currentRateCard(reservation.Vehicle.Type, tax.Currency)
+    if err != nil {
+        return nil, err
+    }
+
+    quote := pricing.Estimate(card, tier, reservation.EndTime.Sub(reservation.StartTime))
+    lines := []models.InvoiceLine{{
+        Kind:        models.LineRental,
+        Description: fmt.Sprintf("%s rental, %d min at %s/h", reservation.Vehicle.Model, quote.Minutes, FormatAmount(quote.HourlyRateCents, card.Currency)),
+        AmountCents: quote.BaseCents,
+    }}
+    if quote.DiscountCents > 0 {
+        lines = append(lines, models.InvoiceLine{
+            Kind:        models.LineTierDiscount,
+            Description: fmt.Sprintf("%s member discount (%d%%)", tier, quote.DiscountPercent),
+            AmountCents: -quote.DiscountCents,
+        })
+    }
+
+    estimate := &models.PriceEstimate{BaseCents: quote.BaseCents}
+    err = s.dynamicPricing(estimate, reservation.Vehicle, reservation.StartTime, reservation.EndTime, reservation.ID)
+    if err != nil {
+        return nil, err
+    }
+    if estimate.PeakAdjustmentCents != 0 {
+        lines = append(lines, models.InvoiceLine{
+            Kind:        models.LinePeakPricing,
+            Description: peakDescription(estimate.PeakRules),
+            AmountCents: estimate.PeakAdjustmentCents,
+        })
+    }
+    if estimate.DemandAdjustmentCents != 0 {
+        lines = append(lines, models.InvoiceLine{
+            Kind: models.LineDemandPricing,
+            Description: fmt.Sprintf("High demand in %s (%d%% of vehicles booked, x%s)",
+                reservation.Vehicle.Location, estimate.UtilizationPercent, formatMultiplier(estimate.DemandMultiplierPercent)),
+            AmountCents: estimate.DemandAdjustmentCents,
+        })
+    }
+
+    if promoCode != "" {
+        promo, redemption, err := s.PromotionRepo.Redeem(promoCode, userID, tier, card.Currency, reservationID, quote.TotalCents)
+        if err != nil {
+            return nil, err
+        }
+        if redemption.DiscountCents > 0 {
+            lines = append(lines, models.InvoiceLine{
+                Kind:        models.LinePromotion,
+                Description: fmt.Sprintf("Promo code %s", promo.Code),
+                AmountCents: -redemption.DiscountCents,
+            })
+        }
+    }
+
+    invoice, err := s.Repo.CreateInvoice(userID, reservationID, card.ID, *tax, lines)
+    if err != nil {
+        if promoCode != "" {
+            if err := s.PromotionRepo.ReverseRedemption(reservationID); err != nil {
+                log.Printf("Billing: failed to reverse promo code on reservation %d: %v", reservationID, err)
+            }
+        }
+        return nil, err
+    }
+    return invoice, nil
+}
+
+// Estimate prices a booking the user is about to make, including any peak
+// or demand pricing, so they see a surge before committing to it, and the
+// tax on top
+func (s *Service) Estimate(userID int, vehicle *models.Vehicle, start, end time.Time) (*models.PriceEstimate, error) {
+    tier, err := s.UserRepo.GetMembershipTier(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    tax, err := s.taxRate(vehicle.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    card, err := s.currentRateCard(vehicle.Type, tax.Currency)
+    if err != nil {
+        return nil, err
+    }
+
+    quote := pricing.Estimate(card, tier, end.Sub(start))
+    estimate := &models.PriceEstimate{
+        VehicleID:       vehicle.ID,
+        VehicleType:     vehicle.Type,
+        Zone:            vehicle.Location,
+        Currency:        card.Currency,
+        Minutes:         quote.Minutes,
+        HourlyRateCents: quote.HourlyRateCents,
+        BaseCents:       quote.BaseCents,
+        DiscountPercent: quote.DiscountPercent,
+        DiscountCents:   quote.DiscountCents,
+    }
+    if err := s.dynamicPricing(estimate, vehicle, start, end, 0); err != nil {
+        return nil, err
+    }
+    estimate.SubtotalCents = quote.TotalCents + estimate.PeakAdjustmentCents + estimate.DemandAdjustmentCents
+    estimate.TaxName = tax.Name
+    estimate.TaxRateBps = tax.RateBps
+    estimate.TaxCents = pricing.Tax(estimate.SubtotalCents, tax.RateBps)
+    estimate.TotalCents = estimate.SubtotalCents + estimate.TaxCents
+
+    return estimate, nil
+}
+
+// dynamicPricing fills in the peak and demand adjustments on the estimate's
+// base price. They are set once at booking; later changes to the booked
+// times and the trip's actual usage only re-price the base rental.
+func (s *Service) dynamicPricing(estimate *models.PriceEstimate, vehicle *models.Vehicle, start, end time.Time, reservationID int) error {
+    rules, err := s.PricingRepo.GetRules(vehicle.Type)
+    if err != nil {
+        return err
+    }
+    estimate.PeakAdjustmentCents, estimate.PeakRules = pricing.PeakAdjustment(rules, start, end, estimate.BaseCents)
+
+    multipliers, err := s.PricingRepo.GetDemandMultipliers()
+    if err != nil {
+        return err
+    }
+    estimate.DemandMultiplierPercent = 100
+    if len(multipliers) > 0 {
+        estimate.UtilizationPercent, err = s.PricingRepo.GetZoneUtilization(vehicle.Location, start, end, reservationID)
+        if err != nil {
+            return err
+        }
+        estimate.DemandMultiplierPercent = pricing.DemandMultiplier(multipliers, estimate.UtilizationPercent)
+    }
+    base := estimate.BaseCents + estimate.PeakAdjustmentCents
+    estimate.DemandAdjustmentCents = base * int64(estimate.DemandMultiplierPercent-100) / 100
+
+    estimate.Surge = estimate.PeakAdjustmentCents+estimate.DemandAdjustmentCents > 0
+    return nil
+}
+
+// ReservationChanged re-prices the rental after its times changed and adds
+// an adjustment line for the difference. It returns ErrPaymentDeclined when
+// the card won't cover a higher price; the caller undoes the change.
+func (s *Service) ReservationChanged(reservationID int, userID int) error {
+    reservation, tier, err := s.load(reservationID, userID)
+    if err != nil {
+        return err
+    }
+
+    invoice, err := s.Repo.GetInvoiceByReservation(reservationID)
+    if err != nil {
+        return err
+    }
+
+    card, err := s.invoiceRateCard(invoice, reservation.Vehicle.Type)
+    if err != nil {
+        return err
+    }
+
+    quote := pricing.Estimate(card, tier, reservation.EndTime.Sub(reservation.StartTime))
+    diff := quote.TotalCents - rentalTotal(invoice)
+    if diff == 0 {
+        return nil
+    }
+
+    err = s.Repo.AddLines(invoice.ID, []models.InvoiceLine{{
+        Kind:        models.LineRentalAdjustment,
+        Description: fmt.Sprintf("Booking changed to %d min", quote.Minutes),
+        AmountCents: diff,
+    }})
+    if err != nil {
+        return err
+    }
+
+    invoice, err = s.Repo.GetInvoiceByReservation(reservationID)
+    if err != nil {
+        return err
+    }
+    return s.reauthorize(invoice)
+}
+
+// ReservationCancelled applies the tier's cancellation policy: everything
+// billed so far is credited back and the fee, if any, is charged instead,
+// with tax on the fee.
+func (s *Service) ReservationCancelled(reservation *models.Reservation, cancelledAt time.Time) (*models.CancellationOutcome, error) {
+    tier, err := s.UserRepo.GetMembershipTier(reservation.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    policy, err := s.cancellationPolicy(tier)
+    if err != nil {
+        return nil, err
+    }
+
+    invoice, err := s.Repo.GetInvoiceByReservation(reservation.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    outcome := &models.CancellationOutcome{Policy: *policy, Reason: "free", Currency: invoice.Currency}
+    percent := 0
+    switch {
+    case !cancelledAt.Before(reservation.StartTime):
+        outcome.Reason = "no_show"
+        percent = policy.NoShowFeePercent
+    case reservation.StartTime.Sub(cancelledAt) < time.Duration(policy.FreeUntilHours)*time.Hour:
+        outcome.Reason = "late_cancellation"
+        percent = policy.LateCancelFeePercent
+    }
+
+    billed := invoice.SubtotalCents
+    fee := billed * int64(percent) / 100
+
+    lines := []models.InvoiceLine{{
+        Kind:        models.LineCancellationCredit,
+        Description: "Reservation cancelled",
+        AmountCents: -billed,
+    }}
+    if fee > 0 {
+        lines = append(lines, models.InvoiceLine{
+            Kind:        models.LineCancellationFee,
+            Description: fmt.Sprintf("Cancellation fee (%s, %d%%)", outcome.Reason, percent),
+            AmountCents: fee,
+        })
+    }
+
+    totalBefore := invoice.TotalCents
+    if err := s.Repo.AddLines(invoice.ID, lines); err != nil {
+        return nil, err
+    }
+    if err := s.refresh(invoice); err != nil {
+        return nil, err
+    }
+    outcome.FeeCents = invoice.TotalCents
+    outcome.RefundCents = totalBefore - outcome.FeeCents
+
+    if err := s.Repo.IssueInvoice(invoice.ID); err != nil {
+        return nil, err
+    }
+    if err := s.PromotionRepo.ReverseRedemption(reservation.ID); err != nil {
+        return nil, err
+    }
+    if err := s.collect(invoice); err != nil {
+        return nil, err
+    }
+
+    return outcome, nil
+}
+
+// QuoteExtension prices extra time on a reservation with the rate card its
+// invoice was opened with
+func (s *Service) QuoteExtension(reservation *models.Reservation, tier string, extra time.Duration) (pricing.Quote, error) {
+    invoice, err := s.Repo.GetInvoiceByReservation(reservation.ID)
+    if err != nil {
+        return pricing.Quote{}, err
+    }
+
+    card, err := s.invoiceRateCard(invoice, reservation.Vehicle.Type)
+    if err != nil {
+        return pricing.Quote{}, err
+    }
+
+    return pricing.Estimate(card, tier, extra), nil
+}
+
+// TripCompleted re-prices the rental from what was actually used, adds any
+// fines for the way the vehicle came back, issues the invoice and collects
+// it (see collect): fines come out of the security deposit, which is held
+// for depositReviewPeriod in case staff find damage, and the payment covers
+// the rest. Time runs from pickup, or the booked start if the vehicle was
+// never unlocked, to the return. If settling fails the invoice is still
+// issued and RetrySettlements picks it up.
+func (s *Service) TripCompleted(reservation *models.Reservation) (*models.Invoice, error) {
+    invoice, err := s.Repo.GetInvoiceByReservation(reservation.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    usage, err := s.ReservationRepo.GetTripUsage(reservation.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    if usage.ReturnedAt != nil {
+        if err := s.chargeUsage(invoice, reservation, usage); err != nil {
+            return nil, err
+        }
+        if err := s.chargePenalties(invoice, reservation, usage); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := s.Repo.IssueInvoice(invoice.ID); err != nil {
+        return nil, err
+    }
+    if err := s.collect(invoice); err != nil {
+        return nil, err
+    }
+
+    return s.Repo.GetInvoiceByReservation(reservation.ID)
+}
+
+func (s *Service) chargeUsage(invoice *models.Invoice, reservation *models.Reservation, usage *models.TripUsage) error {
+    tier, err := s.UserRepo.GetMembershipTier(invoice.UserID)
+    if err != nil {
+        return err
+    }
+
+    // The completed reservation doesn't carry its vehicle
+    full, err := s.ReservationRepo.GetReservation(reservation.ID, invoice.UserID)
+    if err != nil {
+        return err
+    }
+
+    card, err := s.invoiceRateCard(invoice, full.Vehicle.Type)
+    if err != nil {
+        return err
+    }
+
+    start := reservation.StartTime
+    if usage.PickedUpAt != nil {
+        start = *usage.PickedUpAt
+    }
+    quote := pricing.Usage(card, tier, usage.ReturnedAt.Sub(start), usage.DistanceKm(), usage.EnergyUsedPercent())
+
+    var lines []models.InvoiceLine
+    if diff := quote.Time.TotalCents - rentalTotal(invoice); diff != 0 {
+        lines = append(lines, models.InvoiceLine{
+            Kind:        models.LineRentalAdjustment,
+            Description: fmt.Sprintf("Actual rental time %d min", quote.Time.Minutes),
+            AmountCents: diff,
+        })
+    }
+    if quote.DistanceCents > 0 {
+        lines = append(lines, models.InvoiceLine{
+            Kind: models.LineDistance,
+            Description: fmt.Sprintf("Distance %d km, %d km beyond the %d km included at %s/km",
+                quote.DistanceKm, quote.BillableKm, card.IncludedKm, FormatAmount(card.PerKmCents, card.Currency)),
+            AmountCents: quote.DistanceCents,
+        })
+    }
+    if quote.EnergyCents > 0 {
+        lines = append(lines, models.InvoiceLine{
+            Kind:        models.LineEnergy,
+            Description: fmt.Sprintf("Energy used, %d%% at %s per %%", quote.EnergyPercent, FormatAmount(card.EnergyCentsPerPercent, card.Currency)),
+            AmountCents: quote.EnergyCents,
+        })
+    }
+    if len(lines) == 0 {
+        return nil
+    }
+
+    if err := s.Repo.AddLines(invoice.ID, lines); err != nil {
+        return err
+    }
+    return s.refresh(invoice)
+}
+
+func (s *Service) chargePenalties(invoice *models.Invoice, reservation *models.Reservation, usage *models.TripUsage) error {
+    rules, err := s.PenaltyRepo.GetRules()
+    if err != nil {
+        return err
+    }
+
+    fines := penalties.Evaluate(rules, models.ReturnReport{
+        Currency:          invoice.Currency,
+        BookedEnd:         reservation.EndTime,
+        ReturnedAt:        *usage.ReturnedAt,
+        ChargeLevel:       usage.ReturnChargeLevel,
+        PickupCleanliness: usage.PickupCleanliness,
+        ReturnCleanliness: usage.ReturnCleanliness,
+        PickupLocation:    usage.PickupLocation,
+        ReturnLocation:    usage.ReturnLocation,
+    })
+    if len(fines) == 0 {
+        return nil
+    }
+
+    if err := s.PenaltyRepo.AddFines(invoice, fines); err != nil {
+        return err
+    }
+    return s.refresh(invoice)
+}
+
+// refresh reloads an invoice after lines were added to it
+func (s *Service) refresh(invoice *models.Invoice) error {
+    updated, err := s.Repo.GetInvoiceByReservation(invoice.ReservationID)
+    if err != nil {
+        return err
+    }
+    *invoice = *updated
+    return nil
+}
+
+// currentRateCard is the card new bookings in the currency are priced with.
+// The built-in prices are only used for the default currency.
+func (s *Service) currentRateCard(vehicleType string, currency string) (models.RateCard, error) {
+    card, err := s.Repo.GetCurrentRateCard(vehicleType, currency, time.Now())
+    if err == sql.ErrNoRows {
+        if currency != models.DefaultCurrency {
+            return models.RateCard{}, ErrNoRateCard
+        }
+        return pricing.DefaultRateCard(vehicleType), nil
+    }
+    if err != nil {
+        return models.RateCard{}, err
+    }
+    return *card, nil
+}
+
+// invoiceRateCard is the card an invoice was opened with, so later changes
+// are priced the same way even after a new version is published
+func (s *Service) invoiceRateCard(invoice *models.Invoice, vehicleType string) (models.RateCard, error) {
+    if invoice.RateCardID == nil {
+        return pricing.DefaultRateCard(vehicleType), nil
+    }
+
+    card, err := s.Repo.GetRateCard(*invoice.RateCardID)
+    if err != nil {
+        return models.RateCard{}, err
+    }
+    return *card, nil
+}
+
+// taxRate is the current rate for the vehicle's region. A region without one
+// is billed untaxed in the default currency.
+func (s *Service) taxRate(vehicleID int) (*models.TaxRate, error) {
+    rate, err := s.Repo.GetTaxRate(vehicleID, time.Now())
+    if err == sql.ErrNoRows {
+        return &models.TaxRate{Currency: models.DefaultCurrency}, nil
+    }
+    return rate, err
+}
+
+func (s *Service) cancellationPolicy(tier string) (*models.CancellationPolicy, error) {
+    policy, err := s.Repo.GetCancellationPolicy(tier)
+    if err == sql.ErrNoRows {
+        p, ok := defaultCancellationPolicies[tier]
+        if !ok {
+            p = defaultCancellationPolicies[tiers.Basic]
+            p.Tier = tier
+        }
+        return &p, nil
+    }
+    return policy, err
+}
+
+func (s *Service) load(reservationID int, userID int) (*models.Reservation, string, error) {
+    reservation, err := s.ReservationRepo.GetReservation(reservationID, userID)
+    if err != nil {
+        return nil, "", err
+    }
+
+    tier, err := s.UserRepo.GetMembershipTier(userID)
+    if err != nil {
+        return nil, "", err
+    }
+
+    return reservation, tier, nil
+}
+
+// rentalTotal sums the lines that make up the rental price itself
+func rentalTotal(invoice *models.Invoice) int64 {
+    var total int64
+    for _, line := range invoice.Lines {
+        switch line.Kind {
+        case models.LineRental, models.LineTierDiscount, models.LineRentalAdjustment:
+            total += line.AmountCents
+        }
+    }
+    return total
+}
+
+func peakDescription(applied []models.AppliedRule) string {
+    parts := make([]string, 0, len(applied))
+    for _, rule := range applied {
+        parts = append(parts, fmt.Sprintf("%s %d min x%s", rule.Name, rule.Minutes, formatMultiplier(rule.MultiplierPercent)))
+    }
+    return "Time-of-day pricing: " + strings.Join(parts, ", ")
+}
+
+// formatMultiplier renders 125 as "1.25"
+func formatMultiplier(percent int) string {
+    return fmt.Sprintf("%d.%02d", percent/100, percent%100)
+}
+
+// FormatCents renders an amount such as 1250 as "12.50"
+func FormatCents(cents int64) string {
+    return FormatAmount(cents, models.DefaultCurrency)
+}
+
+// FormatAmount renders an amount in the currency's minor units, e.g. 1250
+// as "12.50" in SGD and as "1250" in JPY
+func FormatAmount(amount int64, currency string) string {
+    digits, ok := minorUnits[currency]
+    if !ok {
+        digits = 2
+    }
+
+    sign := ""
+    if amount < 0 {
+        sign = "-"
+        amount = -amount
+    }
+    if digits == 0 {
+        return fmt.Sprintf("%s%d", sign, amount)
+    }
+
+    unit := int64(1)
+    for i := 0; i < digits; i++ {
+        unit *= 10
+    }
+    return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, digits, amount%unit)
+}
+
+// FormatTaxRate renders basis points such as 900 as "9%" and 825 as "8.25%"
+func FormatTaxRate(bps int) string {
+    if bps%100 == 0 {
+        return fmt.Sprintf("%d%%", bps/100)
+    }
+    return strings.TrimRight(fmt.Sprintf("%d.%02d", bps/100, bps%100), "0") + "%"
+}
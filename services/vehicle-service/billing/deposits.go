@@ -0,0 +1,214 @@
+// Path: services/vehicle-service/billing/deposits.go
+package billing
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/pricing"
+    "vehicle-service/repository"
+)
+
+// depositReviewPeriod is how long the deposit of a finished trip stays held
+// so staff can assess damage before it is released
+const depositReviewPeriod = 48 * time.Hour
+
+var (
+    // ErrDepositDeclined means the security deposit could not be held and
+    // the booking has been cancelled again.
+    ErrDepositDeclined = errors.New("security deposit was declined")
+    // ErrTripNotFinished means damage was assessed before the invoice was
+    // issued
+    ErrTripNotFinished = errors.New("trip has not finished yet")
+    // ErrDepositSettled means the deposit was already captured or released,
+    // so damage can no longer be taken from it
+    ErrDepositSettled = errors.New("security deposit has already been settled")
+)
+
+// holdDeposit authorizes the deposit set for the vehicle type and tier in
+// the invoice's currency, if there is one
+func (s *Service) holdDeposit(invoice *models.Invoice) error {
+    reservation, tier, err := s.load(invoice.ReservationID, invoice.UserID)
+    if err != nil {
+        return err
+    }
+
+    rule, err := s.Repo.GetDepositRule(reservation.Vehicle.Type, tier, invoice.Currency)
+    if err == sql.ErrNoRows {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    if rule.AmountCents <= 0 {
+        return nil
+    }
+
+    deposit := &models.Deposit{
+        ReservationID: invoice.ReservationID,
+        UserID:        invoice.UserID,
+        Provider:      s.Payments.Name(),
+        Status:        models.DepositAuthorized,
+        Currency:      invoice.Currency,
+    }
+
+    result, err := s.Payments.Authorize(invoice.UserID, rule.AmountCents, invoice.Currency, fmt.Sprintf("Security deposit, reservation #%d", invoice.ReservationID))
+    if err != nil {
+        deposit.Status = models.DepositFailed
+        deposit.ErrorMessage = err.Error()
+        if err := s.PaymentRepo.CreateDeposit(deposit); err != nil {
+            log.Printf("Billing: failed to record declined deposit for reservation %d: %v", invoice.ReservationID, err)
+        }
+        return ErrDepositDeclined
+    }
+    deposit.Reference = result.Reference
+    deposit.AuthorizedCents = result.AmountCents
+
+    if err := s.PaymentRepo.CreateDeposit(deposit); err != nil {
+        if _, err := s.Payments.Void(result.Reference); err != nil {
+            log.Printf("Billing: failed to void unrecorded deposit %s: %v", result.Reference, err)
+        }
+        return err
+    }
+    return nil
+}
+
+// settleDeposit captures up to amountCents from the reservation's deposit
+// and releases the rest. With nothing to capture the whole hold is voided.
+func (s *Service) settleDeposit(reservationID int, amountCents int64) (*models.Deposit, error) {
+    deposit, err := s.PaymentRepo.GetDepositByReservation(reservationID)
+    if err == repository.ErrDepositNotFound {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if deposit.Status != models.DepositAuthorized {
+        return deposit, nil
+    }
+
+    if amountCents > deposit.AuthorizedCents {
+        amountCents = deposit.AuthorizedCents
+    }
+
+    if amountCents > 0 {
+        result, err := s.Payments.Capture(deposit.Reference, amountCents)
+        if err != nil {
+            deposit.ErrorMessage = err.Error()
+            if err := s.PaymentRepo.UpdateDeposit(deposit); err != nil {
+                return nil, err
+            }
+            return nil, err
+        }
+        deposit.Status = models.DepositCaptured
+        deposit.CapturedCents = result.AmountCents
+    } else {
+        if _, err := s.Payments.Void(deposit.Reference); err != nil {
+            deposit.ErrorMessage = err.Error()
+            if err := s.PaymentRepo.UpdateDeposit(deposit); err != nil {
+                return nil, err
+            }
+            return nil, err
+        }
+        deposit.Status = models.DepositReleased
+    }
+
+    deposit.ErrorMessage = ""
+    return deposit, s.PaymentRepo.UpdateDeposit(deposit)
+}
+
+// AssessDamage charges the damage staff found on a returned vehicle to the
+// trip's invoice and settles it against the security deposit, ending the
+// review. Anything the deposit can't cover is left as a balance due.
+func (s *Service) AssessDamage(reservationID int, amountCents int64, description string) (*models.Invoice, error) {
+    invoice, err := s.Repo.GetInvoiceByReservation(reservationID)
+    if err != nil {
+        return nil, err
+    }
+    if invoice.Status != models.InvoiceIssued {
+        return nil, ErrTripNotFinished
+    }
+
+    deposit, err := s.PaymentRepo.GetDepositByReservation(reservationID)
+    if err != nil && err != repository.ErrDepositNotFound {
+        return nil, err
+    }
+    if deposit != nil && deposit.Status != models.DepositAuthorized {
+        return nil, ErrDepositSettled
+    }
+
+    err = s.Repo.AddLines(invoice.ID, []models.InvoiceLine{{
+        Kind:        models.LineDamage,
+        Description: "Damage: " + description,
+        AmountCents: amountCents,
+    }})
+    if err != nil {
+        return nil, err
+    }
+    if err := s.collect(invoice); err != nil {
+        return nil, err
+    }
+
+    return s.Repo.GetInvoiceByReservation(reservationID)
+}
+
+// depositLiability is what the deposit stands behind on an invoice: fines
+// and damage, with their share of the tax
+func depositLiability(invoice *models.Invoice) int64 {
+    var total int64
+    for _, line := range invoice.Lines {
+        switch line.Kind {
+        case models.LinePenalty, models.LineDamage:
+            total += line.AmountCents
+        }
+    }
+    if total <= 0 {
+        return 0
+    }
+
+    total += pricing.Tax(total, invoice.TaxRateBps)
+    if total > invoice.TotalCents {
+        return invoice.TotalCents
+    }
+    return total
+}
+
+// underReview reports whether the deposit of a finished trip is still held
+// for staff to assess damage. The review ends once damage is charged or
+// depositReviewPeriod has passed; cancelled bookings aren't reviewed.
+func (s *Service) underReview(invoice *models.Invoice, deposit *models.Deposit) (bool, error) {
+    if deposit == nil || deposit.Status != models.DepositAuthorized {
+        return false, nil
+    }
+    if invoice.IssuedAt == nil || time.Since(*invoice.IssuedAt) >= depositReviewPeriod {
+        return false, nil
+    }
+    for _, line := range invoice.Lines {
+        if line.Kind == models.LineDamage {
+            return false, nil
+        }
+    }
+
+    usage, err := s.ReservationRepo.GetTripUsage(invoice.ReservationID)
+    if err != nil {
+        return false, err
+    }
+    return usage.PickedUpAt != nil, nil
+}
+
+// outstanding is what the invoice total came to beyond what the payment
+// collected, e.g. fines above the amount authorized at booking
+func outstanding(invoice *models.Invoice, payment *models.Payment) int64 {
+    if payment == nil {
+        return 0
+    }
+    owed := invoice.TotalCents - payment.WalletCents - payment.CapturedCents
+    if owed < 0 {
+        return 0
+    }
+    return owed
+}
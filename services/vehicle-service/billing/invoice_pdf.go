@@ -0,0 +1,235 @@
+// Path: services/vehicle-service/billing/invoice_pdf.go
+package billing
+
+import (
+    "fmt"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/pdf"
+    "vehicle-service/repository"
+)
+
+const receiptTimeFormat = "02 Jan 2006 15:04 MST"
+
+// Receipt is everything printed on an invoice PDF. Business trips are
+// billed to the organization, with the user shown as the driver.
+type Receipt struct {
+    Invoice     *models.Invoice
+    Reservation *models.Reservation
+    Usage       *models.TripUsage
+    UserEmail   string
+    Tier        string
+    OrgName     string
+    OrgEmail    string
+    CostCenter  string
+}
+
+// InvoicePDF renders one of the user's invoices as a PDF
+func (s *Service) InvoicePDF(invoiceID int, userID int) (*models.Invoice, []byte, error) {
+    invoice, err := s.Repo.GetInvoice(invoiceID, userID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    receipt, err := s.receipt(invoice)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return invoice, RenderInvoice(*receipt), nil
+}
+
+func (s *Service) receipt(invoice *models.Invoice) (*Receipt, error) {
+    reservation, err := s.ReservationRepo.GetReservation(invoice.ReservationID, invoice.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    usage, err := s.ReservationRepo.GetTripUsage(invoice.ReservationID)
+    if err != nil {
+        return nil, err
+    }
+
+    email, err := s.UserRepo.GetUserEmail(invoice.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    tier, err := s.UserRepo.GetMembershipTier(invoice.UserID)
+    if err != nil {
+        return nil, err
+    }
+
+    payment, err := s.PaymentRepo.GetPaymentByReservation(invoice.ReservationID)
+    if err != nil && err != repository.ErrPaymentNotFound {
+        return nil, err
+    }
+    invoice.Payment = payment
+
+    deposit, err := s.PaymentRepo.GetDepositByReservation(invoice.ReservationID)
+    if err != nil && err != repository.ErrDepositNotFound {
+        return nil, err
+    }
+    invoice.Deposit = deposit
+
+    receipt := &Receipt{Invoice: invoice, Reservation: reservation, Usage: usage, UserEmail: email, Tier: tier}
+    if invoice.OrganizationID == nil {
+        return receipt, nil
+    }
+
+    receipt.OrgName, receipt.OrgEmail, err = s.OrgRepo.GetBillingContact(*invoice.OrganizationID)
+    if err != nil {
+        return nil, err
+    }
+    if invoice.CostCenterID != nil {
+        // Cost centers that were closed since are still shown by ID
+        receipt.CostCenter = fmt.Sprintf("#%d", *invoice.CostCenterID)
+        center, err := s.OrgRepo.GetCostCenter(*invoice.OrganizationID, *invoice.CostCenterID)
+        if err != nil && err != repository.ErrCostCenterNotFound {
+            return nil, err
+        }
+        if center != nil {
+            receipt.CostCenter = fmt.Sprintf("%s (%s)", center.Code, center.Name)
+        }
+    }
+    return receipt, nil
+}
+
+// RenderInvoice lays out a receipt on A4. Discounts, promotions and any
+// other adjustments are printed as the invoice lines they are stored as.
+func RenderInvoice(r Receipt) []byte {
+    const (
+        left   = 50.0
+        right  = pdf.PageWidth - 50
+        bottom = pdf.PageHeight - 60
+    )
+
+    doc := pdf.New()
+    y := 70.0
+
+    doc.Text(left, y, pdf.Bold, 20, "Invoice")
+    doc.Text(right-130, y, pdf.Bold, 11, "CNAD Car Sharing")
+    y += 30
+
+    field := func(label, value string) {
+        doc.Text(left, y, pdf.Bold, 10, label)
+        doc.Text(left+110, y, pdf.Regular, 10, value)
+        y += 15
+    }
+    heading := func(title string) {
+        y += 10
+        doc.Text(left, y, pdf.Bold, 12, title)
+        y += 6
+        doc.Line(left, y, right, y)
+        y += 16
+    }
+
+    inv, res := r.Invoice, r.Reservation
+    money := func(amount int64) string {
+        return FormatAmount(amount, inv.Currency)
+    }
+    field("Invoice number", fmt.Sprintf("INV-%06d", inv.ID))
+    field("Status", inv.Status)
+    if inv.IssuedAt != nil {
+        field("Issued", inv.IssuedAt.Format(receiptTimeFormat))
+    }
+    field("Reservation", fmt.Sprintf("#%d", inv.ReservationID))
+
+    heading("Billed to")
+    if inv.OrganizationID != nil {
+        field("Organization", r.OrgName)
+        field("Email", r.OrgEmail)
+        if r.CostCenter != "" {
+            field("Cost center", r.CostCenter)
+        }
+        heading("Driver")
+    }
+    field("Email", r.UserEmail)
+    field("Membership", r.Tier)
+
+    heading("Trip")
+    field("Vehicle", fmt.Sprintf("%s (%s)", res.Vehicle.Model, res.Vehicle.Type))
+    field("Pickup location", res.Vehicle.Location)
+    field("Booked from", res.StartTime.Format(receiptTimeFormat))
+    field("Booked until", res.EndTime.Format(receiptTimeFormat))
+    start := res.StartTime
+    if u := r.Usage; u != nil && u.PickedUpAt != nil {
+        start = *u.PickedUpAt
+        field("Picked up", start.Format(receiptTimeFormat))
+    }
+    if res.ReturnedAt != nil {
+        field("Returned", res.ReturnedAt.Format(receiptTimeFormat))
+        field("Duration", formatDuration(res.ReturnedAt.Sub(start)))
+    }
+    if u := r.Usage; u != nil && u.PickupOdometerKm != nil && u.ReturnOdometerKm != nil {
+        field("Distance", fmt.Sprintf("%d km", u.DistanceKm()))
+    }
+
+    heading("Charges")
+    for _, line := range inv.Lines {
+        if y > bottom {
+            doc.AddPage()
+            y = 70
+        }
+        doc.Text(left, y, pdf.Regular, 10, line.Description)
+        doc.TextRight(right, y, 10, money(line.AmountCents))
+        y += 15
+    }
+    doc.Line(right-150, y-8, right, y-8)
+    y += 4
+    doc.Text(left, y, pdf.Regular, 10, "Subtotal")
+    doc.TextRight(right, y, 10, money(inv.SubtotalCents))
+    y += 15
+    if inv.TaxName != "" {
+        doc.Text(left, y, pdf.Regular, 10, fmt.Sprintf("%s %s", inv.TaxName, FormatTaxRate(inv.TaxRateBps)))
+        doc.TextRight(right, y, 10, money(inv.TaxCents))
+        y += 15
+    }
+    doc.Text(left, y, pdf.Bold, 11, "Total ("+inv.Currency+")")
+    doc.TextRight(right, y, 11, money(inv.TotalCents))
+    y += 15
+
+    p, d := inv.Payment, inv.Deposit
+    if p == nil {
+        p = &models.Payment{}
+    }
+    if d == nil {
+        d = &models.Deposit{}
+    }
+    if p.WalletCents > 0 || p.CapturedCents > 0 || d.CapturedCents > 0 {
+        heading("Paid")
+        if p.WalletCents > 0 {
+            doc.Text(left, y, pdf.Regular, 10, "Wallet")
+            doc.TextRight(right, y, 10, money(p.WalletCents))
+            y += 15
+        }
+        if p.CapturedCents > 0 {
+            doc.Text(left, y, pdf.Regular, 10, "Card")
+            doc.TextRight(right, y, 10, money(p.CapturedCents))
+            y += 15
+        }
+        if d.CapturedCents > 0 {
+            doc.Text(left, y, pdf.Regular, 10, "Taken from security deposit")
+            doc.TextRight(right, y, 10, money(d.CapturedCents))
+            y += 15
+        }
+        if p.RefundedCents > 0 {
+            doc.Text(left, y, pdf.Regular, 10, "Refunded")
+            doc.TextRight(right, y, 10, money(-p.RefundedCents))
+            y += 15
+        }
+    }
+    if inv.BalanceDueCents > 0 {
+        doc.Text(left, y, pdf.Bold, 11, "Balance due")
+        doc.TextRight(right, y, 11, money(inv.BalanceDueCents))
+        y += 15
+    }
+
+    return doc.Bytes()
+}
+
+func formatDuration(d time.Duration) string {
+    minutes := int(d.Round(time.Minute).Minutes())
+    return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
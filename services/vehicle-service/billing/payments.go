@@ -0,0 +1,332 @@
+// Path: services/vehicle-service/billing/payments.go
+package billing
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/repository"
+)
+
+// Settlements that failed are retried after settleRetryDelay for up to
+// settleRetryWindow
+const (
+    settleRetryDelay  = 5 * time.Minute
+    settleRetryWindow = 24 * time.Hour
+)
+
+// ErrPaymentDeclined means the booking could not be pre-authorized and has
+// been cancelled again.
+var ErrPaymentDeclined = errors.New("payment was declined")
+
+// authorize pre-authorizes the invoice total for a new booking
+func (s *Service) authorize(invoice *models.Invoice) error {
+    payment := &models.Payment{
+        ReservationID: invoice.ReservationID,
+        UserID:        invoice.UserID,
+        Provider:      s.Payments.Name(),
+        Status:        models.PaymentAuthorized,
+        Currency:      invoice.Currency,
+    }
+
+    if invoice.TotalCents > 0 {
+        result, err := s.Payments.Authorize(invoice.UserID, invoice.TotalCents, invoice.Currency, fmt.Sprintf("Reservation #%d", invoice.ReservationID))
+        if err != nil {
+            payment.Status = models.PaymentFailed
+            payment.ErrorMessage = err.Error()
+            if err := s.PaymentRepo.CreatePayment(payment); err != nil {
+                log.Printf("Billing: failed to record declined payment for reservation %d: %v", invoice.ReservationID, err)
+            }
+            return ErrPaymentDeclined
+        }
+        payment.Reference = result.Reference
+        payment.AuthorizedCents = result.AmountCents
+    }
+
+    if err := s.PaymentRepo.CreatePayment(payment); err != nil {
+        if payment.Reference != "" {
+            if _, err := s.Payments.Void(payment.Reference); err != nil {
+                log.Printf("Billing: failed to void unrecorded authorization %s: %v", payment.Reference, err)
+            }
+        }
+        return err
+    }
+    return nil
+}
+
+// reauthorize swaps the authorization for a bigger one when the booking
+// grew. If the new amount is declined the old authorization stays in place
+// and ErrPaymentDeclined is returned so the change can be undone.
+func (s *Service) reauthorize(invoice *models.Invoice) error {
+    payment, err := s.PaymentRepo.GetPaymentByReservation(invoice.ReservationID)
+    if err == repository.ErrPaymentNotFound {
+        // Booked before payments were taken
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    if payment.Status != models.PaymentAuthorized || invoice.TotalCents <= payment.AuthorizedCents {
+        return nil
+    }
+
+    result, err := s.Payments.Authorize(invoice.UserID, invoice.TotalCents, invoice.Currency, fmt.Sprintf("Reservation #%d (changed)", invoice.ReservationID))
+    if err != nil {
+        payment.ErrorMessage = err.Error()
+        if err := s.PaymentRepo.UpdatePayment(payment); err != nil {
+            return err
+        }
+        return ErrPaymentDeclined
+    }
+
+    if payment.Reference != "" {
+        if _, err := s.Payments.Void(payment.Reference); err != nil {
+            log.Printf("Billing: failed to void replaced authorization %s: %v", payment.Reference, err)
+        }
+    }
+
+    payment.Reference = result.Reference
+    payment.AuthorizedCents = result.AmountCents
+    payment.ErrorMessage = ""
+    return s.PaymentRepo.UpdatePayment(payment)
+}
+
+// settle collects amountCents for a reservation. Wallet credit is used
+// first when the payment is in the wallet's currency; the rest is captured
+// from the authorization, which is voided when the wallet covers
+// everything. When the rest is more than was authorized at booking, e.g.
+// for distance, energy or fines, the card is authorized again for the full
+// amount; if that is declined the original authorization is captured and
+// the difference is left for the caller to collect (see outstanding).
+func (s *Service) settle(reservationID int, amountCents int64) (*models.Payment, error) {
+    payment, err := s.PaymentRepo.GetPaymentByReservation(reservationID)
+    if err == repository.ErrPaymentNotFound {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if payment.Status != models.PaymentAuthorized {
+        return payment, nil
+    }
+
+    if amountCents > 0 && payment.Currency == models.DefaultCurrency {
+        spent, err := s.WalletRepo.Spend(payment.UserID, amountCents, reservationID)
+        if err != nil {
+            return nil, err
+        }
+        payment.WalletCents = spent
+        amountCents -= spent
+    }
+    if amountCents > payment.AuthorizedCents {
+        s.authorizeShortfall(payment, amountCents)
+    }
+    if amountCents > payment.AuthorizedCents {
+        amountCents = payment.AuthorizedCents
+    }
+
+    switch {
+    case payment.Reference == "":
+        payment.Status = models.PaymentVoided
+    case amountCents > 0:
+        result, err := s.Payments.Capture(payment.Reference, amountCents)
+        if err != nil {
+            // Give the wallet its money back; a retry spends it again
+            if payment.WalletCents > 0 {
+                if err := s.WalletRepo.ReverseSpend(payment.UserID, reservationID); err != nil {
+                    return nil, err
+                }
+                payment.WalletCents = 0
+            }
+            payment.ErrorMessage = err.Error()
+            if err := s.PaymentRepo.UpdatePayment(payment); err != nil {
+                return nil, err
+            }
+            return nil, err
+        }
+        payment.Status = models.PaymentCaptured
+        payment.CapturedCents = result.AmountCents
+    default:
+        if _, err := s.Payments.Void(payment.Reference); err != nil {
+            payment.ErrorMessage = err.Error()
+            if err := s.PaymentRepo.UpdatePayment(payment); err != nil {
+                return nil, err
+            }
+            return nil, err
+        }
+        payment.Status = models.PaymentVoided
+    }
+
+    payment.ErrorMessage = ""
+    return payment, s.PaymentRepo.UpdatePayment(payment)
+}
+
+// authorizeShortfall replaces the payment's authorization with one for
+// amountCents. A decline is logged and leaves the payment as it was.
+func (s *Service) authorizeShortfall(payment *models.Payment, amountCents int64) {
+    result, err := s.Payments.Authorize(payment.UserID, amountCents, payment.Currency, fmt.Sprintf("Reservation #%d (final amount)", payment.ReservationID))
+    if err != nil {
+        log.Printf("Billing: failed to authorize final amount %d for reservation %d: %v", amountCents, payment.ReservationID, err)
+        return
+    }
+
+    if payment.Reference != "" {
+        if _, err := s.Payments.Void(payment.Reference); err != nil {
+            log.Printf("Billing: failed to void replaced authorization %s: %v", payment.Reference, err)
+        }
+    }
+    payment.Reference = result.Reference
+    payment.AuthorizedCents = result.AmountCents
+}
+
+// collect settles an issued invoice. Fines and damage are taken from the
+// security deposit and the payment covers the rest; after that, whatever
+// the payment couldn't collect is taken from what is left of the deposit
+// and the remainder released. A finished trip's deposit is only settled
+// once its damage review is over, until then RetrySettlements comes back
+// for it. Anything still unpaid is recorded on the invoice as a balance due.
+func (s *Service) collect(invoice *models.Invoice) error {
+    if err := s.refresh(invoice); err != nil {
+        return err
+    }
+
+    deposit, err := s.PaymentRepo.GetDepositByReservation(invoice.ReservationID)
+    if err != nil && err != repository.ErrDepositNotFound {
+        return err
+    }
+
+    // Fines and damage are the deposit's to cover, as far as it goes
+    var held int64
+    if deposit != nil && deposit.Status == models.DepositAuthorized {
+        held = depositLiability(invoice)
+        if held > deposit.AuthorizedCents {
+            held = deposit.AuthorizedCents
+        }
+    }
+
+    payment, err := s.settle(invoice.ReservationID, invoice.TotalCents-held)
+    if err != nil {
+        return err
+    }
+
+    reviewing, err := s.underReview(invoice, deposit)
+    if err != nil {
+        return err
+    }
+    if reviewing {
+        return nil
+    }
+
+    // The held amount plus any shortfall on the payment
+    owed := held
+    if payment != nil {
+        owed = outstanding(invoice, payment)
+    }
+    deposit, err = s.settleDeposit(invoice.ReservationID, owed)
+    if err != nil {
+        return err
+    }
+    if deposit != nil {
+        owed -= deposit.CapturedCents
+    }
+    if owed <= 0 {
+        return nil
+    }
+
+    log.Printf("Billing: reservation %d left %d owed after payment and deposit", invoice.ReservationID, owed)
+    return s.Repo.SetBalanceDue(invoice.ID, owed)
+}
+
+// RetrySettlements collects invoices whose payment or deposit could not be
+// settled when they were issued. Once settleRetryWindow has passed the
+// card payment is given up on, so the deposit covers what it can and the
+// hold isn't left in place indefinitely.
+func (s *Service) RetrySettlements() error {
+    now := time.Now()
+    invoices, err := s.Repo.GetUnsettledInvoices(now.Add(-settleRetryDelay), now.Add(-depositReviewPeriod))
+    if err != nil {
+        return err
+    }
+
+    for i := range invoices {
+        invoice := &invoices[i]
+        err := s.collect(invoice)
+        if err == nil {
+            continue
+        }
+        log.Printf("Billing: retrying settlement of reservation %d failed: %v", invoice.ReservationID, err)
+
+        if invoice.IssuedAt == nil || time.Since(*invoice.IssuedAt) < settleRetryWindow {
+            continue
+        }
+        if err := s.abandonPayment(invoice.ReservationID); err != nil {
+            log.Printf("Billing: failed to give up payment for reservation %d: %v", invoice.ReservationID, err)
+            continue
+        }
+        if err := s.collect(invoice); err != nil {
+            log.Printf("Billing: failed to settle deposit for reservation %d: %v", invoice.ReservationID, err)
+        }
+    }
+
+    return nil
+}
+
+// abandonPayment marks a payment that could not be captured as failed,
+// releasing its authorization if the provider allows
+func (s *Service) abandonPayment(reservationID int) error {
+    payment, err := s.PaymentRepo.GetPaymentByReservation(reservationID)
+    if err == repository.ErrPaymentNotFound {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    if payment.Status != models.PaymentAuthorized {
+        return nil
+    }
+
+    if payment.Reference != "" {
+        if _, err := s.Payments.Void(payment.Reference); err != nil {
+            log.Printf("Billing: failed to void abandoned authorization %s: %v", payment.Reference, err)
+        }
+    }
+    if err := s.WalletRepo.ReverseSpend(payment.UserID, reservationID); err != nil {
+        return err
+    }
+    payment.WalletCents = 0
+    payment.Status = models.PaymentFailed
+    return s.PaymentRepo.UpdatePayment(payment)
+}
+
+// abandonBooking undoes a booking whose payment or deposit could not be
+// taken: the reservation is cancelled, any authorization released and its
+// invoice zeroed out.
+func (s *Service) abandonBooking(invoice *models.Invoice) {
+    if err := s.ReservationRepo.CancelReservation(invoice.ReservationID, invoice.UserID); err != nil {
+        log.Printf("Billing: failed to cancel unpaid reservation %d: %v", invoice.ReservationID, err)
+    }
+    if _, err := s.settle(invoice.ReservationID, 0); err != nil {
+        log.Printf("Billing: failed to release payment for unpaid reservation %d: %v", invoice.ReservationID, err)
+    }
+    if _, err := s.settleDeposit(invoice.ReservationID, 0); err != nil {
+        log.Printf("Billing: failed to release deposit for unpaid reservation %d: %v", invoice.ReservationID, err)
+    }
+
+    err := s.Repo.AddLines(invoice.ID, []models.InvoiceLine{{
+        Kind:        models.LineCancellationCredit,
+        Description: "Payment not taken, reservation cancelled",
+        AmountCents: -invoice.SubtotalCents,
+    }})
+    if err == nil {
+        err = s.Repo.IssueInvoice(invoice.ID)
+    }
+    if err == nil {
+        err = s.PromotionRepo.ReverseRedemption(invoice.ReservationID)
+    }
+    if err != nil {
+        log.Printf("Billing: failed to close invoice for unpaid reservation %d: %v", invoice.ReservationID, err)
+    }
+}
@@ -0,0 +1,108 @@
+// Path: services/vehicle-service/calendar/ics.go
+package calendar
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+
+    "vehicle-service/models"
+)
+
+const (
+    MethodPublish = "PUBLISH"
+    MethodRequest = "REQUEST"
+    MethodCancel  = "CANCEL"
+)
+
+const (
+    prodID    = "-//CNAD CarSharingGO//Reservations//EN"
+    uidDomain = "cnad-carsharinggo"
+    icsTime   = "20060102T150405Z"
+)
+
+// Feed renders a subscribable calendar of the given reservations
+func Feed(reservations []models.Reservation) []byte {
+    var b bytes.Buffer
+    writeHeader(&b, MethodPublish)
+    writeLine(&b, "X-WR-CALNAME:Car Sharing Reservations")
+    for _, r := range reservations {
+        writeEvent(&b, r, r.Status == "Cancelled")
+    }
+    writeLine(&b, "END:VCALENDAR")
+    return b.Bytes()
+}
+
+// Invite renders a single-event invite for an email attachment. Use
+// MethodRequest for new or changed bookings and MethodCancel for cancellations.
+func Invite(r models.Reservation, method string) []byte {
+    var b bytes.Buffer
+    writeHeader(&b, method)
+    writeEvent(&b, r, method == MethodCancel)
+    writeLine(&b, "END:VCALENDAR")
+    return b.Bytes()
+}
+
+func writeHeader(b *bytes.Buffer, method string) {
+    writeLine(b, "BEGIN:VCALENDAR")
+    writeLine(b, "VERSION:2.0")
+    writeLine(b, "PRODID:"+prodID)
+    writeLine(b, "CALSCALE:GREGORIAN")
+    writeLine(b, "METHOD:"+method)
+}
+
+func writeEvent(b *bytes.Buffer, r models.Reservation, cancelled bool) {
+    summary := "Car reservation"
+    location := ""
+    if r.Vehicle != nil {
+        summary = fmt.Sprintf("Car reservation: %s", r.Vehicle.Model)
+        location = r.Vehicle.Location
+    }
+
+    writeLine(b, "BEGIN:VEVENT")
+    writeLine(b, fmt.Sprintf("UID:reservation-%d@%s", r.ID, uidDomain))
+    writeLine(b, fmt.Sprintf("SEQUENCE:%d", r.Sequence))
+    writeLine(b, "DTSTAMP:"+r.UpdatedAt.UTC().Format(icsTime))
+    writeLine(b, "DTSTART:"+r.StartTime.UTC().Format(icsTime))
+    writeLine(b, "DTEND:"+r.EndTime.UTC().Format(icsTime))
+    writeLine(b, "SUMMARY:"+escape(summary))
+    if location != "" {
+        writeLine(b, "LOCATION:"+escape(location))
+    }
+    writeLine(b, "DESCRIPTION:"+escape(fmt.Sprintf("Reservation #%d for vehicle #%d", r.ID, r.VehicleID)))
+    if cancelled {
+        writeLine(b, "STATUS:CANCELLED")
+    } else {
+        writeLine(b, "STATUS:CONFIRMED")
+    }
+    writeLine(b, "END:VEVENT")
+}
+
+// writeLine ends lines with CRLF and folds them at 75 octets (RFC 5545 3.1)
+func writeLine(b *bytes.Buffer, line string) {
+    limit := 75
+    for len(line) > limit {
+        cut := limit
+        // Don't split a multi-byte UTF-8 character
+        for cut > 0 && line[cut]&0xC0 == 0x80 {
+            cut--
+        }
+        b.WriteString(line[:cut])
+        b.WriteString("\r\n ")
+        line = line[cut:]
+        // Continuation lines start with a space, which counts toward the limit
+        limit = 74
+    }
+    b.WriteString(line)
+    b.WriteString("\r\n")
+}
+
+func escape(s string) string {
+    return strings.NewReplacer(
+        `\`, `\\`,
+        ";", `\;`,
+        ",", `\,`,
+        "\r\n", `\n`,
+        "\n", `\n`,
+    ).Replace(s)
+}
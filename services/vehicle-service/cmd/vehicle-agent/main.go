@@ -0,0 +1,136 @@
+// Path: services/vehicle-service/cmd/vehicle-agent/main.go
+//
+// Simulated on-board agent for local testing. It polls the vehicle service for
+// lock/unlock commands addressed to one vehicle, pretends to actuate the locks
+// and acknowledges the result.
+//
+//   go run ./cmd/vehicle-agent -vehicle 1
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "log"
+    "math/rand"
+    "net/http"
+    "time"
+
+    "vehicle-service/models"
+)
+
+type agent struct {
+    server    string
+    vehicleID int
+    key       string
+    latency   time.Duration
+    failRate  float64
+    locked    bool
+    client    *http.Client
+}
+
+func main() {
+    server := flag.String("server", "http://localhost:8081", "vehicle service base URL")
+    vehicleID := flag.Int("vehicle", 1, "ID of the vehicle to simulate")
+    key := flag.String("key", "your-vehicle-agent-key", "shared vehicle agent key")
+    poll := flag.Duration("poll", 2*time.Second, "how often to poll for commands")
+    latency := flag.Duration("latency", 500*time.Millisecond, "simulated actuation time")
+    failRate := flag.Float64("fail-rate", 0, "probability (0-1) that a command fails")
+    flag.Parse()
+
+    a := &agent{
+        server:    *server,
+        vehicleID: *vehicleID,
+        key:       *key,
+        latency:   *latency,
+        failRate:  *failRate,
+        locked:    true,
+        client:    &http.Client{Timeout: 10 * time.Second},
+    }
+
+    log.Printf("Simulated agent for vehicle %d polling %s every %s", a.vehicleID, a.server, *poll)
+    for {
+        if err := a.poll(); err != nil {
+            log.Printf("Poll failed: %v", err)
+        }
+        time.Sleep(*poll)
+    }
+}
+
+func (a *agent) poll() error {
+    url := fmt.Sprintf("%s/api/agent/vehicles/%d/commands/next", a.server, a.vehicleID)
+    req, err := http.NewRequest("GET", url, nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("X-Vehicle-Key", a.key)
+
+    resp, err := a.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode == http.StatusNoContent {
+        return nil
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("unexpected status %s", resp.Status)
+    }
+
+    var cmd models.VehicleCommand
+    if err := json.NewDecoder(resp.Body).Decode(&cmd); err != nil {
+        return err
+    }
+
+    return a.acknowledge(cmd.ID, a.execute(cmd))
+}
+
+func (a *agent) execute(cmd models.VehicleCommand) models.CommandAck {
+    log.Printf("Executing command %d: %s", cmd.ID, cmd.Command)
+    time.Sleep(a.latency)
+
+    if rand.Float64() < a.failRate {
+        log.Printf("Command %d failed (simulated)", cmd.ID)
+        return models.CommandAck{Success: false, Error: "simulated actuator failure"}
+    }
+
+    switch cmd.Command {
+    case models.CommandUnlock:
+        a.locked = false
+    case models.CommandLock:
+        a.locked = true
+    default:
+        return models.CommandAck{Success: false, Error: "unknown command " + cmd.Command}
+    }
+
+    log.Printf("Command %d done, vehicle locked=%t", cmd.ID, a.locked)
+    return models.CommandAck{Success: true}
+}
+
+func (a *agent) acknowledge(commandID int, ack models.CommandAck) error {
+    body, err := json.Marshal(ack)
+    if err != nil {
+        return err
+    }
+
+    url := fmt.Sprintf("%s/api/agent/vehicles/%d/commands/%d/ack", a.server, a.vehicleID, commandID)
+    req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("X-Vehicle-Key", a.key)
+
+    resp, err := a.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("ack for command %d rejected: %s", commandID, resp.Status)
+    }
+    return nil
+}
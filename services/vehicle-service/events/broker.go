@@ -0,0 +1,58 @@
+// Path: services/vehicle-service/events/broker.go
+package events
+
+import (
+    "sync"
+
+    "vehicle-service/models"
+)
+
+// Buffered so a short burst of writes doesn't drop events for a healthy client
+const subscriberBuffer = 32
+
+// Broker fans vehicle changes out to every connected stream. Publishing never
+// blocks: a subscriber that can't keep up misses events rather than stalling
+// the repository write that produced them.
+type Broker struct {
+    mu          sync.RWMutex
+    subscribers map[chan models.VehicleEvent]struct{}
+}
+
+func NewBroker() *Broker {
+    return &Broker{subscribers: make(map[chan models.VehicleEvent]struct{})}
+}
+
+func (b *Broker) Subscribe() chan models.VehicleEvent {
+    ch := make(chan models.VehicleEvent, subscriberBuffer)
+
+    b.mu.Lock()
+    b.subscribers[ch] = struct{}{}
+    b.mu.Unlock()
+
+    return ch
+}
+
+func (b *Broker) Unsubscribe(ch chan models.VehicleEvent) {
+    b.mu.Lock()
+    if _, ok := b.subscribers[ch]; ok {
+        delete(b.subscribers, ch)
+        close(ch)
+    }
+    b.mu.Unlock()
+}
+
+func (b *Broker) Publish(event models.VehicleEvent) {
+    if b == nil {
+        return
+    }
+
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    for ch := range b.subscribers {
+        select {
+        case ch <- event:
+        default:
+        }
+    }
+}
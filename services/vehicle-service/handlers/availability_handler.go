@@ -0,0 +1,95 @@
+// Path: services/vehicle-service/handlers/availability_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/availability"
+    "vehicle-service/repository"
+)
+
+const (
+    defaultCalendarRange = 7 * 24 * time.Hour
+    maxCalendarRange     = 31 * 24 * time.Hour
+    defaultSlotMinutes   = 30
+)
+
+type AvailabilityHandler struct {
+    AvailabilityRepo *repository.AvailabilityRepository
+}
+
+func NewAvailabilityHandler(aRepo *repository.AvailabilityRepository) *AvailabilityHandler {
+    return &AvailabilityHandler{AvailabilityRepo: aRepo}
+}
+
+// GetVehicleCalendar returns the vehicle's busy and free intervals between
+// the from and to query parameters (RFC 3339, default the next 7 days) and
+// the next open slot of at least duration minutes (default 30).
+func (h *AvailabilityHandler) GetVehicleCalendar(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    vehicleID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+    query := r.URL.Query()
+
+    now := time.Now()
+    from := now
+    if v := query.Get("from"); v != "" {
+        if from, err = time.Parse(time.RFC3339, v); err != nil {
+            http.Error(w, "Invalid from time", http.StatusBadRequest)
+            return
+        }
+    }
+    to := from.Add(defaultCalendarRange)
+    if v := query.Get("to"); v != "" {
+        if to, err = time.Parse(time.RFC3339, v); err != nil {
+            http.Error(w, "Invalid to time", http.StatusBadRequest)
+            return
+        }
+    }
+    if !to.After(from) {
+        http.Error(w, "to must be after from", http.StatusBadRequest)
+        return
+    }
+    if to.Sub(from) > maxCalendarRange {
+        http.Error(w, "Calendar range cannot exceed 31 days", http.StatusBadRequest)
+        return
+    }
+
+    slotMinutes := defaultSlotMinutes
+    if v := query.Get("duration"); v != "" {
+        if slotMinutes, err = strconv.Atoi(v); err != nil || slotMinutes <= 0 {
+            http.Error(w, "Invalid duration", http.StatusBadRequest)
+            return
+        }
+    }
+
+    bufferMinutes, err := h.AvailabilityRepo.GetTurnaroundMinutes(vehicleID)
+    if err == repository.ErrVehicleNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get vehicle calendar: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    busy, err := h.AvailabilityRepo.GetBusyPeriods(vehicleID, from, to, userID, bufferMinutes)
+    if err != nil {
+        http.Error(w, "Failed to get vehicle calendar: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    vehicleCalendar := availability.Build(vehicleID, from, to, now, busy, bufferMinutes, time.Duration(slotMinutes)*time.Minute)
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(vehicleCalendar)
+}
@@ -0,0 +1,85 @@
+// Path: services/vehicle-service/handlers/calendar_handler.go
+package handlers
+
+import (
+    "database/sql"
+    "encoding/json"
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/calendar"
+    "vehicle-service/repository"
+)
+
+type CalendarHandler struct {
+    CalendarRepo    *repository.CalendarRepository
+    ReservationRepo *repository.ReservationRepository
+    BaseURL         string
+}
+
+func NewCalendarHandler(cRepo *repository.CalendarRepository, rRepo *repository.ReservationRepository, baseURL string) *CalendarHandler {
+    return &CalendarHandler{
+        CalendarRepo:    cRepo,
+        ReservationRepo: rRepo,
+        BaseURL:         baseURL,
+    }
+}
+
+// GetFeedURL returns the user's private calendar subscription URL
+func (h *CalendarHandler) GetFeedURL(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    token, err := h.CalendarRepo.GetFeedToken(userID)
+    if err != nil {
+        http.Error(w, "Failed to get calendar feed", http.StatusInternalServerError)
+        return
+    }
+
+    h.writeFeedURL(w, token)
+}
+
+// RotateFeedURL issues a new URL; the old one stops working immediately
+func (h *CalendarHandler) RotateFeedURL(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    token, err := h.CalendarRepo.RotateFeedToken(userID)
+    if err != nil {
+        http.Error(w, "Failed to rotate calendar feed", http.StatusInternalServerError)
+        return
+    }
+
+    h.writeFeedURL(w, token)
+}
+
+// ServeFeed is unauthenticated: calendar apps can't send our JWT, so the
+// secret token in the URL is the credential.
+func (h *CalendarHandler) ServeFeed(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+
+    userID, err := h.CalendarRepo.GetUserIDByFeedToken(vars["token"])
+    if err == sql.ErrNoRows {
+        http.Error(w, "Calendar feed not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to load calendar feed", http.StatusInternalServerError)
+        return
+    }
+
+    reservations, err := h.ReservationRepo.GetUpcomingReservations(userID)
+    if err != nil {
+        http.Error(w, "Failed to load calendar feed", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+    w.Header().Set("Content-Disposition", `inline; filename="reservations.ics"`)
+    w.Write(calendar.Feed(reservations))
+}
+
+func (h *CalendarHandler) writeFeedURL(w http.ResponseWriter, token string) {
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]string{
+        "feed_url": h.BaseURL + "/api/calendar/" + token + ".ics",
+    })
+}
@@ -0,0 +1,134 @@
+// Path: services/vehicle-service/handlers/command_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/models"
+    "vehicle-service/repository"
+)
+
+type CommandHandler struct {
+    CommandRepo *repository.CommandRepository
+}
+
+func NewCommandHandler(cRepo *repository.CommandRepository) *CommandHandler {
+    return &CommandHandler{CommandRepo: cRepo}
+}
+
+func (h *CommandHandler) UnlockVehicle(w http.ResponseWriter, r *http.Request) {
+    h.queueCommand(w, r, models.CommandUnlock)
+}
+
+func (h *CommandHandler) LockVehicle(w http.ResponseWriter, r *http.Request) {
+    h.queueCommand(w, r, models.CommandLock)
+}
+
+func (h *CommandHandler) queueCommand(w http.ResponseWriter, r *http.Request, command string) {
+    vars := mux.Vars(r)
+    reservationID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    cmd, err := h.CommandRepo.QueueCommand(reservationID, userID, command)
+    switch err {
+    case nil:
+    case repository.ErrReservationNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case repository.ErrOutsideWindow:
+        http.Error(w, err.Error(), http.StatusForbidden)
+        return
+    default:
+        http.Error(w, "Failed to queue command: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusAccepted)
+    json.NewEncoder(w).Encode(cmd)
+}
+
+func (h *CommandHandler) GetCommand(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    commandID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid command ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    cmd, err := h.CommandRepo.GetCommand(commandID, userID)
+    if err == repository.ErrCommandNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get command", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(cmd)
+}
+
+// NextCommand is polled by the vehicle agent. 204 means nothing is queued.
+func (h *CommandHandler) NextCommand(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    vehicleID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+        return
+    }
+
+    cmd, err := h.CommandRepo.NextCommand(vehicleID)
+    if err != nil {
+        http.Error(w, "Failed to get next command", http.StatusInternalServerError)
+        return
+    }
+    if cmd == nil {
+        w.WriteHeader(http.StatusNoContent)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(cmd)
+}
+
+func (h *CommandHandler) AcknowledgeCommand(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    vehicleID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+        return
+    }
+    commandID, err := strconv.Atoi(vars["commandId"])
+    if err != nil {
+        http.Error(w, "Invalid command ID", http.StatusBadRequest)
+        return
+    }
+
+    var ack models.CommandAck
+    if err := json.NewDecoder(r.Body).Decode(&ack); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if err := h.CommandRepo.AcknowledgeCommand(commandID, vehicleID, ack); err != nil {
+        http.Error(w, "Failed to acknowledge command: "+err.Error(), http.StatusConflict)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Command acknowledged",
+    })
+}
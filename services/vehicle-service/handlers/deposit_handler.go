@@ -0,0 +1,73 @@
+// Path: services/vehicle-service/handlers/deposit_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strings"
+
+    "vehicle-service/models"
+    "vehicle-service/repository"
+    "vehicle-service/tiers"
+)
+
+type DepositHandler struct {
+    BillingRepo *repository.BillingRepository
+}
+
+func NewDepositHandler(bRepo *repository.BillingRepository) *DepositHandler {
+    return &DepositHandler{BillingRepo: bRepo}
+}
+
+// GetDepositRules lists the deposit held for each vehicle type and tier.
+// Combinations that aren't listed take no deposit.
+func (h *DepositHandler) GetDepositRules(w http.ResponseWriter, r *http.Request) {
+    rules, err := h.BillingRepo.GetDepositRules()
+    if err != nil {
+        http.Error(w, "Failed to get deposit rules", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(rules)
+}
+
+// SetDepositRule lets staff change the deposit for a vehicle type and tier.
+// An amount of 0 stops taking one.
+func (h *DepositHandler) SetDepositRule(w http.ResponseWriter, r *http.Request) {
+    var rule models.DepositRule
+    if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    rule.VehicleType = strings.TrimSpace(rule.VehicleType)
+    if rule.VehicleType == "" {
+        http.Error(w, "vehicle_type is required", http.StatusBadRequest)
+        return
+    }
+    if !tiers.Valid(rule.Tier) {
+        http.Error(w, "Unknown membership tier", http.StatusBadRequest)
+        return
+    }
+    currency, ok := normalizeCurrency(rule.Currency)
+    if !ok {
+        http.Error(w, "currency must be a three-letter ISO 4217 code", http.StatusBadRequest)
+        return
+    }
+    rule.Currency = currency
+    if rule.AmountCents < 0 {
+        http.Error(w, "amount_cents must not be negative", http.StatusBadRequest)
+        return
+    }
+
+    staffID := r.Context().Value("user_id").(int)
+
+    if err := h.BillingRepo.SetDepositRule(&rule, staffID); err != nil {
+        http.Error(w, "Failed to set deposit rule: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(rule)
+}
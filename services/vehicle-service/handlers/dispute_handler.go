@@ -0,0 +1,212 @@
+// Path: services/vehicle-service/handlers/dispute_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/models"
+    "vehicle-service/payments"
+    "vehicle-service/refunds"
+    "vehicle-service/repository"
+)
+
+type DisputeHandler struct {
+    DisputeRepo *repository.DisputeRepository
+    Refunds     *refunds.Service
+}
+
+func NewDisputeHandler(dRepo *repository.DisputeRepository, refundService *refunds.Service) *DisputeHandler {
+    return &DisputeHandler{DisputeRepo: dRepo, Refunds: refundService}
+}
+
+// OpenDispute lets the user object to one line of an issued invoice
+func (h *DisputeHandler) OpenDispute(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    invoiceID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+        return
+    }
+
+    var req models.OpenDisputeRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    req.Reason = strings.TrimSpace(req.Reason)
+    if req.LineID == 0 || req.Reason == "" {
+        http.Error(w, "line_id and reason are required", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    dispute, err := h.DisputeRepo.OpenDispute(userID, invoiceID, req.LineID, req.Reason)
+    switch err {
+    case nil:
+    case repository.ErrInvoiceNotFound, repository.ErrLineNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case repository.ErrInvoiceNotIssued, repository.ErrLineNotDisputable, repository.ErrDisputeExists:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to open dispute: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(dispute)
+}
+
+func (h *DisputeHandler) GetUserDisputes(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    disputes, err := h.DisputeRepo.GetUserDisputes(userID)
+    if err != nil {
+        http.Error(w, "Failed to get disputes", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(disputes)
+}
+
+func (h *DisputeHandler) GetDispute(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+    h.writeDispute(w, r, userID)
+}
+
+// GetDisputeQueue lists disputes for support, open ones by default
+func (h *DisputeHandler) GetDisputeQueue(w http.ResponseWriter, r *http.Request) {
+    status := r.URL.Query().Get("status")
+    if status == "" {
+        status = models.DisputeOpen
+    }
+
+    disputes, err := h.DisputeRepo.GetDisputesByStatus(status)
+    if err != nil {
+        http.Error(w, "Failed to get disputes", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(disputes)
+}
+
+// GetAnyDispute shows support any user's dispute with its audit trail
+func (h *DisputeHandler) GetAnyDispute(w http.ResponseWriter, r *http.Request) {
+    h.writeDispute(w, r, 0)
+}
+
+// ApproveDispute resolves a dispute for the user, refunding amount_cents to
+// their card or wallet
+func (h *DisputeHandler) ApproveDispute(w http.ResponseWriter, r *http.Request) {
+    disputeID, req, ok := decodeResolution(w, r)
+    if !ok {
+        return
+    }
+    if req.AmountCents < 0 {
+        http.Error(w, "amount_cents must not be negative", http.StatusBadRequest)
+        return
+    }
+
+    staffID := r.Context().Value("user_id").(int)
+
+    dispute, err := h.Refunds.Approve(disputeID, staffID, req.AmountCents, req.Method, req.Note)
+    switch {
+    case err == nil:
+    case err == repository.ErrDisputeNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case err == refunds.ErrInvalidMethod, err == repository.ErrWalletCurrency:
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    case err == repository.ErrDisputeNotOpen, err == repository.ErrExceedsLine,
+        err == repository.ErrExceedsPaid, err == repository.ErrExceedsCapture, err == repository.ErrRefundNotPending:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    case errors.Is(err, payments.ErrDeclined), errors.Is(err, payments.ErrInvalidState), errors.Is(err, payments.ErrExceedsCaptured):
+        http.Error(w, "Refund failed: "+err.Error(), http.StatusBadGateway)
+        return
+    default:
+        http.Error(w, "Failed to approve dispute: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(dispute)
+}
+
+func (h *DisputeHandler) RejectDispute(w http.ResponseWriter, r *http.Request) {
+    disputeID, req, ok := decodeResolution(w, r)
+    if !ok {
+        return
+    }
+    if req.Note == "" {
+        http.Error(w, "A note explaining the rejection is required", http.StatusBadRequest)
+        return
+    }
+
+    staffID := r.Context().Value("user_id").(int)
+
+    err := h.DisputeRepo.Reject(disputeID, staffID, req.Note)
+    if err == repository.ErrDisputeNotOpen {
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to reject dispute: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    h.writeDispute(w, r, 0)
+}
+
+func (h *DisputeHandler) writeDispute(w http.ResponseWriter, r *http.Request, userID int) {
+    vars := mux.Vars(r)
+    disputeID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid dispute ID", http.StatusBadRequest)
+        return
+    }
+
+    dispute, err := h.DisputeRepo.GetDispute(disputeID, userID)
+    if err == repository.ErrDisputeNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get dispute", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(dispute)
+}
+
+func decodeResolution(w http.ResponseWriter, r *http.Request) (int, models.ResolveDisputeRequest, bool) {
+    var req models.ResolveDisputeRequest
+
+    vars := mux.Vars(r)
+    disputeID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid dispute ID", http.StatusBadRequest)
+        return 0, req, false
+    }
+
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return 0, req, false
+    }
+    req.Note = strings.TrimSpace(req.Note)
+
+    return disputeID, req, true
+}
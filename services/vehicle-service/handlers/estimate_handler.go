@@ -0,0 +1,60 @@
+// Path: services/vehicle-service/handlers/estimate_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+
+    "vehicle-service/billing"
+    "vehicle-service/models"
+    "vehicle-service/repository"
+)
+
+type EstimateHandler struct {
+    VehicleRepo *repository.VehicleRepository
+    Billing     *billing.Service
+}
+
+func NewEstimateHandler(vRepo *repository.VehicleRepository, billingService *billing.Service) *EstimateHandler {
+    return &EstimateHandler{VehicleRepo: vRepo, Billing: billingService}
+}
+
+// EstimatePrice shows what a booking would cost right now, with any peak or
+// demand pricing broken out
+func (h *EstimateHandler) EstimatePrice(w http.ResponseWriter, r *http.Request) {
+    var req models.EstimateRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if !req.EndTime.After(req.StartTime) {
+        http.Error(w, "End time must be after start time", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    vehicle, err := h.VehicleRepo.GetVehicleByID(req.VehicleID)
+    if err == repository.ErrVehicleNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to estimate price: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    estimate, err := h.Billing.Estimate(userID, vehicle, req.StartTime, req.EndTime)
+    if err == billing.ErrNoRateCard {
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to estimate price: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(estimate)
+}
@@ -0,0 +1,80 @@
+// Path: services/vehicle-service/handlers/fine_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/models"
+    "vehicle-service/repository"
+)
+
+type FineHandler struct {
+    PenaltyRepo *repository.PenaltyRepository
+    DisputeRepo *repository.DisputeRepository
+}
+
+func NewFineHandler(pRepo *repository.PenaltyRepository, dRepo *repository.DisputeRepository) *FineHandler {
+    return &FineHandler{PenaltyRepo: pRepo, DisputeRepo: dRepo}
+}
+
+func (h *FineHandler) GetUserFines(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    fines, err := h.PenaltyRepo.GetUserFines(userID)
+    if err != nil {
+        http.Error(w, "Failed to get fines", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(fines)
+}
+
+// DisputeFine lets the user contest a fine, e.g. when the car was already
+// dirty when they picked it up. It opens an invoice dispute on the fine's
+// line, which support approves or rejects from the dispute queue.
+func (h *FineHandler) DisputeFine(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    fineID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid fine ID", http.StatusBadRequest)
+        return
+    }
+
+    var req models.DisputeRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    req.Reason = strings.TrimSpace(req.Reason)
+    if req.Reason == "" {
+        http.Error(w, "A reason is required", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    dispute, err := h.DisputeRepo.DisputeFine(fineID, userID, req.Reason)
+    switch err {
+    case nil:
+    case repository.ErrFineNotFound, repository.ErrLineNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case repository.ErrFineAlreadyDisputed, repository.ErrDisputeWindowClosed,
+        repository.ErrInvoiceNotIssued, repository.ErrDisputeExists:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to dispute fine: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(dispute)
+}
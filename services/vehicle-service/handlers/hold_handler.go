@@ -0,0 +1,180 @@
+// Path: services/vehicle-service/handlers/hold_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "log"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/billing"
+    "vehicle-service/calendar"
+    "vehicle-service/models"
+    "vehicle-service/notifications"
+    "vehicle-service/repository"
+    "vehicle-service/waitlist"
+)
+
+type HoldHandler struct {
+    HoldRepo        *repository.HoldRepository
+    ReservationRepo *repository.ReservationRepository
+    UserRepo        *repository.UserRepository
+    Mailer          notifications.Mailer
+    Waitlist        *waitlist.Service
+    Billing         *billing.Service
+}
+
+func NewHoldHandler(hRepo *repository.HoldRepository, rRepo *repository.ReservationRepository, uRepo *repository.UserRepository, mailer notifications.Mailer, wl *waitlist.Service, billingService *billing.Service) *HoldHandler {
+    return &HoldHandler{
+        HoldRepo:        hRepo,
+        ReservationRepo: rRepo,
+        UserRepo:        uRepo,
+        Mailer:          mailer,
+        Waitlist:        wl,
+        Billing:         billingService,
+    }
+}
+
+func (h *HoldHandler) CreateHold(w http.ResponseWriter, r *http.Request) {
+    var req models.HoldRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if req.StartTime.IsZero() || req.EndTime.IsZero() {
+        http.Error(w, "Start time and end time are required", http.StatusBadRequest)
+        return
+    }
+
+    if !req.EndTime.After(req.StartTime) || !req.EndTime.After(time.Now()) {
+        http.Error(w, "End time must be after start time and in the future", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    if !requireLicense(w, h.UserRepo, userID, req.EndTime) {
+        return
+    }
+
+    hold := &models.BookingHold{
+        UserID:    userID,
+        VehicleID: req.VehicleID,
+        StartTime: req.StartTime,
+        EndTime:   req.EndTime,
+    }
+
+    err := h.HoldRepo.CreateHold(hold)
+    var conflictErr *repository.ConflictError
+    switch {
+    case err == nil:
+    case errors.As(err, &conflictErr), err == repository.ErrTooManyHolds:
+        http.Error(w, "Failed to hold vehicle: "+err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to hold vehicle: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(hold)
+}
+
+func (h *HoldHandler) GetUserHolds(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    holds, err := h.HoldRepo.GetUserHolds(userID)
+    if err != nil {
+        http.Error(w, "Failed to get holds", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(holds)
+}
+
+func (h *HoldHandler) ConfirmHold(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    holdID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+        return
+    }
+
+    // The body is optional
+    var req models.ConfirmHoldRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    reservation, err := h.HoldRepo.ConfirmHold(holdID, userID)
+    var conflictErr *repository.ConflictError
+    switch {
+    case err == nil:
+    case err == repository.ErrHoldNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case err == repository.ErrHoldNotActive, errors.As(err, &conflictErr):
+        http.Error(w, "Failed to confirm hold: "+err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to confirm hold: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    // The license was checked when the hold was made but may have lapsed or
+    // been revoked since
+    if !requireLicense(w, h.UserRepo, userID, reservation.EndTime) {
+        if err := h.ReservationRepo.CancelReservation(reservation.ID, userID); err != nil {
+            log.Printf("Holds: failed to cancel unlicensed reservation %d: %v", reservation.ID, err)
+        }
+        return
+    }
+
+    if _, err := h.Billing.ReservationBooked(reservation.ID, userID, req.PromoCode); err != nil {
+        writeBookingError(w, "Failed to confirm hold: ", err)
+        return
+    }
+    sendReservationEmail(h.ReservationRepo, h.UserRepo, h.Mailer, reservation.ID, userID, calendar.MethodRequest)
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(reservation)
+}
+
+func (h *HoldHandler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    holdID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid hold ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    hold, err := h.HoldRepo.ReleaseHold(holdID, userID)
+    if err == repository.ErrHoldNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to release hold: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    h.Waitlist.VehicleFreed(hold.VehicleID, hold.StartTime, hold.EndTime)
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Hold released",
+    })
+}
@@ -0,0 +1,145 @@
+// Path: services/vehicle-service/handlers/invoice_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/billing"
+    "vehicle-service/models"
+    "vehicle-service/repository"
+)
+
+type InvoiceHandler struct {
+    BillingRepo *repository.BillingRepository
+    PaymentRepo *repository.PaymentRepository
+    Billing     *billing.Service
+}
+
+func NewInvoiceHandler(bRepo *repository.BillingRepository, payRepo *repository.PaymentRepository, billingService *billing.Service) *InvoiceHandler {
+    return &InvoiceHandler{BillingRepo: bRepo, PaymentRepo: payRepo, Billing: billingService}
+}
+
+func (h *InvoiceHandler) GetUserInvoices(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    invoices, err := h.BillingRepo.GetUserInvoices(userID)
+    if err != nil {
+        http.Error(w, "Failed to get invoices", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(invoices)
+}
+
+func (h *InvoiceHandler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    invoiceID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    invoice, err := h.BillingRepo.GetInvoice(invoiceID, userID)
+    if err == repository.ErrInvoiceNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get invoice", http.StatusInternalServerError)
+        return
+    }
+
+    payment, err := h.PaymentRepo.GetPaymentByReservation(invoice.ReservationID)
+    if err != nil && err != repository.ErrPaymentNotFound {
+        http.Error(w, "Failed to get invoice", http.StatusInternalServerError)
+        return
+    }
+    invoice.Payment = payment
+
+    deposit, err := h.PaymentRepo.GetDepositByReservation(invoice.ReservationID)
+    if err != nil && err != repository.ErrDepositNotFound {
+        http.Error(w, "Failed to get invoice", http.StatusInternalServerError)
+        return
+    }
+    invoice.Deposit = deposit
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(invoice)
+}
+
+// GetInvoicePDF downloads the invoice as a PDF receipt
+func (h *InvoiceHandler) GetInvoicePDF(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    invoiceID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid invoice ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    invoice, data, err := h.Billing.InvoicePDF(invoiceID, userID)
+    if err == repository.ErrInvoiceNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to generate invoice PDF", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/pdf")
+    w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=invoice-%d.pdf", invoice.ID))
+    w.Write(data)
+}
+
+// AssessDamage lets staff charge damage found on a returned vehicle while
+// the trip's security deposit is still held
+func (h *InvoiceHandler) AssessDamage(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    reservationID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+        return
+    }
+
+    var req models.DamageAssessment
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    req.Description = strings.TrimSpace(req.Description)
+    if req.AmountCents <= 0 {
+        http.Error(w, "amount_cents must be positive", http.StatusBadRequest)
+        return
+    }
+    if req.Description == "" {
+        http.Error(w, "description is required", http.StatusBadRequest)
+        return
+    }
+
+    invoice, err := h.Billing.AssessDamage(reservationID, req.AmountCents, req.Description)
+    switch err {
+    case nil:
+    case repository.ErrInvoiceNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case billing.ErrTripNotFinished, billing.ErrDepositSettled:
+        http.Error(w, "Failed to assess damage: "+err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to assess damage: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(invoice)
+}
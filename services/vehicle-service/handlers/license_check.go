@@ -0,0 +1,31 @@
+// Path: services/vehicle-service/handlers/license_check.go
+package handlers
+
+import (
+    "net/http"
+    "time"
+
+    "vehicle-service/repository"
+)
+
+// requireLicense checks that the user has a verified driver's license that
+// is still valid when the booking ends, and writes the error response if not
+func requireLicense(w http.ResponseWriter, userRepo *repository.UserRepository, userID int, until time.Time) bool {
+    expiresOn, err := userRepo.GetLicenseExpiry(userID)
+    if err == repository.ErrNoVerifiedLicense {
+        http.Error(w, err.Error(), http.StatusForbidden)
+        return false
+    }
+    if err != nil {
+        http.Error(w, "Failed to check driver's license", http.StatusInternalServerError)
+        return false
+    }
+
+    validUntil := time.Date(expiresOn.Year(), expiresOn.Month(), expiresOn.Day()+1, 0, 0, 0, 0, time.Local)
+    if !until.Before(validUntil) {
+        http.Error(w, "Your driver's license expires on "+expiresOn.Format("2006-01-02")+
+            ", before this booking ends. Submit your renewed license to book.", http.StatusForbidden)
+        return false
+    }
+    return true
+}
@@ -0,0 +1,223 @@
+// Path: services/vehicle-service/handlers/organization_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strings"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/repository"
+    "vehicle-service/statements"
+)
+
+// Default window for the member reservations view: 30 days either side of now
+const memberReservationsRange = 30 * 24 * time.Hour
+
+type OrganizationHandler struct {
+    OrgRepo *repository.OrganizationRepository
+}
+
+func NewOrganizationHandler(oRepo *repository.OrganizationRepository) *OrganizationHandler {
+    return &OrganizationHandler{OrgRepo: oRepo}
+}
+
+// GetMemberReservations shows an organization admin every business booking
+// overlapping the from and to query parameters (RFC 3339)
+func (h *OrganizationHandler) GetMemberReservations(w http.ResponseWriter, r *http.Request) {
+    membership, ok := h.membership(w, r, true)
+    if !ok {
+        return
+    }
+
+    query := r.URL.Query()
+    var err error
+
+    now := time.Now()
+    from := now.Add(-memberReservationsRange)
+    if v := query.Get("from"); v != "" {
+        if from, err = time.Parse(time.RFC3339, v); err != nil {
+            http.Error(w, "Invalid from time", http.StatusBadRequest)
+            return
+        }
+    }
+    to := now.Add(memberReservationsRange)
+    if v := query.Get("to"); v != "" {
+        if to, err = time.Parse(time.RFC3339, v); err != nil {
+            http.Error(w, "Invalid to time", http.StatusBadRequest)
+            return
+        }
+    }
+    if !to.After(from) {
+        http.Error(w, "to must be after from", http.StatusBadRequest)
+        return
+    }
+
+    reservations, err := h.OrgRepo.GetMemberReservations(membership.OrganizationID, from, to)
+    if err != nil {
+        http.Error(w, "Failed to get reservations", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(reservations)
+}
+
+// GetCompanyBill returns the invoices billed to the organization in the
+// month query parameter (YYYY-MM, default this month) with cost center totals
+func (h *OrganizationHandler) GetCompanyBill(w http.ResponseWriter, r *http.Request) {
+    membership, ok := h.membership(w, r, true)
+    if !ok {
+        return
+    }
+
+    now := time.Now()
+    month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+    if v := r.URL.Query().Get("month"); v != "" {
+        var err error
+        if month, err = time.ParseInLocation(statements.MonthFormat, v, time.Local); err != nil {
+            http.Error(w, "Invalid month, expected YYYY-MM", http.StatusBadRequest)
+            return
+        }
+    }
+
+    bill, err := h.OrgRepo.GetCompanyBill(membership.OrganizationID, month)
+    if err != nil {
+        http.Error(w, "Failed to get company bill", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(bill)
+}
+
+// GetCostCenters lists the cost centers members can charge bookings to
+func (h *OrganizationHandler) GetCostCenters(w http.ResponseWriter, r *http.Request) {
+    membership, ok := h.membership(w, r, false)
+    if !ok {
+        return
+    }
+
+    centers, err := h.OrgRepo.GetCostCenters(membership.OrganizationID)
+    if err != nil {
+        http.Error(w, "Failed to get cost centers", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(centers)
+}
+
+func (h *OrganizationHandler) CreateCostCenter(w http.ResponseWriter, r *http.Request) {
+    membership, ok := h.membership(w, r, true)
+    if !ok {
+        return
+    }
+
+    var req models.CostCenterRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+    req.Name = strings.TrimSpace(req.Name)
+    if req.Code == "" || req.Name == "" {
+        http.Error(w, "code and name are required", http.StatusBadRequest)
+        return
+    }
+
+    center := &models.CostCenter{
+        OrganizationID: membership.OrganizationID,
+        Code:           req.Code,
+        Name:           req.Name,
+    }
+    err := h.OrgRepo.CreateCostCenter(center)
+    if err == repository.ErrCostCenterExists {
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to create cost center: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(center)
+}
+
+func (h *OrganizationHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+    membership, ok := h.membership(w, r, false)
+    if !ok {
+        return
+    }
+
+    policy, err := h.OrgRepo.GetPolicy(membership.OrganizationID)
+    if err != nil {
+        http.Error(w, "Failed to get booking policy", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(policy)
+}
+
+// SetPolicy replaces the organization's booking policy. It applies to
+// business bookings made from now on.
+func (h *OrganizationHandler) SetPolicy(w http.ResponseWriter, r *http.Request) {
+    membership, ok := h.membership(w, r, true)
+    if !ok {
+        return
+    }
+
+    var policy models.OrganizationPolicy
+    if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if policy.MaxRentalHours != nil && *policy.MaxRentalHours <= 0 {
+        http.Error(w, "max_rental_hours must be positive", http.StatusBadRequest)
+        return
+    }
+    allowed := []string{}
+    for _, vehicleType := range policy.AllowedVehicleTypes {
+        if vehicleType = strings.TrimSpace(vehicleType); vehicleType != "" {
+            allowed = append(allowed, vehicleType)
+        }
+    }
+    policy.AllowedVehicleTypes = allowed
+    policy.OrganizationID = membership.OrganizationID
+
+    if err := h.OrgRepo.SetPolicy(&policy, r.Context().Value("user_id").(int)); err != nil {
+        http.Error(w, "Failed to set booking policy: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(policy)
+}
+
+// membership looks up the caller's organization and writes the error
+// response if they have none, or aren't its admin when admin is set
+func (h *OrganizationHandler) membership(w http.ResponseWriter, r *http.Request, admin bool) (*models.Membership, bool) {
+    userID := r.Context().Value("user_id").(int)
+
+    membership, err := h.OrgRepo.GetMembership(userID)
+    if err == repository.ErrNotMember {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return nil, false
+    }
+    if err != nil {
+        http.Error(w, "Failed to get organization", http.StatusInternalServerError)
+        return nil, false
+    }
+    if admin && membership.Role != models.OrgRoleAdmin {
+        http.Error(w, "Only organization admins can do this", http.StatusForbidden)
+        return nil, false
+    }
+
+    return membership, true
+}
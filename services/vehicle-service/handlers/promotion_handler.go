@@ -0,0 +1,64 @@
+// Path: services/vehicle-service/handlers/promotion_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+
+    "vehicle-service/billing"
+    "vehicle-service/models"
+    "vehicle-service/repository"
+)
+
+type PromotionHandler struct {
+    VehicleRepo *repository.VehicleRepository
+    Billing     *billing.Service
+}
+
+func NewPromotionHandler(vRepo *repository.VehicleRepository, billingService *billing.Service) *PromotionHandler {
+    return &PromotionHandler{VehicleRepo: vRepo, Billing: billingService}
+}
+
+// ValidatePromotion tells the user whether a code applies to the booking
+// they are about to make and what it would save them.
+func (h *PromotionHandler) ValidatePromotion(w http.ResponseWriter, r *http.Request) {
+    var req models.PromoValidationRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if req.Code == "" {
+        http.Error(w, "Promo code is required", http.StatusBadRequest)
+        return
+    }
+    if !req.EndTime.After(req.StartTime) {
+        http.Error(w, "End time must be after start time", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    vehicle, err := h.VehicleRepo.GetVehicleByID(req.VehicleID)
+    if err == repository.ErrVehicleNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to validate promo code: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    preview, err := h.Billing.PreviewPromotion(req.Code, userID, vehicle, req.StartTime, req.EndTime)
+    if err == billing.ErrNoRateCard {
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to validate promo code: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(preview)
+}
@@ -0,0 +1,86 @@
+// Path: services/vehicle-service/handlers/rate_card_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strings"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/repository"
+)
+
+type RateCardHandler struct {
+    BillingRepo *repository.BillingRepository
+}
+
+func NewRateCardHandler(bRepo *repository.BillingRepository) *RateCardHandler {
+    return &RateCardHandler{BillingRepo: bRepo}
+}
+
+// GetRateCards lists every published version, so old invoices can be
+// checked against the prices they were charged at
+func (h *RateCardHandler) GetRateCards(w http.ResponseWriter, r *http.Request) {
+    cards, err := h.BillingRepo.GetRateCards()
+    if err != nil {
+        http.Error(w, "Failed to get rate cards", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(cards)
+}
+
+// CreateRateCard lets staff publish new prices for a vehicle type. Existing
+// bookings keep the version they were priced with.
+func (h *RateCardHandler) CreateRateCard(w http.ResponseWriter, r *http.Request) {
+    var req models.RateCardRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    req.VehicleType = strings.TrimSpace(req.VehicleType)
+    if req.VehicleType == "" {
+        http.Error(w, "vehicle_type is required", http.StatusBadRequest)
+        return
+    }
+    currency, ok := normalizeCurrency(req.Currency)
+    if !ok {
+        http.Error(w, "currency must be a three-letter ISO 4217 code", http.StatusBadRequest)
+        return
+    }
+    if req.HourlyRateCents <= 0 {
+        http.Error(w, "hourly_rate_cents must be positive", http.StatusBadRequest)
+        return
+    }
+    if req.PerKmCents < 0 || req.IncludedKm < 0 || req.EnergyCentsPerPercent < 0 {
+        http.Error(w, "Distance and energy rates must not be negative", http.StatusBadRequest)
+        return
+    }
+
+    card := &models.RateCard{
+        VehicleType:           req.VehicleType,
+        Currency:              currency,
+        HourlyRateCents:       req.HourlyRateCents,
+        PerKmCents:            req.PerKmCents,
+        IncludedKm:            req.IncludedKm,
+        EnergyCentsPerPercent: req.EnergyCentsPerPercent,
+        EffectiveFrom:         time.Now(),
+    }
+    if req.EffectiveFrom != nil {
+        card.EffectiveFrom = *req.EffectiveFrom
+    }
+
+    staffID := r.Context().Value("user_id").(int)
+
+    if err := h.BillingRepo.CreateRateCard(card, staffID); err != nil {
+        http.Error(w, "Failed to create rate card: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(card)
+}
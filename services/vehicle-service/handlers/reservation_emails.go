@@ -0,0 +1,174 @@
+// Path: services/vehicle-service/handlers/reservation_emails.go
+package handlers
+
+import (
+    "fmt"
+    "log"
+    "strings"
+
+    "vehicle-service/billing"
+    "vehicle-service/calendar"
+    "vehicle-service/models"
+    "vehicle-service/notifications"
+    "vehicle-service/repository"
+)
+
+// sendReservationEmail emails the user about a booking change with an .ics
+// invite attached. It runs in the background; a mail failure never fails the
+// request that triggered it.
+func sendReservationEmail(rRepo *repository.ReservationRepository, uRepo *repository.UserRepository, mailer notifications.Mailer, reservationID int, userID int, method string) {
+    go func() {
+        reservation, err := rRepo.GetReservation(reservationID, userID)
+        if err != nil {
+            log.Printf("Reservation email: failed to load reservation %d: %v", reservationID, err)
+            return
+        }
+
+        email, err := uRepo.GetUserEmail(userID)
+        if err != nil {
+            log.Printf("Reservation email: failed to load user %d: %v", userID, err)
+            return
+        }
+
+        var subject string
+        switch {
+        case method == calendar.MethodCancel:
+            subject = fmt.Sprintf("Reservation #%d cancelled", reservation.ID)
+        case reservation.Sequence > 0:
+            subject = fmt.Sprintf("Reservation #%d updated", reservation.ID)
+        default:
+            subject = fmt.Sprintf("Reservation #%d confirmed", reservation.ID)
+        }
+
+        body := fmt.Sprintf(
+            "%s\n\nVehicle: %s (%s)\nPickup: %s\nFrom: %s\nTo: %s\n",
+            subject,
+            reservation.Vehicle.Model, reservation.Vehicle.Type,
+            reservation.Vehicle.Location,
+            reservation.StartTime.Format("Mon 02 Jan 2006 15:04 MST"),
+            reservation.EndTime.Format("Mon 02 Jan 2006 15:04 MST"),
+        )
+
+        err = mailer.Send(notifications.Message{
+            To:      email,
+            Subject: subject,
+            Body:    body,
+            Attachments: []notifications.Attachment{{
+                Filename:    fmt.Sprintf("reservation-%d.ics", reservation.ID),
+                ContentType: fmt.Sprintf("text/calendar; charset=utf-8; method=%s", method),
+                Data:        calendar.Invite(*reservation, method),
+            }},
+        })
+        if err != nil {
+            log.Printf("Reservation email: failed to send to %s: %v", email, err)
+        }
+    }()
+}
+
+// sendReceiptEmail sends the trip-completed email with the invoice PDF
+// attached. Business trips aren't charged to the employee, so their invoice
+// goes to the organization's billing address and the employee is only told
+// the trip is done.
+func sendReceiptEmail(billingService *billing.Service, uRepo *repository.UserRepository, oRepo *repository.OrganizationRepository, mailer notifications.Mailer, invoiceID int, userID int) {
+    go func() {
+        invoice, data, err := billingService.InvoicePDF(invoiceID, userID)
+        if err != nil {
+            log.Printf("Receipt email: failed to render invoice %d: %v", invoiceID, err)
+            return
+        }
+
+        email, err := uRepo.GetUserEmail(userID)
+        if err != nil {
+            log.Printf("Receipt email: failed to load user %d: %v", userID, err)
+            return
+        }
+
+        attachment := notifications.Attachment{
+            Filename:    fmt.Sprintf("invoice-%d.pdf", invoice.ID),
+            ContentType: "application/pdf",
+            Data:        data,
+        }
+        amount := invoice.Currency + " " + billing.FormatAmount(invoice.TotalCents, invoice.Currency)
+        subject := fmt.Sprintf("Trip completed - receipt for reservation #%d", invoice.ReservationID)
+
+        messages := []notifications.Message{{
+            To:      email,
+            Subject: subject,
+            Body: fmt.Sprintf(
+                "Thanks for driving with us.\n\nYour trip for reservation #%d is complete. Total charged: %s\n\nThe receipt is attached as a PDF.\n",
+                invoice.ReservationID, amount,
+            ),
+            Attachments: []notifications.Attachment{attachment},
+        }}
+
+        if invoice.OrganizationID != nil {
+            orgName, billingEmail, err := oRepo.GetBillingContact(*invoice.OrganizationID)
+            if err != nil {
+                log.Printf("Receipt email: failed to load organization %d: %v", *invoice.OrganizationID, err)
+                return
+            }
+            messages = []notifications.Message{
+                {
+                    To:      email,
+                    Subject: fmt.Sprintf("Trip completed - reservation #%d", invoice.ReservationID),
+                    Body: fmt.Sprintf(
+                        "Thanks for driving with us.\n\nYour business trip for reservation #%d is complete. It has been billed to %s, so nothing was charged to you.\n",
+                        invoice.ReservationID, orgName,
+                    ),
+                },
+                {
+                    To:      billingEmail,
+                    Subject: fmt.Sprintf("Invoice for business trip - reservation #%d", invoice.ReservationID),
+                    Body: fmt.Sprintf(
+                        "A business trip by %s for reservation #%d is complete. Amount billed to %s: %s\n\nThe invoice is attached as a PDF. It will also appear on your monthly company bill.\n",
+                        email, invoice.ReservationID, orgName, amount,
+                    ),
+                    Attachments: []notifications.Attachment{attachment},
+                },
+            }
+        }
+
+        for _, msg := range messages {
+            if err := mailer.Send(msg); err != nil {
+                log.Printf("Receipt email: failed to send to %s: %v", msg.To, err)
+            }
+        }
+    }()
+}
+
+// sendSeriesEmail emails a summary of a recurring booking change with every
+// affected occurrence in one attached calendar.
+func sendSeriesEmail(uRepo *repository.UserRepository, mailer notifications.Mailer, userID int, seriesID int, subject string, reservations []models.Reservation) {
+    go func() {
+        email, err := uRepo.GetUserEmail(userID)
+        if err != nil {
+            log.Printf("Series email: failed to load user %d: %v", userID, err)
+            return
+        }
+
+        var body strings.Builder
+        body.WriteString(subject + "\n\n")
+        for _, res := range reservations {
+            fmt.Fprintf(&body, "#%d  %s - %s  %s\n",
+                res.ID,
+                res.StartTime.Format("Mon 02 Jan 2006 15:04"),
+                res.EndTime.Format("15:04 MST"),
+                res.Status,
+            )
+        }
+
+        err = mailer.Send(notifications.Message{
+            To:      email,
+            Subject: subject,
+            Body:    body.String(),
+            Attachments: []notifications.Attachment{{
+                Filename:    fmt.Sprintf("reservation-series-%d.ics", seriesID),
+                ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+                Data:        calendar.Feed(reservations),
+            }},
+        })
+        if err != nil {
+            log.Printf("Series email: failed to send to %s: %v", email, err)
+        }
+    }()
+}
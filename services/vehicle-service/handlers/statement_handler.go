@@ -0,0 +1,85 @@
+// Path: services/vehicle-service/handlers/statement_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/repository"
+    "vehicle-service/statements"
+)
+
+type StatementHandler struct {
+    StatementRepo *repository.StatementRepository
+    UserRepo      *repository.UserRepository
+}
+
+func NewStatementHandler(sRepo *repository.StatementRepository, uRepo *repository.UserRepository) *StatementHandler {
+    return &StatementHandler{StatementRepo: sRepo, UserRepo: uRepo}
+}
+
+func (h *StatementHandler) GetStatements(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    list, err := h.StatementRepo.GetStatements(userID)
+    if err != nil {
+        http.Error(w, "Failed to get statements", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(list)
+}
+
+// GetStatement returns one month's statement as JSON, or as a download with
+// ?format=csv or ?format=pdf
+func (h *StatementHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    month, err := time.ParseInLocation(statements.MonthFormat, vars["month"], time.Local)
+    if err != nil {
+        http.Error(w, "Invalid month, expected YYYY-MM", http.StatusBadRequest)
+        return
+    }
+
+    format := r.URL.Query().Get("format")
+    if format != "" && format != "json" && format != "csv" && format != "pdf" {
+        http.Error(w, "format must be json, csv or pdf", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    statement, err := h.StatementRepo.GetStatement(userID, month)
+    if err == repository.ErrStatementNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get statement", http.StatusInternalServerError)
+        return
+    }
+
+    filename := "statement-" + month.Format(statements.MonthFormat)
+
+    switch format {
+    case "csv":
+        w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+        w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+        w.Write(statements.CSV(statement))
+    case "pdf":
+        email, err := h.UserRepo.GetUserEmail(userID)
+        if err != nil {
+            http.Error(w, "Failed to generate statement PDF", http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/pdf")
+        w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", filename))
+        w.Write(statements.PDF(statement, email))
+    default:
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(statement)
+    }
+}
@@ -0,0 +1,89 @@
+// Path: services/vehicle-service/handlers/stream_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "time"
+
+    "vehicle-service/events"
+    "vehicle-service/repository"
+)
+
+// Keeps idle connections from being closed by proxies
+const streamHeartbeat = 25 * time.Second
+
+type StreamHandler struct {
+    VehicleRepo *repository.VehicleRepository
+    Events      *events.Broker
+}
+
+func NewStreamHandler(vRepo *repository.VehicleRepository, broker *events.Broker) *StreamHandler {
+    return &StreamHandler{VehicleRepo: vRepo, Events: broker}
+}
+
+// StreamVehicles pushes live vehicle changes as Server-Sent Events. The first
+// event is a snapshot of the whole fleet; every later "vehicle" event carries
+// one vehicle's new state.
+func (h *StreamHandler) StreamVehicles(w http.ResponseWriter, r *http.Request) {
+    rc := http.NewResponseController(w)
+    // The server's WriteTimeout would otherwise cut the stream after 15s
+    if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+        http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+        return
+    }
+
+    // Subscribe before the snapshot so nothing between the two is lost
+    sub := h.Events.Subscribe()
+    defer h.Events.Unsubscribe(sub)
+
+    vehicles, err := h.VehicleRepo.GetAllVehicles()
+    if err != nil {
+        http.Error(w, "Failed to get vehicles", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("Connection", "keep-alive")
+    w.WriteHeader(http.StatusOK)
+
+    if err := writeEvent(w, "snapshot", vehicles); err != nil {
+        return
+    }
+    rc.Flush()
+
+    heartbeat := time.NewTicker(streamHeartbeat)
+    defer heartbeat.Stop()
+
+    for {
+        select {
+        case <-r.Context().Done():
+            return
+        case event, ok := <-sub:
+            if !ok {
+                return
+            }
+            if err := writeEvent(w, "vehicle", event); err != nil {
+                log.Printf("Vehicle stream write failed: %v", err)
+                return
+            }
+        case <-heartbeat.C:
+            if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+                return
+            }
+        }
+        rc.Flush()
+    }
+}
+
+func writeEvent(w http.ResponseWriter, name string, data interface{}) error {
+    payload, err := json.Marshal(data)
+    if err != nil {
+        return err
+    }
+    _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+    return err
+}
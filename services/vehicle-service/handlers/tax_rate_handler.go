@@ -0,0 +1,98 @@
+// Path: services/vehicle-service/handlers/tax_rate_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strings"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/repository"
+)
+
+type TaxRateHandler struct {
+    BillingRepo *repository.BillingRepository
+}
+
+func NewTaxRateHandler(bRepo *repository.BillingRepository) *TaxRateHandler {
+    return &TaxRateHandler{BillingRepo: bRepo}
+}
+
+// GetTaxRates lists every region's rates, including past and scheduled ones
+func (h *TaxRateHandler) GetTaxRates(w http.ResponseWriter, r *http.Request) {
+    rates, err := h.BillingRepo.GetTaxRates()
+    if err != nil {
+        http.Error(w, "Failed to get tax rates", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(rates)
+}
+
+// CreateTaxRate lets staff set a region's tax and billing currency from a
+// given time. Bookings already made keep the rate they were invoiced with.
+func (h *TaxRateHandler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+    var req models.TaxRateRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    req.Region = strings.ToUpper(strings.TrimSpace(req.Region))
+    req.Name = strings.TrimSpace(req.Name)
+    if req.Region == "" || req.Name == "" {
+        http.Error(w, "region and name are required", http.StatusBadRequest)
+        return
+    }
+    currency, ok := normalizeCurrency(req.Currency)
+    if !ok {
+        http.Error(w, "currency must be a three-letter ISO 4217 code", http.StatusBadRequest)
+        return
+    }
+    if req.RateBps < 0 || req.RateBps > 10000 {
+        http.Error(w, "rate_bps must be between 0 and 10000", http.StatusBadRequest)
+        return
+    }
+
+    rate := &models.TaxRate{
+        Region:        req.Region,
+        Currency:      currency,
+        Name:          req.Name,
+        RateBps:       req.RateBps,
+        EffectiveFrom: time.Now(),
+    }
+    if req.EffectiveFrom != nil {
+        rate.EffectiveFrom = *req.EffectiveFrom
+    }
+
+    staffID := r.Context().Value("user_id").(int)
+
+    if err := h.BillingRepo.CreateTaxRate(rate, staffID); err != nil {
+        http.Error(w, "Failed to create tax rate: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(rate)
+}
+
+// normalizeCurrency upper-cases an ISO 4217 code, defaulting to the wallet
+// currency when none is given
+func normalizeCurrency(code string) (string, bool) {
+    code = strings.ToUpper(strings.TrimSpace(code))
+    if code == "" {
+        return models.DefaultCurrency, true
+    }
+    if len(code) != 3 {
+        return "", false
+    }
+    for _, c := range code {
+        if c < 'A' || c > 'Z' {
+            return "", false
+        }
+    }
+    return code, true
+}
@@ -1,139 +1,776 @@
-// Path: services/vehicle-service/handlers/vehicle_handler.go
-package handlers
-
-import (
-    "encoding/json"
-    "net/http"
-    "strconv"
-
-    "github.com/gorilla/mux"
-    "vehicle-service/models"
-    "vehicle-service/repository"
-)
-
-type VehicleHandler struct {
-    VehicleRepo     *repository.VehicleRepository
-    ReservationRepo *repository.ReservationRepository
-}
-
-func NewVehicleHandler(vRepo *repository.VehicleRepository, rRepo *repository.ReservationRepository) *VehicleHandler {
-    return &VehicleHandler{
-        VehicleRepo:     vRepo,
-        ReservationRepo: rRepo,
-    }
-}
-
-func (h *VehicleHandler) GetAvailableVehicles(w http.ResponseWriter, r *http.Request) {
-    var req models.AvailabilityRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-
-    if req.StartTime.IsZero() || req.EndTime.IsZero() {
-        http.Error(w, "Start time and end time are required", http.StatusBadRequest)
-        return
-    }
-
-    if req.EndTime.Before(req.StartTime) {
-        http.Error(w, "End time must be after start time", http.StatusBadRequest)
-        return
-    }
-
-    vehicles, err := h.VehicleRepo.GetAvailableVehicles(req.StartTime, req.EndTime)
-    if err != nil {
-        http.Error(w, "Failed to get available vehicles", http.StatusInternalServerError)
-        return
-    }
-
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(vehicles)
-}
-
-func (h *VehicleHandler) CreateReservation(w http.ResponseWriter, r *http.Request) {
-    var req models.ReservationRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-
-    // Get user ID from context (set by auth middleware)
-    userID := r.Context().Value("user_id").(int)
-
-    reservation := &models.Reservation{
-        UserID:    userID,
-        VehicleID: req.VehicleID,
-        StartTime: req.StartTime,
-        EndTime:   req.EndTime,
-    }
-
-    if err := h.ReservationRepo.CreateReservation(reservation); err != nil {
-        http.Error(w, "Failed to create reservation: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(http.StatusCreated)
-    json.NewEncoder(w).Encode(reservation)
-}
-
-func (h *VehicleHandler) GetUserReservations(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("user_id").(int)
-
-    reservations, err := h.ReservationRepo.GetUserReservations(userID)
-    if err != nil {
-        http.Error(w, "Failed to get reservations", http.StatusInternalServerError)
-        return
-    }
-
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(reservations)
-}
-
-func (h *VehicleHandler) UpdateReservation(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    reservationID, err := strconv.Atoi(vars["id"])
-    if err != nil {
-        http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
-        return
-    }
-
-    userID := r.Context().Value("user_id").(int)
-
-    var req models.UpdateReservationRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        http.Error(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-
-    if err := h.ReservationRepo.UpdateReservation(reservationID, userID, req); err != nil {
-        http.Error(w, "Failed to update reservation: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    w.WriteHeader(http.StatusOK)
-    json.NewEncoder(w).Encode(map[string]string{
-        "message": "Reservation updated successfully",
-    })
-}
-
-func (h *VehicleHandler) CancelReservation(w http.ResponseWriter, r *http.Request) {
-    vars := mux.Vars(r)
-    reservationID, err := strconv.Atoi(vars["id"])
-    if err != nil {
-        http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
-        return
-    }
-
-    userID := r.Context().Value("user_id").(int)
-
-    if err := h.ReservationRepo.CancelReservation(reservationID, userID); err != nil {
-        http.Error(w, "Failed to cancel reservation: "+err.Error(), http.StatusInternalServerError)
-        return
-    }
-
-    w.WriteHeader(http.StatusOK)
-    json.NewEncoder(w).Encode(map[string]string{
-        "message": "Reservation cancelled successfully",
-    })
-}
\ No newline at end of file
+// Path: services/vehicle-service/handlers/vehicle_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/billing"
+    "vehicle-service/calendar"
+    "vehicle-service/models"
+    "vehicle-service/notifications"
+    "vehicle-service/organizations"
+    "vehicle-service/promotions"
+    "vehicle-service/recurrence"
+    "vehicle-service/repository"
+    "vehicle-service/tiers"
+    "vehicle-service/waitlist"
+)
+
+type VehicleHandler struct {
+    VehicleRepo     *repository.VehicleRepository
+    ReservationRepo *repository.ReservationRepository
+    UserRepo        *repository.UserRepository
+    SeriesRepo      *repository.SeriesRepository
+    OrgRepo         *repository.OrganizationRepository
+    ReferralRepo    *repository.ReferralRepository
+    Mailer          notifications.Mailer
+    Waitlist        *waitlist.Service
+    Billing         *billing.Service
+}
+
+func NewVehicleHandler(vRepo *repository.VehicleRepository, rRepo *repository.ReservationRepository, uRepo *repository.UserRepository, sRepo *repository.SeriesRepository, oRepo *repository.OrganizationRepository, refRepo *repository.ReferralRepository, mailer notifications.Mailer, wl *waitlist.Service, billingService *billing.Service) *VehicleHandler {
+    return &VehicleHandler{
+        VehicleRepo:     vRepo,
+        ReservationRepo: rRepo,
+        UserRepo:        uRepo,
+        SeriesRepo:      sRepo,
+        OrgRepo:         oRepo,
+        ReferralRepo:    refRepo,
+        Mailer:          mailer,
+        Waitlist:        wl,
+        Billing:         billingService,
+    }
+}
+
+func (h *VehicleHandler) GetAvailableVehicles(w http.ResponseWriter, r *http.Request) {
+    var req models.AvailabilityRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if req.StartTime.IsZero() || req.EndTime.IsZero() {
+        http.Error(w, "Start time and end time are required", http.StatusBadRequest)
+        return
+    }
+
+    if req.EndTime.Before(req.StartTime) {
+        http.Error(w, "End time must be after start time", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    vehicles, err := h.VehicleRepo.GetAvailableVehicles(req.StartTime, req.EndTime, userID)
+    if err != nil {
+        http.Error(w, "Failed to get available vehicles", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(vehicles)
+}
+
+func (h *VehicleHandler) CreateReservation(w http.ResponseWriter, r *http.Request) {
+    var req models.ReservationRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    // Get user ID from context (set by auth middleware)
+    userID := r.Context().Value("user_id").(int)
+
+    // Recurring bookings are checked against their last occurrence
+    if req.Recurrence == "" && !requireLicense(w, h.UserRepo, userID, req.EndTime) {
+        return
+    }
+
+    if req.Recurrence != "" {
+        if req.PromoCode != "" {
+            http.Error(w, "Promo codes cannot be used on recurring reservations", http.StatusBadRequest)
+            return
+        }
+        if req.Business {
+            http.Error(w, "Recurring reservations cannot be business bookings", http.StatusBadRequest)
+            return
+        }
+        h.createSeries(w, userID, req)
+        return
+    }
+    if req.Business && req.PromoCode != "" {
+        http.Error(w, "Promo codes cannot be used on business bookings", http.StatusBadRequest)
+        return
+    }
+
+    reservation := &models.Reservation{
+        UserID:    userID,
+        VehicleID: req.VehicleID,
+        StartTime: req.StartTime,
+        EndTime:   req.EndTime,
+    }
+
+    // Turn a vehicle that can't be priced, a bad code or a booking against
+    // company policy down before anything is booked
+    vehicle, ok := h.priceableVehicle(w, req.VehicleID)
+    if !ok {
+        return
+    }
+
+    if req.PromoCode != "" {
+        preview, err := h.Billing.PreviewPromotion(req.PromoCode, userID, vehicle, req.StartTime, req.EndTime)
+        if err != nil {
+            http.Error(w, "Failed to create reservation: "+err.Error(), http.StatusInternalServerError)
+            return
+        }
+        if !preview.Valid {
+            http.Error(w, "Invalid promo code: "+preview.Reason, http.StatusBadRequest)
+            return
+        }
+    }
+
+    if req.Business && !h.billToOrganization(w, reservation, vehicle, req.CostCenter) {
+        return
+    }
+
+    err := h.ReservationRepo.CreateReservation(reservation)
+    var conflictErr *repository.ConflictError
+    if errors.As(err, &conflictErr) {
+        http.Error(w, "Failed to create reservation: "+err.Error(), http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to create reservation: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    if _, err := h.Billing.ReservationBooked(reservation.ID, userID, req.PromoCode); err != nil {
+        writeBookingError(w, "Failed to create reservation: ", err)
+        return
+    }
+    sendReservationEmail(h.ReservationRepo, h.UserRepo, h.Mailer, reservation.ID, userID, calendar.MethodRequest)
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(reservation)
+}
+
+// priceableVehicle looks up the vehicle being booked and checks billing can
+// price it. Otherwise it writes the error response.
+func (h *VehicleHandler) priceableVehicle(w http.ResponseWriter, vehicleID int) (*models.Vehicle, bool) {
+    vehicle, err := h.VehicleRepo.GetVehicleByID(vehicleID)
+    if err == repository.ErrVehicleNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return nil, false
+    }
+    if err != nil {
+        http.Error(w, "Failed to create reservation: "+err.Error(), http.StatusInternalServerError)
+        return nil, false
+    }
+
+    err = h.Billing.CheckPriceable(vehicle)
+    if err == billing.ErrNoRateCard {
+        http.Error(w, err.Error(), http.StatusConflict)
+        return nil, false
+    }
+    if err != nil {
+        http.Error(w, "Failed to create reservation: "+err.Error(), http.StatusInternalServerError)
+        return nil, false
+    }
+
+    return vehicle, true
+}
+
+// writeBookingError reports why a new reservation couldn't be billed.
+// Billing has already cancelled it again.
+func writeBookingError(w http.ResponseWriter, prefix string, err error) {
+    var rejection *promotions.Rejection
+    switch {
+    case errors.As(err, &rejection):
+        http.Error(w, "Invalid promo code: "+rejection.Reason, http.StatusBadRequest)
+    case err == billing.ErrPaymentDeclined, err == billing.ErrDepositDeclined:
+        http.Error(w, prefix+err.Error(), http.StatusPaymentRequired)
+    case err == billing.ErrNoRateCard:
+        http.Error(w, prefix+err.Error(), http.StatusConflict)
+    default:
+        http.Error(w, prefix+err.Error(), http.StatusInternalServerError)
+    }
+}
+
+// billToOrganization checks a business booking against the user's
+// organization and its policy and, if it passes, charges it to the
+// organization. Otherwise it writes the error response.
+func (h *VehicleHandler) billToOrganization(w http.ResponseWriter, reservation *models.Reservation, vehicle *models.Vehicle, costCenterID int) bool {
+    membership, err := h.OrgRepo.GetMembership(reservation.UserID)
+    if err == repository.ErrNotMember {
+        http.Error(w, err.Error(), http.StatusForbidden)
+        return false
+    }
+    if err != nil {
+        http.Error(w, "Failed to create reservation: "+err.Error(), http.StatusInternalServerError)
+        return false
+    }
+
+    if costCenterID != 0 {
+        _, err := h.OrgRepo.GetCostCenter(membership.OrganizationID, costCenterID)
+        if err == repository.ErrCostCenterNotFound {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return false
+        }
+        if err != nil {
+            http.Error(w, "Failed to create reservation: "+err.Error(), http.StatusInternalServerError)
+            return false
+        }
+        reservation.CostCenterID = &costCenterID
+    }
+
+    policy, err := h.OrgRepo.GetPolicy(membership.OrganizationID)
+    if err != nil {
+        http.Error(w, "Failed to create reservation: "+err.Error(), http.StatusInternalServerError)
+        return false
+    }
+    err = organizations.CheckPolicy(policy, vehicle.Type, reservation.StartTime, reservation.EndTime, costCenterID)
+    if err != nil {
+        http.Error(w, "Booking not allowed: "+err.Error(), http.StatusForbidden)
+        return false
+    }
+
+    reservation.OrganizationID = &membership.OrganizationID
+    return true
+}
+
+// checkOrganizationPolicy re-checks a business booking against its
+// organization's policy when its times change to start and end. Personal
+// bookings always pass.
+func (h *VehicleHandler) checkOrganizationPolicy(reservation *models.Reservation, start, end time.Time) error {
+    if reservation.OrganizationID == nil {
+        return nil
+    }
+
+    vehicle, err := h.VehicleRepo.GetVehicleByID(reservation.VehicleID)
+    if err != nil {
+        return err
+    }
+    policy, err := h.OrgRepo.GetPolicy(*reservation.OrganizationID)
+    if err != nil {
+        return err
+    }
+
+    costCenterID := 0
+    if reservation.CostCenterID != nil {
+        costCenterID = *reservation.CostCenterID
+    }
+    return organizations.CheckPolicy(policy, vehicle.Type, start, end, costCenterID)
+}
+
+// createSeries expands a recurring request into individual reservations.
+// Occurrences that clash with other bookings are skipped and reported.
+func (h *VehicleHandler) createSeries(w http.ResponseWriter, userID int, req models.ReservationRequest) {
+    if !req.EndTime.After(req.StartTime) {
+        http.Error(w, "End time must be after start time", http.StatusBadRequest)
+        return
+    }
+
+    rule, err := recurrence.Parse(req.Recurrence)
+    if err != nil {
+        http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    vehicle, ok := h.priceableVehicle(w, req.VehicleID)
+    if !ok {
+        return
+    }
+
+    series := &models.ReservationSeries{
+        UserID:    userID,
+        VehicleID: req.VehicleID,
+        RRule:     req.Recurrence,
+        StartTime: req.StartTime,
+        EndTime:   req.EndTime,
+    }
+
+    occurrences := rule.Expand(req.StartTime)
+    if len(occurrences) > 0 && !requireLicense(w, h.UserRepo, userID, occurrences[len(occurrences)-1].Add(req.EndTime.Sub(req.StartTime))) {
+        return
+    }
+
+    result, err := h.SeriesRepo.CreateSeries(series, occurrences)
+    if err == repository.ErrNoFreeOccurrence {
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusConflict)
+        json.NewEncoder(w).Encode(result)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to create reservation series: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    // Occurrences that can't be billed are cancelled again by billing
+    billed := result.Created[:0]
+    for _, res := range result.Created {
+        _, err := h.Billing.ReservationBooked(res.ID, userID, "")
+        if err != nil {
+            if err != billing.ErrPaymentDeclined && err != billing.ErrDepositDeclined {
+                log.Printf("Billing: failed to bill reservation %d: %v", res.ID, err)
+            }
+            result.Conflicts = append(result.Conflicts, models.OccurrenceConflict{
+                StartTime: res.StartTime,
+                EndTime:   res.EndTime,
+                Reason:    err.Error(),
+            })
+            continue
+        }
+        billed = append(billed, res)
+    }
+    result.Created = billed
+
+    for i := range result.Created {
+        result.Created[i].Vehicle = vehicle
+    }
+    sendSeriesEmail(h.UserRepo, h.Mailer, userID, series.ID,
+        fmt.Sprintf("Recurring reservation #%d confirmed (%d bookings)", series.ID, len(result.Created)),
+        result.Created)
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(result)
+}
+
+func (h *VehicleHandler) GetSeries(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    seriesID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid series ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    series, err := h.SeriesRepo.GetSeries(seriesID, userID)
+    if err == repository.ErrSeriesNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get reservation series", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(series)
+}
+
+// CancelSeries cancels all upcoming occurrences. Single occurrences are
+// cancelled through CancelReservation like any other booking.
+func (h *VehicleHandler) CancelSeries(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    seriesID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid series ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    cancelled, err := h.SeriesRepo.CancelSeries(seriesID, userID)
+    if err == repository.ErrSeriesNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to cancel reservation series: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    now := time.Now()
+    for i := range cancelled {
+        res := &cancelled[i]
+        if _, err := h.Billing.ReservationCancelled(res, now); err != nil {
+            log.Printf("Billing: failed to apply cancellation to reservation %d: %v", res.ID, err)
+        }
+        h.Waitlist.VehicleFreed(res.VehicleID, res.StartTime, res.EndTime)
+    }
+    if len(cancelled) > 0 {
+        sendSeriesEmail(h.UserRepo, h.Mailer, userID, seriesID,
+            fmt.Sprintf("Recurring reservation #%d cancelled", seriesID),
+            cancelled)
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "message":   "Reservation series cancelled successfully",
+        "cancelled": len(cancelled),
+    })
+}
+
+func (h *VehicleHandler) GetUserReservations(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    reservations, err := h.ReservationRepo.GetUserReservations(userID)
+    if err != nil {
+        http.Error(w, "Failed to get reservations", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(reservations)
+}
+
+func (h *VehicleHandler) UpdateReservation(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    reservationID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    var req models.UpdateReservationRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if req.EndTime != nil && !requireLicense(w, h.UserRepo, userID, *req.EndTime) {
+        return
+    }
+
+    reservation, err := h.ReservationRepo.GetReservation(reservationID, userID)
+    if err == repository.ErrReservationNotFound {
+        http.Error(w, "Failed to update reservation: "+err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to update reservation: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+    start, end := reservation.StartTime, reservation.EndTime
+    if req.StartTime != nil {
+        start = *req.StartTime
+    }
+    if req.EndTime != nil {
+        end = *req.EndTime
+    }
+    err = h.checkOrganizationPolicy(reservation, start, end)
+    var violation *organizations.Violation
+    if errors.As(err, &violation) {
+        http.Error(w, "Change not allowed: "+err.Error(), http.StatusForbidden)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to update reservation: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    err = h.ReservationRepo.UpdateReservation(reservationID, userID, req)
+    var conflictErr *repository.ConflictError
+    if errors.As(err, &conflictErr) {
+        http.Error(w, "Failed to update reservation: "+err.Error(), http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to update reservation: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    err = h.Billing.ReservationChanged(reservationID, userID)
+    if err == billing.ErrPaymentDeclined {
+        h.undoChange(reservation)
+        http.Error(w, "Failed to update reservation: "+err.Error(), http.StatusPaymentRequired)
+        return
+    }
+    if err != nil {
+        log.Printf("Billing: failed to re-price reservation %d: %v", reservationID, err)
+    }
+    sendReservationEmail(h.ReservationRepo, h.UserRepo, h.Mailer, reservationID, userID, calendar.MethodRequest)
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Reservation updated successfully",
+    })
+}
+
+func (h *VehicleHandler) CancelReservation(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    reservationID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    reservation, err := h.ReservationRepo.GetReservation(reservationID, userID)
+    if err == repository.ErrReservationNotFound {
+        http.Error(w, "Failed to cancel reservation: "+err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to cancel reservation: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    err = h.ReservationRepo.CancelReservation(reservationID, userID)
+    if err == repository.ErrTripUnderway {
+        http.Error(w, "Failed to cancel reservation: "+err.Error(), http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to cancel reservation: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    h.Waitlist.VehicleFreed(reservation.VehicleID, reservation.StartTime, reservation.EndTime)
+    sendReservationEmail(h.ReservationRepo, h.UserRepo, h.Mailer, reservationID, userID, calendar.MethodCancel)
+
+    // The reservation stays cancelled either way; the client has to know
+    // the refund or fee didn't go through
+    outcome, err := h.Billing.ReservationCancelled(reservation, time.Now())
+    if err != nil {
+        http.Error(w, "Reservation cancelled but billing failed: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "message":      "Reservation cancelled successfully",
+        "cancellation": outcome,
+    })
+}
+
+// CompleteReservation ends a trip when the user hands the vehicle back. An
+// early return frees the rest of the booked time for the waitlist.
+func (h *VehicleHandler) CompleteReservation(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    reservationID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    reservation, err := h.ReservationRepo.CompleteReservation(reservationID, userID)
+    switch err {
+    case nil:
+    case repository.ErrReservationNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case repository.ErrReservationNotOngoing:
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to complete reservation: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    if reservation.ReturnedAt.Before(reservation.EndTime) {
+        h.Waitlist.VehicleFreed(reservation.VehicleID, *reservation.ReturnedAt, reservation.EndTime)
+    }
+
+    invoice, err := h.Billing.TripCompleted(reservation)
+    if err != nil {
+        log.Printf("Billing: failed to issue invoice for reservation %d: %v", reservationID, err)
+    } else {
+        sendReceiptEmail(h.Billing, h.UserRepo, h.OrgRepo, h.Mailer, invoice.ID, userID)
+    }
+
+    // A referred user's first trip pays out the referral to both sides
+    if _, err := h.ReferralRepo.RewardFirstTrip(userID); err != nil {
+        log.Printf("Referrals: failed to reward referral for user %d: %v", userID, err)
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "reservation": reservation,
+        "invoice":     invoice,
+    })
+}
+
+// ExtendReservation lengthens an ongoing trip. The response always carries
+// the quote for the extra time; when the extension can't be granted it also
+// says why and, for booking conflicts, the latest end time that would work.
+func (h *VehicleHandler) ExtendReservation(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    reservationID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    var req models.ExtendReservationRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    reservation, err := h.ReservationRepo.GetReservation(reservationID, userID)
+    if err == repository.ErrReservationNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to get reservation", http.StatusInternalServerError)
+        return
+    }
+
+    var newEnd time.Time
+    switch {
+    case req.EndTime != nil:
+        newEnd = *req.EndTime
+    case req.Minutes > 0:
+        newEnd = reservation.EndTime.Add(time.Duration(req.Minutes) * time.Minute)
+    default:
+        http.Error(w, "Either end_time or minutes is required", http.StatusBadRequest)
+        return
+    }
+    if !newEnd.After(reservation.EndTime) {
+        http.Error(w, repository.ErrInvalidExtension.Error(), http.StatusBadRequest)
+        return
+    }
+    if !requireLicense(w, h.UserRepo, userID, newEnd) {
+        return
+    }
+
+    tier, err := h.UserRepo.GetMembershipTier(userID)
+    if err != nil {
+        http.Error(w, "Failed to get membership tier", http.StatusInternalServerError)
+        return
+    }
+    policy := tiers.For(tier)
+
+    quote, err := h.Billing.QuoteExtension(reservation, tier, newEnd.Sub(reservation.EndTime))
+    if err != nil {
+        http.Error(w, "Failed to price extension: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+    result := models.ExtensionResult{
+        ReservationID:    reservation.ID,
+        CurrentEndTime:   reservation.EndTime,
+        RequestedEndTime: newEnd,
+        AddedCostCents:   quote.TotalCents,
+    }
+
+    // A business trip can only be extended as far as the company allows
+    err = h.checkOrganizationPolicy(reservation, reservation.StartTime, newEnd)
+    var violation *organizations.Violation
+    if err == nil {
+        err = h.ReservationRepo.ExtendReservation(reservationID, userID, newEnd, policy.MaxRentalDuration)
+    }
+    var conflictErr *repository.ConflictError
+    switch {
+    case errors.As(err, &violation):
+        result.Reason = err.Error()
+    case err == nil:
+        err := h.Billing.ReservationChanged(reservationID, userID)
+        if err == billing.ErrPaymentDeclined {
+            h.undoChange(reservation)
+            result.Reason = "The extra time could not be charged: " + err.Error()
+            w.Header().Set("Content-Type", "application/json")
+            w.WriteHeader(http.StatusPaymentRequired)
+            json.NewEncoder(w).Encode(result)
+            return
+        }
+        if err != nil {
+            h.undoChange(reservation)
+            http.Error(w, "Failed to price extension: "+err.Error(), http.StatusInternalServerError)
+            return
+        }
+        result.Extended = true
+        sendReservationEmail(h.ReservationRepo, h.UserRepo, h.Mailer, reservationID, userID, calendar.MethodRequest)
+    case errors.As(err, &conflictErr):
+        result.Reason = "The vehicle is booked by someone else from " + conflictErr.Conflict.StartTime.Format(time.RFC3339)
+        if conflictErr.Conflict.Kind == "maintenance" {
+            result.Reason = "The vehicle is scheduled for maintenance from " + conflictErr.Conflict.StartTime.Format(time.RFC3339)
+        }
+        latest := conflictErr.Conflict.FreeFrom()
+        if maxEnd := reservation.StartTime.Add(policy.MaxRentalDuration); maxEnd.Before(latest) {
+            latest = maxEnd
+        }
+        if latest.After(reservation.EndTime) {
+            result.LatestPossibleEnd = &latest
+        }
+    case err == repository.ErrExceedsTierLimit:
+        result.Reason = fmt.Sprintf("%s members can rent for at most %s in total", policy.Tier, policy.MaxRentalDuration)
+        latest := reservation.StartTime.Add(policy.MaxRentalDuration)
+        if latest.After(reservation.EndTime) {
+            result.LatestPossibleEnd = &latest
+        }
+    case err == repository.ErrReservationNotOngoing, err == repository.ErrInvalidExtension:
+        result.Reason = err.Error()
+    default:
+        http.Error(w, "Failed to extend reservation: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    if !result.Extended {
+        w.WriteHeader(http.StatusConflict)
+    }
+    json.NewEncoder(w).Encode(result)
+}
+
+// undoChange puts a reservation back to the times it had before a change
+// whose extra cost was declined, and prices it back
+func (h *VehicleHandler) undoChange(reservation *models.Reservation) {
+    err := h.ReservationRepo.UpdateReservation(reservation.ID, reservation.UserID, models.UpdateReservationRequest{
+        StartTime: &reservation.StartTime,
+        EndTime:   &reservation.EndTime,
+    })
+    if err != nil {
+        log.Printf("Billing: failed to undo declined change to reservation %d: %v", reservation.ID, err)
+        return
+    }
+    if err := h.Billing.ReservationChanged(reservation.ID, reservation.UserID); err != nil {
+        log.Printf("Billing: failed to re-price reservation %d: %v", reservation.ID, err)
+    }
+}
+
+// ReportVehicleStatus is called by vehicle agents with telemetry such as
+// charge level and location.
+func (h *VehicleHandler) ReportVehicleStatus(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    vehicleID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+        return
+    }
+
+    var req models.VehicleStatusUpdate
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if req.ChargeLevel != nil && (*req.ChargeLevel < 0 || *req.ChargeLevel > 100) {
+        http.Error(w, "Charge level must be between 0 and 100", http.StatusBadRequest)
+        return
+    }
+
+    if req.OdometerKm != nil && *req.OdometerKm < 0 {
+        http.Error(w, "Odometer must not be negative", http.StatusBadRequest)
+        return
+    }
+
+    if err := h.VehicleRepo.UpdateVehicle(vehicleID, req); err != nil {
+        http.Error(w, "Failed to update vehicle: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Vehicle status updated",
+    })
+}
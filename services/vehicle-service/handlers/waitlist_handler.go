@@ -0,0 +1,185 @@
+// Path: services/vehicle-service/handlers/waitlist_handler.go
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "log"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gorilla/mux"
+    "vehicle-service/billing"
+    "vehicle-service/calendar"
+    "vehicle-service/models"
+    "vehicle-service/notifications"
+    "vehicle-service/repository"
+    "vehicle-service/waitlist"
+)
+
+type WaitlistHandler struct {
+    WaitlistRepo    *repository.WaitlistRepository
+    ReservationRepo *repository.ReservationRepository
+    UserRepo        *repository.UserRepository
+    Mailer          notifications.Mailer
+    Waitlist        *waitlist.Service
+    Billing         *billing.Service
+}
+
+func NewWaitlistHandler(wRepo *repository.WaitlistRepository, rRepo *repository.ReservationRepository, uRepo *repository.UserRepository, mailer notifications.Mailer, service *waitlist.Service, billingService *billing.Service) *WaitlistHandler {
+    return &WaitlistHandler{
+        WaitlistRepo:    wRepo,
+        ReservationRepo: rRepo,
+        UserRepo:        uRepo,
+        Mailer:          mailer,
+        Waitlist:        service,
+        Billing:         billingService,
+    }
+}
+
+func (h *WaitlistHandler) JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+    var req models.WaitlistRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if req.StartTime.IsZero() || req.EndTime.IsZero() {
+        http.Error(w, "Start time and end time are required", http.StatusBadRequest)
+        return
+    }
+
+    if !req.EndTime.After(req.StartTime) {
+        http.Error(w, "End time must be after start time", http.StatusBadRequest)
+        return
+    }
+
+    if !req.StartTime.After(time.Now()) {
+        http.Error(w, "Start time must be in the future", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    if !requireLicense(w, h.UserRepo, userID, req.EndTime) {
+        return
+    }
+
+    entry := &models.WaitlistEntry{
+        UserID:      userID,
+        StartTime:   req.StartTime,
+        EndTime:     req.EndTime,
+        VehicleType: req.VehicleType,
+        Zone:        req.Zone,
+    }
+
+    if err := h.WaitlistRepo.JoinWaitlist(entry); err != nil {
+        http.Error(w, "Failed to join waitlist: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(entry)
+}
+
+func (h *WaitlistHandler) GetUserWaitlist(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    entries, err := h.WaitlistRepo.GetUserEntries(userID)
+    if err != nil {
+        http.Error(w, "Failed to get waitlist", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(entries)
+}
+
+func (h *WaitlistHandler) LeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    entryID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid waitlist entry ID", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    entry, err := h.WaitlistRepo.CancelEntry(entryID, userID)
+    if err == repository.ErrWaitlistEntryNotFound {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, "Failed to leave waitlist: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    // A declined offer goes to the next user in line
+    if entry.Status == models.WaitlistOffered && entry.OfferedVehicleID != nil {
+        h.Waitlist.VehicleFreed(*entry.OfferedVehicleID, entry.StartTime, entry.EndTime)
+    }
+
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Removed from waitlist",
+    })
+}
+
+func (h *WaitlistHandler) AcceptOffer(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    entryID, err := strconv.Atoi(vars["id"])
+    if err != nil {
+        http.Error(w, "Invalid waitlist entry ID", http.StatusBadRequest)
+        return
+    }
+
+    // The body is optional
+    var req models.AcceptOfferRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    reservation, err := h.WaitlistRepo.AcceptOffer(entryID, userID)
+    var conflictErr *repository.ConflictError
+    switch {
+    case err == nil:
+    case err == repository.ErrWaitlistEntryNotFound:
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    case err == repository.ErrNoActiveOffer, errors.As(err, &conflictErr):
+        http.Error(w, err.Error(), http.StatusConflict)
+        return
+    default:
+        http.Error(w, "Failed to accept offer: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    // The license was checked when joining the waitlist but may have lapsed
+    // or been revoked while waiting
+    if !requireLicense(w, h.UserRepo, userID, reservation.EndTime) {
+        if err := h.ReservationRepo.CancelReservation(reservation.ID, userID); err != nil {
+            log.Printf("Waitlist: failed to cancel unlicensed reservation %d: %v", reservation.ID, err)
+            return
+        }
+        // The offer goes to the next user in line
+        h.Waitlist.VehicleFreed(reservation.VehicleID, reservation.StartTime, reservation.EndTime)
+        return
+    }
+
+    if _, err := h.Billing.ReservationBooked(reservation.ID, userID, req.PromoCode); err != nil {
+        writeBookingError(w, "Failed to accept offer: ", err)
+        return
+    }
+    sendReservationEmail(h.ReservationRepo, h.UserRepo, h.Mailer, reservation.ID, userID, calendar.MethodRequest)
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(reservation)
+}
@@ -0,0 +1,128 @@
+// Path: services/vehicle-service/handlers/wallet_handler.go
+package handlers
+
+import (
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+
+    "vehicle-service/models"
+    "vehicle-service/payments"
+    "vehicle-service/repository"
+)
+
+// Wallet limits, amounts in cents
+const (
+    minTopUpCents     = 500
+    maxTopUpCents     = 50000
+    maxCreditCents    = 20000
+    walletHistorySize = 50
+)
+
+type WalletHandler struct {
+    WalletRepo *repository.WalletRepository
+    UserRepo   *repository.UserRepository
+    Payments   payments.PaymentProvider
+}
+
+func NewWalletHandler(wRepo *repository.WalletRepository, uRepo *repository.UserRepository, provider payments.PaymentProvider) *WalletHandler {
+    return &WalletHandler{WalletRepo: wRepo, UserRepo: uRepo, Payments: provider}
+}
+
+func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
+    userID := r.Context().Value("user_id").(int)
+
+    wallet, err := h.WalletRepo.GetWallet(userID, walletHistorySize)
+    if err != nil {
+        http.Error(w, "Failed to get wallet", http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(wallet)
+}
+
+// TopUp charges the user's payment method straight away and credits the
+// wallet with the same amount.
+func (h *WalletHandler) TopUp(w http.ResponseWriter, r *http.Request) {
+    var req models.TopUpRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    if req.AmountCents < minTopUpCents || req.AmountCents > maxTopUpCents {
+        http.Error(w, fmt.Sprintf("Top-up must be between %d and %d cents", minTopUpCents, maxTopUpCents), http.StatusBadRequest)
+        return
+    }
+
+    userID := r.Context().Value("user_id").(int)
+
+    auth, err := h.Payments.Authorize(userID, req.AmountCents, models.DefaultCurrency, "Wallet top-up")
+    if err != nil {
+        http.Error(w, "Failed to top up wallet: "+err.Error(), http.StatusPaymentRequired)
+        return
+    }
+    if _, err := h.Payments.Capture(auth.Reference, req.AmountCents); err != nil {
+        if _, err := h.Payments.Void(auth.Reference); err != nil {
+            log.Printf("Wallet: failed to void top-up authorization %s: %v", auth.Reference, err)
+        }
+        http.Error(w, "Failed to top up wallet: "+err.Error(), http.StatusPaymentRequired)
+        return
+    }
+
+    if err := h.WalletRepo.TopUp(userID, req.AmountCents, auth.Reference); err != nil {
+        // The money was taken but never credited, so give it back
+        if _, refundErr := h.Payments.Refund(auth.Reference, req.AmountCents, "topup-"+auth.Reference); refundErr != nil {
+            log.Printf("Wallet: failed to refund top-up %s for user %d: %v", auth.Reference, userID, refundErr)
+        }
+        http.Error(w, "Failed to top up wallet: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    h.GetWallet(w, r)
+}
+
+// IssueCredit lets staff give a user goodwill credit, e.g. after their
+// vehicle had to be swapped.
+func (h *WalletHandler) IssueCredit(w http.ResponseWriter, r *http.Request) {
+    var req models.CreditRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+
+    req.Reason = strings.TrimSpace(req.Reason)
+    if req.UserID == 0 || req.Reason == "" {
+        http.Error(w, "user_id and reason are required", http.StatusBadRequest)
+        return
+    }
+    if req.AmountCents <= 0 || req.AmountCents > maxCreditCents {
+        http.Error(w, fmt.Sprintf("Credit must be between 1 and %d cents", maxCreditCents), http.StatusBadRequest)
+        return
+    }
+
+    if _, err := h.UserRepo.GetRole(req.UserID); err == sql.ErrNoRows {
+        http.Error(w, "User not found", http.StatusNotFound)
+        return
+    } else if err != nil {
+        http.Error(w, "Failed to issue credit: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    staffID := r.Context().Value("user_id").(int)
+
+    if err := h.WalletRepo.IssueCredit(req.UserID, req.AmountCents, req.Reason, staffID); err != nil {
+        http.Error(w, "Failed to issue credit: "+err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(map[string]string{
+        "message": "Credit issued successfully",
+    })
+}
@@ -0,0 +1,22 @@
+// Path: services/vehicle-service/jobs/jobs.go
+package jobs
+
+import (
+    "log"
+    "time"
+)
+
+// Every runs fn in the background on a fixed interval. Errors are logged and
+// the job keeps running.
+func Every(name string, interval time.Duration, fn func() error) {
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+
+        for range ticker.C {
+            if err := fn(); err != nil {
+                log.Printf("Job %s failed: %v", name, err)
+            }
+        }
+    }()
+}
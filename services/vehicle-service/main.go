@@ -1,148 +1,370 @@
-// Path: services/vehicle-service/main.go
-package main
-
-import (
-    "database/sql"
-    "fmt"
-    "log"
-    "net/http"
-    "os"
-    "time"
-    "path/filepath"
-
-    gorillaCORS "github.com/gorilla/handlers"
-    "github.com/gorilla/mux"
-    _ "github.com/lib/pq"
-
-    "vehicle-service/handlers"
-    "vehicle-service/repository"
-    "vehicle-service/middleware"
-)
-
-const (
-    defaultPort = ":8081"  // Different port from user-service
-    dbConnRetries = 5
-    dbConnRetryDelay = 5 * time.Second
-)
-
-func initDB(connStr string) (*sql.DB, error) {
-    var db *sql.DB
-    var err error
-
-    for i := 0; i < dbConnRetries; i++ {
-        db, err = sql.Open("postgres", connStr)
-        if err != nil {
-            log.Printf("Failed to open database connection (attempt %d/%d): %v", i+1, dbConnRetries, err)
-            time.Sleep(dbConnRetryDelay)
-            continue
-        }
-
-        err = db.Ping()
-        if err == nil {
-            log.Println("Successfully connected to the database")
-            break
-        }
-
-        log.Printf("Failed to ping database (attempt %d/%d): %v", i+1, dbConnRetries, err)
-        db.Close()
-        time.Sleep(dbConnRetryDelay)
-    }
-
-    if err != nil {
-        return nil, fmt.Errorf("failed to establish database connection after %d attempts: %v", dbConnRetries, err)
-    }
-
-    db.SetMaxOpenConns(25)
-    db.SetMaxIdleConns(25)
-    db.SetConnMaxLifetime(5 * time.Minute)
-
-    return db, nil
-}
-
-func setupRoutes(vehicleHandler *handlers.VehicleHandler) *mux.Router {
-    r := mux.NewRouter()
-
-    // API routes
-    api := r.PathPrefix("/api").Subrouter()
-    
-    // Vehicle routes
-    api.HandleFunc("/vehicles/available", middleware.AuthMiddleware(vehicleHandler.GetAvailableVehicles)).Methods("POST", "OPTIONS")
-    api.HandleFunc("/reservations", middleware.AuthMiddleware(vehicleHandler.CreateReservation)).Methods("POST", "OPTIONS")
-    api.HandleFunc("/reservations/user", middleware.AuthMiddleware(vehicleHandler.GetUserReservations)).Methods("GET", "OPTIONS")
-    api.HandleFunc("/reservations/{id}", middleware.AuthMiddleware(vehicleHandler.UpdateReservation)).Methods("PUT", "OPTIONS")
-    api.HandleFunc("/reservations/{id}", middleware.AuthMiddleware(vehicleHandler.CancelReservation)).Methods("DELETE", "OPTIONS")
-
-	api.HandleFunc("/verify-token", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})).Methods("GET", "OPTIONS")
-	
-    // Static file server for the frontend directory
-    fs := http.FileServer(http.Dir("frontend"))
-    
-    // Handle the root path
-    r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-        http.ServeFile(w, r, "frontend/index.html")
-    })
-    
-    // Serve other static files
-    r.PathPrefix("/").Handler(fs)
-
-    return r
-}
-
-func setupCORS(handler http.Handler) http.Handler {
-    return gorillaCORS.CORS(
-        gorillaCORS.AllowedOrigins([]string{"http://localhost:8080", "http://localhost:8081"}),
-        gorillaCORS.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-        gorillaCORS.AllowedHeaders([]string{"Content-Type", "Authorization"}),
-        gorillaCORS.AllowCredentials(),
-        gorillaCORS.MaxAge(86400),
-    )(handler)
-}
-
-func main() {
-    log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-    
-    // Database connection string (use same Supabase instance)
-    connStr := "postgres://postgres.wjdhhzmaclmsvaiszagk:22KC6282t04@@aws-0-ap-southeast-1.pooler.supabase.com:6543/postgres"
-    
-    db, err := initDB(connStr)
-    if err != nil {
-        log.Fatal("Failed to initialize database:", err)
-    }
-    defer db.Close()
-
-    // Initialize repositories and handlers
-    vehicleRepo := repository.NewVehicleRepository(db)
-    reservationRepo := repository.NewReservationRepository(db)
-    vehicleHandler := handlers.NewVehicleHandler(vehicleRepo, reservationRepo)
-
-    // Setup routes
-    router := setupRoutes(vehicleHandler)
-
-    // Setup CORS
-    corsHandler := setupCORS(router)
-	
-
-    // Determine port
-    port := os.Getenv("PORT")
-    if port == "" {
-        port = defaultPort
-    }
-
-    // Create server
-    server := &http.Server{
-        Addr:         port,
-        Handler:      corsHandler,
-        ReadTimeout:  15 * time.Second,
-        WriteTimeout: 15 * time.Second,
-        IdleTimeout:  60 * time.Second,
-    }
-
-    // Start server
-    log.Printf("Vehicle Service starting on port %s\n", port)
-    log.Printf("Serving frontend from: %s\n", filepath.Join(".", "frontend"))
-    if err := server.ListenAndServe(); err != nil {
-        log.Fatal("Server failed to start:", err)
-    }
-}
\ No newline at end of file
+// Path: services/vehicle-service/main.go
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "log"
+    "net/http"
+    "os"
+    "time"
+    "path/filepath"
+
+    gorillaCORS "github.com/gorilla/handlers"
+    "github.com/gorilla/mux"
+    _ "github.com/lib/pq"
+
+    "vehicle-service/billing"
+    "vehicle-service/events"
+    "vehicle-service/handlers"
+    "vehicle-service/jobs"
+    "vehicle-service/repository"
+    "vehicle-service/middleware"
+    "vehicle-service/models"
+    "vehicle-service/notifications"
+    "vehicle-service/payments"
+    "vehicle-service/refunds"
+    "vehicle-service/statements"
+    "vehicle-service/waitlist"
+)
+
+const (
+    defaultPort = ":8081"  // Different port from user-service
+    dbConnRetries = 5
+    dbConnRetryDelay = 5 * time.Second
+)
+
+func initDB(connStr string) (*sql.DB, error) {
+    var db *sql.DB
+    var err error
+
+    for i := 0; i < dbConnRetries; i++ {
+        db, err = sql.Open("postgres", connStr)
+        if err != nil {
+            log.Printf("Failed to open database connection (attempt %d/%d): %v", i+1, dbConnRetries, err)
+            time.Sleep(dbConnRetryDelay)
+            continue
+        }
+
+        err = db.Ping()
+        if err == nil {
+            log.Println("Successfully connected to the database")
+            break
+        }
+
+        log.Printf("Failed to ping database (attempt %d/%d): %v", i+1, dbConnRetries, err)
+        db.Close()
+        time.Sleep(dbConnRetryDelay)
+    }
+
+    if err != nil {
+        return nil, fmt.Errorf("failed to establish database connection after %d attempts: %v", dbConnRetries, err)
+    }
+
+    db.SetMaxOpenConns(25)
+    db.SetMaxIdleConns(25)
+    db.SetConnMaxLifetime(5 * time.Minute)
+
+    return db, nil
+}
+
+func setupRoutes(vehicleHandler *handlers.VehicleHandler, commandHandler *handlers.CommandHandler, streamHandler *handlers.StreamHandler, calendarHandler *handlers.CalendarHandler, waitlistHandler *handlers.WaitlistHandler, holdHandler *handlers.HoldHandler, invoiceHandler *handlers.InvoiceHandler, availabilityHandler *handlers.AvailabilityHandler, promotionHandler *handlers.PromotionHandler, walletHandler *handlers.WalletHandler, statementHandler *handlers.StatementHandler, rateCardHandler *handlers.RateCardHandler, taxRateHandler *handlers.TaxRateHandler, depositHandler *handlers.DepositHandler, estimateHandler *handlers.EstimateHandler, fineHandler *handlers.FineHandler, disputeHandler *handlers.DisputeHandler, organizationHandler *handlers.OrganizationHandler, userRepo *repository.UserRepository) *mux.Router {
+    r := mux.NewRouter()
+
+    // API routes
+    api := r.PathPrefix("/api").Subrouter()
+    
+    // Vehicle routes
+    api.HandleFunc("/vehicles/available", middleware.AuthMiddleware(vehicleHandler.GetAvailableVehicles)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/vehicles/{id}/calendar", middleware.AuthMiddleware(availabilityHandler.GetVehicleCalendar)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/vehicles/stream", middleware.StreamAuthMiddleware(streamHandler.StreamVehicles)).Methods("GET")
+    api.HandleFunc("/reservations", middleware.AuthMiddleware(vehicleHandler.CreateReservation)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/reservations/user", middleware.AuthMiddleware(vehicleHandler.GetUserReservations)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/reservations/series/{id}", middleware.AuthMiddleware(vehicleHandler.GetSeries)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/reservations/series/{id}", middleware.AuthMiddleware(vehicleHandler.CancelSeries)).Methods("DELETE", "OPTIONS")
+    api.HandleFunc("/reservations/{id}", middleware.AuthMiddleware(vehicleHandler.UpdateReservation)).Methods("PUT", "OPTIONS")
+    api.HandleFunc("/reservations/{id}", middleware.AuthMiddleware(vehicleHandler.CancelReservation)).Methods("DELETE", "OPTIONS")
+    api.HandleFunc("/reservations/{id}/extend", middleware.AuthMiddleware(vehicleHandler.ExtendReservation)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/reservations/{id}/complete", middleware.AuthMiddleware(vehicleHandler.CompleteReservation)).Methods("POST", "OPTIONS")
+
+    // Checkout hold routes
+    api.HandleFunc("/holds", middleware.AuthMiddleware(holdHandler.CreateHold)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/holds", middleware.AuthMiddleware(holdHandler.GetUserHolds)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/holds/{id}/confirm", middleware.AuthMiddleware(holdHandler.ConfirmHold)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/holds/{id}", middleware.AuthMiddleware(holdHandler.ReleaseHold)).Methods("DELETE", "OPTIONS")
+
+    // Waitlist routes
+    api.HandleFunc("/waitlist", middleware.AuthMiddleware(waitlistHandler.JoinWaitlist)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/waitlist", middleware.AuthMiddleware(waitlistHandler.GetUserWaitlist)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/waitlist/{id}", middleware.AuthMiddleware(waitlistHandler.LeaveWaitlist)).Methods("DELETE", "OPTIONS")
+    api.HandleFunc("/waitlist/{id}/accept", middleware.AuthMiddleware(waitlistHandler.AcceptOffer)).Methods("POST", "OPTIONS")
+
+    // Invoice routes
+    api.HandleFunc("/invoices", middleware.AuthMiddleware(invoiceHandler.GetUserInvoices)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/invoices/{id}", middleware.AuthMiddleware(invoiceHandler.GetInvoice)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/invoices/{id}/pdf", middleware.AuthMiddleware(invoiceHandler.GetInvoicePDF)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/fines", middleware.AuthMiddleware(fineHandler.GetUserFines)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/fines/{id}/dispute", middleware.AuthMiddleware(fineHandler.DisputeFine)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/invoices/{id}/disputes", middleware.AuthMiddleware(disputeHandler.OpenDispute)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/disputes", middleware.AuthMiddleware(disputeHandler.GetUserDisputes)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/disputes/{id}", middleware.AuthMiddleware(disputeHandler.GetDispute)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/statements", middleware.AuthMiddleware(statementHandler.GetStatements)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/statements/{month}", middleware.AuthMiddleware(statementHandler.GetStatement)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/pricing/estimate", middleware.AuthMiddleware(estimateHandler.EstimatePrice)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/rate-cards", middleware.AuthMiddleware(rateCardHandler.GetRateCards)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/rate-cards", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, rateCardHandler.CreateRateCard))).Methods("POST", "OPTIONS")
+    api.HandleFunc("/tax-rates", middleware.AuthMiddleware(taxRateHandler.GetTaxRates)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/tax-rates", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, taxRateHandler.CreateTaxRate))).Methods("POST", "OPTIONS")
+    api.HandleFunc("/deposit-rules", middleware.AuthMiddleware(depositHandler.GetDepositRules)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/deposit-rules", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, depositHandler.SetDepositRule))).Methods("PUT", "OPTIONS")
+
+    // Promotion routes
+    api.HandleFunc("/promotions/validate", middleware.AuthMiddleware(promotionHandler.ValidatePromotion)).Methods("POST", "OPTIONS")
+
+    // Wallet routes
+    api.HandleFunc("/wallet", middleware.AuthMiddleware(walletHandler.GetWallet)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/wallet/topup", middleware.AuthMiddleware(walletHandler.TopUp)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/wallet/credits", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, walletHandler.IssueCredit))).Methods("POST", "OPTIONS")
+
+    // Organization routes
+    api.HandleFunc("/organizations/reservations", middleware.AuthMiddleware(organizationHandler.GetMemberReservations)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/organizations/invoices", middleware.AuthMiddleware(organizationHandler.GetCompanyBill)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/organizations/cost-centers", middleware.AuthMiddleware(organizationHandler.GetCostCenters)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/organizations/cost-centers", middleware.AuthMiddleware(organizationHandler.CreateCostCenter)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/organizations/policy", middleware.AuthMiddleware(organizationHandler.GetPolicy)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/organizations/policy", middleware.AuthMiddleware(organizationHandler.SetPolicy)).Methods("PUT", "OPTIONS")
+
+    // Support dispute routes
+    api.HandleFunc("/support/disputes", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, disputeHandler.GetDisputeQueue))).Methods("GET", "OPTIONS")
+    api.HandleFunc("/support/disputes/{id}", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, disputeHandler.GetAnyDispute))).Methods("GET", "OPTIONS")
+    api.HandleFunc("/support/disputes/{id}/approve", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, disputeHandler.ApproveDispute))).Methods("POST", "OPTIONS")
+    api.HandleFunc("/support/disputes/{id}/reject", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, disputeHandler.RejectDispute))).Methods("POST", "OPTIONS")
+    api.HandleFunc("/support/reservations/{id}/damage", middleware.AuthMiddleware(middleware.StaffMiddleware(userRepo.GetRole, invoiceHandler.AssessDamage))).Methods("POST", "OPTIONS")
+
+    // Remote lock/unlock routes
+    api.HandleFunc("/reservations/{id}/unlock", middleware.AuthMiddleware(commandHandler.UnlockVehicle)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/reservations/{id}/lock", middleware.AuthMiddleware(commandHandler.LockVehicle)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/commands/{id}", middleware.AuthMiddleware(commandHandler.GetCommand)).Methods("GET", "OPTIONS")
+
+    // Calendar routes
+    api.HandleFunc("/calendar/feed", middleware.AuthMiddleware(calendarHandler.GetFeedURL)).Methods("GET", "OPTIONS")
+    api.HandleFunc("/calendar/feed/rotate", middleware.AuthMiddleware(calendarHandler.RotateFeedURL)).Methods("POST", "OPTIONS")
+    api.HandleFunc("/calendar/{token}.ics", calendarHandler.ServeFeed).Methods("GET")
+
+    // Vehicle agent routes
+    agent := api.PathPrefix("/agent").Subrouter()
+    agent.HandleFunc("/vehicles/{id}/commands/next", middleware.AgentMiddleware(commandHandler.NextCommand)).Methods("GET")
+    agent.HandleFunc("/vehicles/{id}/commands/{commandId}/ack", middleware.AgentMiddleware(commandHandler.AcknowledgeCommand)).Methods("POST")
+    agent.HandleFunc("/vehicles/{id}/status", middleware.AgentMiddleware(vehicleHandler.ReportVehicleStatus)).Methods("POST")
+
+	api.HandleFunc("/verify-token", middleware.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).Methods("GET", "OPTIONS")
+	
+    // Static file server for the frontend directory
+    fs := http.FileServer(http.Dir("frontend"))
+    
+    // Handle the root path
+    r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+        http.ServeFile(w, r, "frontend/index.html")
+    })
+    
+    // Serve other static files
+    r.PathPrefix("/").Handler(fs)
+
+    return r
+}
+
+func setupCORS(handler http.Handler) http.Handler {
+    return gorillaCORS.CORS(
+        gorillaCORS.AllowedOrigins([]string{"http://localhost:8080", "http://localhost:8081"}),
+        gorillaCORS.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+        gorillaCORS.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+        gorillaCORS.AllowCredentials(),
+        gorillaCORS.MaxAge(86400),
+    )(handler)
+}
+
+// publicURL is the externally reachable base URL, used in links we hand out
+func publicURL() string {
+    if url := os.Getenv("PUBLIC_URL"); url != "" {
+        return url
+    }
+    return "http://localhost" + defaultPort
+}
+
+// restoreSimulator rebuilds the payment simulator's state from the payments
+// and deposits billing stored, which would otherwise be lost on restart
+func restoreSimulator(sim *payments.Simulator, paymentRepo *repository.PaymentRepository) error {
+    var records []payments.Record
+
+    list, err := paymentRepo.GetProviderPayments(sim.Name())
+    if err != nil {
+        return err
+    }
+    for _, p := range list {
+        status := payments.StatusVoided
+        switch p.Status {
+        case models.PaymentAuthorized:
+            status = payments.StatusAuthorized
+        case models.PaymentCaptured:
+            status = payments.StatusCaptured
+        }
+        records = append(records, payments.Record{
+            Reference:       p.Reference,
+            UserID:          p.UserID,
+            AuthorizedCents: p.AuthorizedCents,
+            CapturedCents:   p.CapturedCents,
+            RefundedCents:   p.RefundedCents,
+            Status:          status,
+        })
+    }
+
+    deposits, err := paymentRepo.GetProviderDeposits(sim.Name())
+    if err != nil {
+        return err
+    }
+    for _, d := range deposits {
+        status := payments.StatusVoided
+        switch d.Status {
+        case models.DepositAuthorized:
+            status = payments.StatusAuthorized
+        case models.DepositCaptured:
+            status = payments.StatusCaptured
+        }
+        records = append(records, payments.Record{
+            Reference:       d.Reference,
+            UserID:          d.UserID,
+            AuthorizedCents: d.AuthorizedCents,
+            CapturedCents:   d.CapturedCents,
+            Status:          status,
+        })
+    }
+
+    sim.Restore(records)
+    return nil
+}
+
+func main() {
+    log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+    
+    // Database connection string (use same Supabase instance)
+    connStr := "postgres://postgres.wjdhhzmaclmsvaiszagk:22KC6282t04@@aws-0-ap-southeast-1.pooler.supabase.com:6543/postgres"
+    
+    db, err := initDB(connStr)
+    if err != nil {
+        log.Fatal("Failed to initialize database:", err)
+    }
+    defer db.Close()
+
+    // Live vehicle updates are published by the repositories after each write
+    broker := events.NewBroker()
+
+    // Initialize repositories and handlers
+    vehicleRepo := repository.NewVehicleRepository(db, broker)
+    reservationRepo := repository.NewReservationRepository(db, broker)
+    commandRepo := repository.NewCommandRepository(db)
+    userRepo := repository.NewUserRepository(db)
+    calendarRepo := repository.NewCalendarRepository(db)
+    waitlistRepo := repository.NewWaitlistRepository(db, broker)
+    seriesRepo := repository.NewSeriesRepository(db, broker)
+    holdRepo := repository.NewHoldRepository(db, broker)
+    billingRepo := repository.NewBillingRepository(db)
+    availabilityRepo := repository.NewAvailabilityRepository(db)
+    promotionRepo := repository.NewPromotionRepository(db)
+    paymentRepo := repository.NewPaymentRepository(db)
+    walletRepo := repository.NewWalletRepository(db)
+    statementRepo := repository.NewStatementRepository(db)
+    pricingRepo := repository.NewPricingRepository(db)
+    penaltyRepo := repository.NewPenaltyRepository(db)
+    disputeRepo := repository.NewDisputeRepository(db)
+    organizationRepo := repository.NewOrganizationRepository(db)
+    referralRepo := repository.NewReferralRepository(db)
+    mailer := notifications.NewMailerFromEnv()
+    paymentProvider := payments.NewProviderFromEnv()
+    if sim, ok := paymentProvider.(*payments.Simulator); ok {
+        if err := restoreSimulator(sim, paymentRepo); err != nil {
+            log.Fatal("Failed to restore payment simulator:", err)
+        }
+    }
+    waitlistService := waitlist.NewService(waitlistRepo, userRepo, mailer)
+    statementService := statements.NewService(statementRepo, userRepo)
+    billingService := billing.NewService(billingRepo, reservationRepo, userRepo, promotionRepo, paymentRepo, walletRepo, pricingRepo, penaltyRepo, organizationRepo, paymentProvider)
+    refundService := refunds.NewService(disputeRepo, billingRepo, paymentRepo, paymentProvider)
+    vehicleHandler := handlers.NewVehicleHandler(vehicleRepo, reservationRepo, userRepo, seriesRepo, organizationRepo, referralRepo, mailer, waitlistService, billingService)
+    commandHandler := handlers.NewCommandHandler(commandRepo)
+    streamHandler := handlers.NewStreamHandler(vehicleRepo, broker)
+    calendarHandler := handlers.NewCalendarHandler(calendarRepo, reservationRepo, publicURL())
+    waitlistHandler := handlers.NewWaitlistHandler(waitlistRepo, reservationRepo, userRepo, mailer, waitlistService, billingService)
+    holdHandler := handlers.NewHoldHandler(holdRepo, reservationRepo, userRepo, mailer, waitlistService, billingService)
+    invoiceHandler := handlers.NewInvoiceHandler(billingRepo, paymentRepo, billingService)
+    availabilityHandler := handlers.NewAvailabilityHandler(availabilityRepo)
+    promotionHandler := handlers.NewPromotionHandler(vehicleRepo, billingService)
+    walletHandler := handlers.NewWalletHandler(walletRepo, userRepo, paymentProvider)
+    statementHandler := handlers.NewStatementHandler(statementRepo, userRepo)
+    rateCardHandler := handlers.NewRateCardHandler(billingRepo)
+    taxRateHandler := handlers.NewTaxRateHandler(billingRepo)
+    depositHandler := handlers.NewDepositHandler(billingRepo)
+    estimateHandler := handlers.NewEstimateHandler(vehicleRepo, billingService)
+    fineHandler := handlers.NewFineHandler(penaltyRepo, disputeRepo)
+    disputeHandler := handlers.NewDisputeHandler(disputeRepo, refundService)
+    organizationHandler := handlers.NewOrganizationHandler(organizationRepo)
+
+    // Background jobs
+    jobs.Every("expire-vehicle-commands", 5*time.Second, func() error {
+        _, err := commandRepo.ExpireCommands()
+        return err
+    })
+    jobs.Every("expire-waitlist-offers", 30*time.Second, waitlistService.ExpireOffers)
+    jobs.Every("expire-booking-holds", 30*time.Second, func() error {
+        expired, err := holdRepo.ExpireHolds()
+        for _, hold := range expired {
+            waitlistService.VehicleFreed(hold.VehicleID, hold.StartTime, hold.EndTime)
+        }
+        return err
+    })
+    jobs.Every("cancel-no-shows", time.Minute, func() error {
+        cancelled, err := reservationRepo.CancelNoShows()
+        now := time.Now()
+        for i := range cancelled {
+            res := &cancelled[i]
+            if _, err := billingService.ReservationCancelled(res, now); err != nil {
+                log.Printf("Billing: failed to charge no-show fee for reservation %d: %v", res.ID, err)
+            }
+            waitlistService.VehicleFreed(res.VehicleID, now, res.EndTime)
+        }
+        return err
+    })
+    jobs.Every("build-monthly-statements", time.Hour, statementService.BuildPreviousMonth)
+    jobs.Every("retry-settlements", 10*time.Minute, billingService.RetrySettlements)
+    jobs.Every("retry-pending-refunds", 10*time.Minute, refundService.RetryPendingRefunds)
+
+    // Setup routes
+    router := setupRoutes(vehicleHandler, commandHandler, streamHandler, calendarHandler, waitlistHandler, holdHandler, invoiceHandler, availabilityHandler, promotionHandler, walletHandler, statementHandler, rateCardHandler, taxRateHandler, depositHandler, estimateHandler, fineHandler, disputeHandler, organizationHandler, userRepo)
+
+    // Setup CORS
+    corsHandler := setupCORS(router)
+	
+
+    // Determine port
+    port := os.Getenv("PORT")
+    if port == "" {
+        port = defaultPort
+    }
+
+    // Create server
+    server := &http.Server{
+        Addr:         port,
+        Handler:      corsHandler,
+        ReadTimeout:  15 * time.Second,
+        WriteTimeout: 15 * time.Second,
+        IdleTimeout:  60 * time.Second,
+    }
+
+    // Start server
+    log.Printf("Vehicle Service starting on port %s\n", port)
+    log.Printf("Serving frontend from: %s\n", filepath.Join(".", "frontend"))
+    if err := server.ListenAndServe(); err != nil {
+        log.Fatal("Server failed to start:", err)
+    }
+}
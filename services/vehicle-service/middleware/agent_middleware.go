@@ -0,0 +1,37 @@
+// Path: services/vehicle-service/middleware/agent_middleware.go
+package middleware
+
+import (
+    "crypto/subtle"
+    "net/http"
+    "os"
+)
+
+// Shared key the on-board vehicle agents use to talk to this service
+var agentKey = []byte(agentKeyFromEnv())
+
+func agentKeyFromEnv() string {
+    if key := os.Getenv("VEHICLE_AGENT_KEY"); key != "" {
+        return key
+    }
+    return "your-vehicle-agent-key"
+}
+
+// AgentMiddleware authenticates requests coming from vehicle agents rather
+// than users. Agents send their key in the X-Vehicle-Key header.
+func AgentMiddleware(next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        key := r.Header.Get("X-Vehicle-Key")
+        if key == "" {
+            http.Error(w, "Missing vehicle key", http.StatusUnauthorized)
+            return
+        }
+
+        if subtle.ConstantTimeCompare([]byte(key), agentKey) != 1 {
+            http.Error(w, "Invalid vehicle key", http.StatusUnauthorized)
+            return
+        }
+
+        next.ServeHTTP(w, r)
+    }
+}
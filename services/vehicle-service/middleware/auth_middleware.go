@@ -1,67 +1,104 @@
-// Path: services/vehicle-service/middleware/auth_middleware.go
-package middleware
-
-import (
-    "context"
-    "net/http"
-    "strings"
-    "fmt"
-    "github.com/golang-jwt/jwt"
-)
-
-var jwtKey = []byte("your-secret-key") // Use the same key as user-service
-
-type Claims struct {
-    UserID int    `json:"user_id"`
-    Email  string `json:"email"`
-    jwt.StandardClaims
-}
-
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-    return func(w http.ResponseWriter, r *http.Request) {
-        // Handle preflight requests
-        if r.Method == "OPTIONS" {
-            w.WriteHeader(http.StatusOK)
-            return
-        }
-
-        // Get the Authorization header
-        authHeader := r.Header.Get("Authorization")
-        if authHeader == "" {
-            http.Error(w, "Missing authorization token", http.StatusUnauthorized)
-            return
-        }
-
-        // Check if the header starts with "Bearer "
-        parts := strings.Split(authHeader, " ")
-        if len(parts) != 2 || parts[0] != "Bearer" {
-            http.Error(w, "Invalid token format", http.StatusUnauthorized)
-            return
-        }
-
-        tokenString := parts[1]
-
-        // Parse and validate the token
-        claims := &Claims{}
-        token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-            if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-                return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-            }
-            return jwtKey, nil
-        })
-
-        if err != nil {
-            http.Error(w, "Invalid token", http.StatusUnauthorized)
-            return
-        }
-
-        if !token.Valid {
-            http.Error(w, "Token is not valid", http.StatusUnauthorized)
-            return
-        }
-
-        // Add claims to request context
-        ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
-        next.ServeHTTP(w, r.WithContext(ctx))
-    }
-}
\ No newline at end of file
+// Path: services/vehicle-service/middleware/auth_middleware.go
+package middleware
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "strings"
+    "fmt"
+    "github.com/golang-jwt/jwt"
+)
+
+var jwtKey = []byte("your-secret-key") // Use the same key as user-service
+
+type Claims struct {
+    UserID int    `json:"user_id"`
+    Email  string `json:"email"`
+    jwt.StandardClaims
+}
+
+func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        // Handle preflight requests
+        if r.Method == "OPTIONS" {
+            w.WriteHeader(http.StatusOK)
+            return
+        }
+
+        // Get the Authorization header
+        authHeader := r.Header.Get("Authorization")
+        if authHeader == "" {
+            http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+            return
+        }
+
+        // Check if the header starts with "Bearer "
+        parts := strings.Split(authHeader, " ")
+        if len(parts) != 2 || parts[0] != "Bearer" {
+            http.Error(w, "Invalid token format", http.StatusUnauthorized)
+            return
+        }
+
+        tokenString := parts[1]
+
+        claims, err := parseToken(tokenString)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusUnauthorized)
+            return
+        }
+
+        // Add claims to request context
+        ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+        next.ServeHTTP(w, r.WithContext(ctx))
+    }
+}
+
+// StreamAuthMiddleware is AuthMiddleware for streaming endpoints. Browsers'
+// EventSource can't set headers, so the token may also come from the
+// access_token query parameter.
+func StreamAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        tokenString := r.URL.Query().Get("access_token")
+        if tokenString == "" {
+            parts := strings.Split(r.Header.Get("Authorization"), " ")
+            if len(parts) == 2 && parts[0] == "Bearer" {
+                tokenString = parts[1]
+            }
+        }
+        if tokenString == "" {
+            http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+            return
+        }
+
+        claims, err := parseToken(tokenString)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusUnauthorized)
+            return
+        }
+
+        ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
+        next.ServeHTTP(w, r.WithContext(ctx))
+    }
+}
+
+// parseToken validates a JWT issued by user-service and returns its claims
+func parseToken(tokenString string) (*Claims, error) {
+    claims := &Claims{}
+    token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+            return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+        }
+        return jwtKey, nil
+    })
+
+    if err != nil {
+        return nil, errors.New("Invalid token")
+    }
+
+    if !token.Valid {
+        return nil, errors.New("Token is not valid")
+    }
+
+    return claims, nil
+}
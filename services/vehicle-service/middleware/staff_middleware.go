@@ -0,0 +1,30 @@
+// Path: services/vehicle-service/middleware/staff_middleware.go
+package middleware
+
+import (
+    "net/http"
+)
+
+// RoleLookup returns the role stored for a user
+type RoleLookup func(userID int) (string, error)
+
+// StaffMiddleware only lets staff members through. The role is read from the
+// database on every request so revoking it takes effect immediately. It
+// must sit inside AuthMiddleware, which provides the user ID.
+func StaffMiddleware(lookup RoleLookup, next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        userID := r.Context().Value("user_id").(int)
+
+        role, err := lookup(userID)
+        if err != nil {
+            http.Error(w, "Failed to check permissions", http.StatusInternalServerError)
+            return
+        }
+        if role != "staff" {
+            http.Error(w, "Staff only", http.StatusForbidden)
+            return
+        }
+
+        next.ServeHTTP(w, r)
+    }
+}
@@ -0,0 +1,32 @@
+// Path: services/vehicle-service/models/availability.go
+package models
+
+import (
+    "time"
+)
+
+const (
+    SlotFree        = "free"
+    SlotReservation = "reservation"
+    SlotHold        = "hold"
+    SlotMaintenance = "maintenance"
+    SlotBuffer      = "buffer"
+)
+
+// AvailabilityInterval is one stretch of a vehicle's timeline
+type AvailabilityInterval struct {
+    Kind      string    `json:"kind"` // free, reservation, hold, maintenance, buffer
+    StartTime time.Time `json:"start_time"`
+    EndTime   time.Time `json:"end_time"`
+    Own       bool      `json:"own,omitempty"` // the requesting user's own reservation
+}
+
+// VehicleCalendar lays out when a vehicle is busy and free over a range
+type VehicleCalendar struct {
+    VehicleID     int                    `json:"vehicle_id"`
+    From          time.Time              `json:"from"`
+    To            time.Time              `json:"to"`
+    BufferMinutes int                    `json:"buffer_minutes"`
+    Intervals     []AvailabilityInterval `json:"intervals"`
+    NextOpenSlot  *AvailabilityInterval  `json:"next_open_slot,omitempty"`
+}
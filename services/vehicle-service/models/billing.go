@@ -0,0 +1,169 @@
+// Path: services/vehicle-service/models/billing.go
+package models
+
+import (
+    "time"
+)
+
+const (
+    InvoiceOpen   = "Open"
+    InvoiceIssued = "Issued"
+)
+
+// DefaultCurrency is what wallets hold and what regions without a tax rate
+// are billed in
+const DefaultCurrency = "SGD"
+
+// Invoice line kinds
+const (
+    LineRental             = "rental"
+    LineTierDiscount       = "tier_discount"
+    LineRentalAdjustment   = "rental_adjustment"
+    LineCancellationCredit = "cancellation_credit"
+    LineCancellationFee    = "cancellation_fee"
+    LinePromotion          = "promotion"
+    LineDistance           = "distance"
+    LineEnergy             = "energy"
+    LinePeakPricing        = "peak_pricing"
+    LineDemandPricing      = "demand_pricing"
+    LinePenalty            = "penalty"
+    LineDamage             = "damage"
+    LineRefund             = "refund"
+)
+
+// Invoice collects everything billed for one reservation. It stays Open while
+// the booking can still change and is Issued once the trip is over or
+// cancelled. Amounts are in the minor units of Currency; lines are before
+// tax, which is worked out on the subtotal at the rate the invoice was
+// opened with.
+type Invoice struct {
+    ID              int           `json:"id"`
+    UserID          int           `json:"user_id"`
+    ReservationID   int           `json:"reservation_id"`
+    RateCardID      *int          `json:"rate_card_id,omitempty"`
+    OrganizationID  *int          `json:"organization_id,omitempty"` // billed to the company, not the user
+    CostCenterID    *int          `json:"cost_center_id,omitempty"`
+    Status          string        `json:"status"` // Open, Issued
+    Currency        string        `json:"currency"`
+    SubtotalCents   int64         `json:"subtotal_cents"`
+    TaxName         string        `json:"tax_name,omitempty"`
+    TaxRateBps      int           `json:"tax_rate_bps"`
+    TaxCents        int64         `json:"tax_cents"`
+    TotalCents      int64         `json:"total_cents"`       // subtotal plus tax
+    BalanceDueCents int64         `json:"balance_due_cents"` // left unpaid after the trip was settled
+    CreatedAt       time.Time     `json:"created_at"`
+    UpdatedAt       time.Time     `json:"updated_at"`
+    IssuedAt        *time.Time    `json:"issued_at,omitempty"`
+    Lines           []InvoiceLine `json:"lines"`
+    Payment         *Payment      `json:"payment,omitempty"`
+    Deposit         *Deposit      `json:"deposit,omitempty"`
+}
+
+type InvoiceLine struct {
+    ID          int       `json:"id"`
+    InvoiceID   int       `json:"invoice_id"`
+    Kind        string    `json:"kind"`
+    Description string    `json:"description"`
+    AmountCents int64     `json:"amount_cents"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+// TaxRate is a region's sales tax and the currency the region is billed in
+type TaxRate struct {
+    ID            int       `json:"id"` // 0 when the region has no rate
+    Region        string    `json:"region"`
+    Currency      string    `json:"currency"`
+    Name          string    `json:"name"`
+    RateBps       int       `json:"rate_bps"` // basis points, 900 = 9%
+    EffectiveFrom time.Time `json:"effective_from"`
+    CreatedAt     time.Time `json:"created_at"`
+}
+
+type TaxRateRequest struct {
+    Region        string     `json:"region"`
+    Currency      string     `json:"currency"`
+    Name          string     `json:"name"`
+    RateBps       int        `json:"rate_bps"`
+    EffectiveFrom *time.Time `json:"effective_from,omitempty"` // defaults to now
+}
+
+type CancellationPolicy struct {
+    Tier                 string `json:"tier"`
+    FreeUntilHours       int    `json:"free_until_hours"`
+    LateCancelFeePercent int    `json:"late_cancel_fee_percent"`
+    NoShowFeePercent     int    `json:"no_show_fee_percent"`
+}
+
+// CancellationOutcome is what cancelling cost the user
+type CancellationOutcome struct {
+    Policy      CancellationPolicy `json:"policy"`
+    Reason      string             `json:"reason"` // free, late_cancellation, no_show
+    Currency    string             `json:"currency"`
+    FeeCents    int64              `json:"fee_cents"` // including tax
+    RefundCents int64              `json:"refund_cents"`
+}
+
+const (
+    PaymentAuthorized = "Authorized"
+    PaymentCaptured   = "Captured"
+    PaymentVoided     = "Voided"
+    PaymentFailed     = "Failed"
+)
+
+// Payment tracks the money side of a reservation's invoice
+type Payment struct {
+    ID              int       `json:"id"`
+    ReservationID   int       `json:"reservation_id"`
+    UserID          int       `json:"user_id"`
+    Provider        string    `json:"provider"`
+    Reference       string    `json:"reference"`
+    Status          string    `json:"status"` // Authorized, Captured, Voided, Failed
+    Currency        string    `json:"currency"`
+    AuthorizedCents int64     `json:"authorized_cents"`
+    CapturedCents   int64     `json:"captured_cents"`
+    RefundedCents   int64     `json:"refunded_cents"`
+    WalletCents     int64     `json:"wallet_cents"` // paid from the wallet instead of the card
+    ErrorMessage    string    `json:"error_message,omitempty"`
+    CreatedAt       time.Time `json:"created_at"`
+    UpdatedAt       time.Time `json:"updated_at"`
+}
+
+const (
+    DepositAuthorized = "Authorized"
+    DepositReleased   = "Released"
+    DepositCaptured   = "Captured"
+    DepositFailed     = "Failed"
+)
+
+// DepositRule is the security deposit held for a vehicle type and tier in
+// one currency
+type DepositRule struct {
+    VehicleType string `json:"vehicle_type"`
+    Tier        string `json:"tier"`
+    Currency    string `json:"currency"`
+    AmountCents int64  `json:"amount_cents"` // 0 takes no deposit
+}
+
+// Deposit is held on the user's card next to the reservation's payment.
+// Fines, damage and whatever the payment couldn't cover at the end of the
+// trip are captured from it and the rest released.
+type Deposit struct {
+    ID              int       `json:"id"`
+    ReservationID   int       `json:"reservation_id"`
+    UserID          int       `json:"user_id"`
+    Provider        string    `json:"provider"`
+    Reference       string    `json:"reference"`
+    Status          string    `json:"status"` // Authorized, Released, Captured, Failed
+    Currency        string    `json:"currency"`
+    AuthorizedCents int64     `json:"authorized_cents"`
+    CapturedCents   int64     `json:"captured_cents"`
+    ErrorMessage    string    `json:"error_message,omitempty"`
+    CreatedAt       time.Time `json:"created_at"`
+    UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// DamageAssessment is what staff found wrong with a returned vehicle
+type DamageAssessment struct {
+    AmountCents int64  `json:"amount_cents"`
+    Description string `json:"description"`
+}
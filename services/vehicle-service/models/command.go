@@ -0,0 +1,38 @@
+// Path: services/vehicle-service/models/command.go
+package models
+
+import (
+    "time"
+)
+
+const (
+    CommandUnlock = "Unlock"
+    CommandLock   = "Lock"
+)
+
+const (
+    CommandPending      = "Pending"
+    CommandSent         = "Sent"
+    CommandAcknowledged = "Acknowledged"
+    CommandFailed       = "Failed"
+    CommandTimedOut     = "TimedOut"
+)
+
+type VehicleCommand struct {
+    ID             int        `json:"id"`
+    VehicleID      int        `json:"vehicle_id"`
+    ReservationID  int        `json:"reservation_id"`
+    UserID         int        `json:"user_id"`
+    Command        string     `json:"command"` // Unlock, Lock
+    Status         string     `json:"status"`  // Pending, Sent, Acknowledged, Failed, TimedOut
+    ErrorMessage   string     `json:"error_message,omitempty"`
+    CreatedAt      time.Time  `json:"created_at"`
+    SentAt         *time.Time `json:"sent_at,omitempty"`
+    AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+    ExpiresAt      time.Time  `json:"expires_at"`
+}
+
+type CommandAck struct {
+    Success bool   `json:"success"`
+    Error   string `json:"error,omitempty"`
+}
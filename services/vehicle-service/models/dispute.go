@@ -0,0 +1,89 @@
+// Path: services/vehicle-service/models/dispute.go
+package models
+
+import (
+    "time"
+)
+
+const (
+    DisputeOpen      = "Open"
+    DisputeRefunding = "Refunding" // claimed by an agent while the refund is paid out
+    DisputeApproved  = "Approved"
+    DisputeRejected  = "Rejected"
+)
+
+const (
+    RefundPending   = "Pending"
+    RefundCompleted = "Completed"
+    RefundFailed    = "Failed"
+)
+
+// Refund methods
+const (
+    RefundToCard   = "card"
+    RefundToWallet = "wallet"
+)
+
+// Dispute audit actions
+const (
+    DisputeActionOpened       = "opened"
+    DisputeActionApproved     = "approved"
+    DisputeActionRejected     = "rejected"
+    DisputeActionRefunded     = "refunded"
+    DisputeActionRefundFailed = "refund_failed"
+)
+
+// Dispute is a user's objection to one line on an invoice
+type Dispute struct {
+    ID             int            `json:"id"`
+    InvoiceID      int            `json:"invoice_id"`
+    InvoiceLineID  int            `json:"invoice_line_id"`
+    UserID         int            `json:"user_id"`
+    Reason         string         `json:"reason"`
+    Status         string         `json:"status"` // Open, Refunding, Approved, Rejected
+    RefundCents    int64          `json:"refund_cents"`
+    ResolvedBy     *int           `json:"resolved_by,omitempty"`
+    ResolutionNote string         `json:"resolution_note,omitempty"`
+    CreatedAt      time.Time      `json:"created_at"`
+    ResolvedAt     *time.Time     `json:"resolved_at,omitempty"`
+    Line           *InvoiceLine   `json:"line,omitempty"`
+    Events         []DisputeEvent `json:"events,omitempty"`
+}
+
+// DisputeEvent is one entry in a dispute's audit trail
+type DisputeEvent struct {
+    ID          int       `json:"id"`
+    DisputeID   int       `json:"dispute_id"`
+    ActorID     int       `json:"actor_id"`
+    Action      string    `json:"action"`
+    AmountCents int64     `json:"amount_cents,omitempty"`
+    Note        string    `json:"note,omitempty"`
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+type Refund struct {
+    ID                int        `json:"id"`
+    DisputeID         int        `json:"dispute_id"`
+    InvoiceID         int        `json:"invoice_id"`
+    UserID            int        `json:"user_id"`
+    AmountCents       int64      `json:"amount_cents"`
+    Method            string     `json:"method"` // card, wallet
+    Status            string     `json:"status"` // Pending, Completed, Failed
+    ProviderReference string     `json:"provider_reference,omitempty"`
+    CreatedBy         int        `json:"created_by"`
+    CreatedAt         time.Time  `json:"created_at"`
+    CompletedAt       *time.Time `json:"completed_at,omitempty"`
+}
+
+type OpenDisputeRequest struct {
+    LineID int    `json:"line_id"`
+    Reason string `json:"reason"`
+}
+
+// ResolveDisputeRequest approves with a refund of AmountCents (0 for none)
+// or rejects the dispute
+type ResolveDisputeRequest struct {
+    AmountCents int64  `json:"amount_cents"`
+    Method      string `json:"method"` // card or wallet, for refunds
+    Note        string `json:"note"`
+}
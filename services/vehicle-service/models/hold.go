@@ -0,0 +1,40 @@
+// Path: services/vehicle-service/models/hold.go
+package models
+
+import (
+    "time"
+)
+
+const (
+    HoldHeld      = "Held"
+    HoldConfirmed = "Confirmed"
+    HoldReleased  = "Released"
+    HoldExpired   = "Expired"
+)
+
+// BookingHold keeps a vehicle aside for one user for a few minutes, e.g.
+// during checkout, so nobody else can book it in the meantime.
+type BookingHold struct {
+    ID            int       `json:"id"`
+    UserID        int       `json:"user_id"`
+    VehicleID     int       `json:"vehicle_id"`
+    StartTime     time.Time `json:"start_time"`
+    EndTime       time.Time `json:"end_time"`
+    Status        string    `json:"status"` // Held, Confirmed, Released, Expired
+    ExpiresAt     time.Time `json:"expires_at"`
+    ReservationID *int      `json:"reservation_id,omitempty"`
+    CreatedAt     time.Time `json:"created_at"`
+    UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type HoldRequest struct {
+    VehicleID int       `json:"vehicle_id"`
+    StartTime time.Time `json:"start_time"`
+    EndTime   time.Time `json:"end_time"`
+}
+
+// ConfirmHoldRequest is the optional body when a hold is turned into a
+// reservation
+type ConfirmHoldRequest struct {
+    PromoCode string `json:"promo_code,omitempty"`
+}
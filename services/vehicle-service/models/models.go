@@ -1,53 +1,100 @@
-// Path: services/vehicle-service/models/models.go
-package models
-
-import (
-    "time"
-)
-
-type Vehicle struct {
-    ID          int       `json:"id"`
-    Model       string    `json:"model"`
-    Type        string    `json:"type"`
-    Status      string    `json:"status"` // Available, In-Use, Maintenance
-    Location    string    `json:"location"`
-    ChargeLevel int       `json:"charge_level,omitempty"`
-    Cleanliness string    `json:"cleanliness,omitempty"` // Clean, Needs-Cleaning
-    CreatedAt   time.Time `json:"created_at"`
-    UpdatedAt   time.Time `json:"updated_at"`
-}
-
-type Reservation struct {
-    ID         int       `json:"id"`
-    UserID     int       `json:"user_id"`
-    VehicleID  int       `json:"vehicle_id"`
-    StartTime  time.Time `json:"start_time"`
-    EndTime    time.Time `json:"end_time"`
-    Status     string    `json:"status"` // Active, Completed, Cancelled
-    CreatedAt  time.Time `json:"created_at"`
-    UpdatedAt  time.Time `json:"updated_at"`
-    Vehicle    *Vehicle  `json:"vehicle,omitempty"`
-}
-
-type AvailabilityRequest struct {
-    StartTime time.Time `json:"start_time"`
-    EndTime   time.Time `json:"end_time"`
-}
-
-type ReservationRequest struct {
-    VehicleID int       `json:"vehicle_id"`
-    StartTime time.Time `json:"start_time"`
-    EndTime   time.Time `json:"end_time"`
-}
-
-type UpdateReservationRequest struct {
-    StartTime *time.Time `json:"start_time,omitempty"`
-    EndTime   *time.Time `json:"end_time,omitempty"`
-}
-
-type VehicleStatusUpdate struct {
-    Status      *string `json:"status,omitempty"`
-    ChargeLevel *int    `json:"charge_level,omitempty"`
-    Cleanliness *string `json:"cleanliness,omitempty"`
-    Location    *string `json:"location,omitempty"`
-}
\ No newline at end of file
+// Path: services/vehicle-service/models/models.go
+package models
+
+import (
+    "time"
+)
+
+type Vehicle struct {
+    ID          int       `json:"id"`
+    Model       string    `json:"model"`
+    Type        string    `json:"type"`
+    Status      string    `json:"status"` // Available, In-Use, Maintenance
+    Location    string    `json:"location"`
+    ChargeLevel int       `json:"charge_level,omitempty"`
+    Cleanliness string    `json:"cleanliness,omitempty"` // Clean, Needs-Cleaning
+    CreatedAt   time.Time `json:"created_at"`
+    UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Cleanliness as reported by the vehicle or staff
+const (
+    CleanlinessClean         = "Clean"
+    CleanlinessNeedsCleaning = "Needs-Cleaning"
+)
+
+type Reservation struct {
+    ID             int        `json:"id"`
+    UserID         int        `json:"user_id"`
+    VehicleID      int        `json:"vehicle_id"`
+    StartTime      time.Time  `json:"start_time"`
+    EndTime        time.Time  `json:"end_time"`
+    Status         string     `json:"status"` // Active, Completed, Cancelled
+    Sequence       int        `json:"-"`      // iCalendar SEQUENCE, bumped on every change
+    ReturnedAt     *time.Time `json:"returned_at,omitempty"`
+    SeriesID       *int       `json:"series_id,omitempty"`
+    OrganizationID *int       `json:"organization_id,omitempty"` // set on business bookings
+    CostCenterID   *int       `json:"cost_center_id,omitempty"`
+    CreatedAt      time.Time  `json:"created_at"`
+    UpdatedAt      time.Time  `json:"updated_at"`
+    Vehicle        *Vehicle   `json:"vehicle,omitempty"`
+}
+
+type AvailabilityRequest struct {
+    StartTime time.Time `json:"start_time"`
+    EndTime   time.Time `json:"end_time"`
+}
+
+type ReservationRequest struct {
+    VehicleID  int       `json:"vehicle_id"`
+    StartTime  time.Time `json:"start_time"`
+    EndTime    time.Time `json:"end_time"`
+    Recurrence string    `json:"recurrence,omitempty"` // RRULE subset, e.g. FREQ=WEEKLY;BYDAY=MO,FR;COUNT=10
+    PromoCode  string    `json:"promo_code,omitempty"`
+    Business   bool      `json:"business,omitempty"` // bill the user's organization instead of the user
+    CostCenter int       `json:"cost_center_id,omitempty"`
+}
+
+type UpdateReservationRequest struct {
+    StartTime *time.Time `json:"start_time,omitempty"`
+    EndTime   *time.Time `json:"end_time,omitempty"`
+}
+
+// ExtendReservationRequest asks for a new end time, either directly or as
+// a number of extra minutes.
+type ExtendReservationRequest struct {
+    EndTime *time.Time `json:"end_time,omitempty"`
+    Minutes int        `json:"minutes,omitempty"`
+}
+
+type ExtensionResult struct {
+    ReservationID     int        `json:"reservation_id"`
+    Extended          bool       `json:"extended"`
+    CurrentEndTime    time.Time  `json:"current_end_time"`
+    RequestedEndTime  time.Time  `json:"requested_end_time"`
+    AddedCostCents    int64      `json:"added_cost_cents"`
+    Reason            string     `json:"reason,omitempty"`
+    LatestPossibleEnd *time.Time `json:"latest_possible_end,omitempty"`
+}
+
+type VehicleStatusUpdate struct {
+    Status      *string `json:"status,omitempty"`
+    ChargeLevel *int    `json:"charge_level,omitempty"`
+    OdometerKm  *int    `json:"odometer_km,omitempty"`
+    Cleanliness *string `json:"cleanliness,omitempty"`
+    Location    *string `json:"location,omitempty"`
+}
+
+// VehicleEvent is pushed to live status streams whenever a vehicle changes
+type VehicleEvent struct {
+    VehicleID   int       `json:"vehicle_id"`
+    Model       string    `json:"model"`
+    Type        string    `json:"type"`
+    Status      string    `json:"status"`
+    Available   bool      `json:"available"`
+    Location    string    `json:"location"`
+    ChargeLevel int       `json:"charge_level"`
+    Cleanliness string    `json:"cleanliness,omitempty"`
+    Reason      string    `json:"reason"` // what triggered the change, e.g. reservation_created
+    At          time.Time `json:"at"`
+}
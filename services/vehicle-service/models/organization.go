@@ -0,0 +1,90 @@
+// Path: services/vehicle-service/models/organization.go
+package models
+
+import (
+    "time"
+)
+
+// Organization roles, managed by the user service
+const (
+    OrgRoleAdmin  = "Admin"
+    OrgRoleMember = "Member"
+)
+
+// Membership is the organization a user books for on business
+type Membership struct {
+    OrganizationID int    `json:"organization_id"`
+    Name           string `json:"name"`
+    Role           string `json:"role"`
+}
+
+// CostCenter is a company budget business bookings can be charged to
+type CostCenter struct {
+    ID             int       `json:"id"`
+    OrganizationID int       `json:"organization_id"`
+    Code           string    `json:"code"`
+    Name           string    `json:"name"`
+    Active         bool      `json:"active"`
+    CreatedAt      time.Time `json:"created_at"`
+}
+
+type CostCenterRequest struct {
+    Code string `json:"code"`
+    Name string `json:"name"`
+}
+
+// OrganizationPolicy limits what members may book on the company's account.
+// Zero values mean no limit.
+type OrganizationPolicy struct {
+    OrganizationID      int       `json:"organization_id"`
+    MaxRentalHours      *int      `json:"max_rental_hours,omitempty"`
+    AllowedVehicleTypes []string  `json:"allowed_vehicle_types"`
+    WeekdaysOnly        bool      `json:"weekdays_only"`
+    RequireCostCenter   bool      `json:"require_cost_center"`
+    UpdatedBy           *int      `json:"updated_by,omitempty"`
+    UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// MemberReservation is a business booking as seen by the organization's
+// admins
+type MemberReservation struct {
+    Reservation
+    UserEmail      string `json:"user_email"`
+    CostCenterCode string `json:"cost_center_code,omitempty"`
+}
+
+// CompanyBill lists the invoices billed to an organization in one calendar
+// month, totalled per cost center
+type CompanyBill struct {
+    OrganizationID int               `json:"organization_id"`
+    Month          time.Time         `json:"month"` // first day of the month
+    Invoices       []CompanyInvoice  `json:"invoices"`
+    CostCenters    []CostCenterTotal `json:"cost_centers"`
+}
+
+type CompanyInvoice struct {
+    InvoiceID      int        `json:"invoice_id"`
+    ReservationID  int        `json:"reservation_id"`
+    UserID         int        `json:"user_id"`
+    UserEmail      string     `json:"user_email"`
+    CostCenterCode string     `json:"cost_center_code,omitempty"`
+    Status         string     `json:"status"`
+    Currency       string     `json:"currency"`
+    SubtotalCents  int64      `json:"subtotal_cents"`
+    TaxCents       int64      `json:"tax_cents"`
+    TotalCents     int64      `json:"total_cents"`
+    CreatedAt      time.Time  `json:"created_at"`
+    IssuedAt       *time.Time `json:"issued_at,omitempty"`
+}
+
+// CostCenterTotal sums a cost center's invoices in one currency. Bookings
+// made without a cost center are totalled under an empty code.
+type CostCenterTotal struct {
+    CostCenterID  *int   `json:"cost_center_id,omitempty"`
+    Code          string `json:"code"`
+    Currency      string `json:"currency"`
+    InvoiceCount  int    `json:"invoice_count"`
+    SubtotalCents int64  `json:"subtotal_cents"`
+    TaxCents      int64  `json:"tax_cents"`
+    TotalCents    int64  `json:"total_cents"`
+}
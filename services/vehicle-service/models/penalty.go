@@ -0,0 +1,64 @@
+// Path: services/vehicle-service/models/penalty.go
+package models
+
+import (
+    "time"
+)
+
+// Penalty kinds
+const (
+    PenaltyLateReturn = "late_return"
+    PenaltyLowCharge  = "low_charge"
+    PenaltyDirty      = "dirty"
+    PenaltyOutOfZone  = "out_of_zone"
+)
+
+const (
+    FineCharged  = "Charged"
+    FineDisputed = "Disputed"
+)
+
+// PenaltyRule prices one kind of fine in one currency. Threshold is the
+// grace period in minutes for late returns and the minimum charge level for
+// low charge.
+type PenaltyRule struct {
+    Kind         string `json:"kind"`
+    Currency     string `json:"currency"`
+    Threshold    int    `json:"threshold"`
+    AmountCents  int64  `json:"amount_cents"`
+    PerUnitCents int64  `json:"per_unit_cents"`
+    MaxCents     *int64 `json:"max_cents,omitempty"`
+}
+
+// ReturnReport is the state a vehicle came back in, as far as the fines
+// care
+type ReturnReport struct {
+    Currency          string // of the trip's invoice
+    BookedEnd         time.Time
+    ReturnedAt        time.Time
+    ChargeLevel       *int
+    PickupCleanliness string
+    ReturnCleanliness string
+    PickupLocation    string
+    ReturnLocation    string
+}
+
+// Fine is a penalty charged on a trip's invoice
+type Fine struct {
+    ID            int        `json:"id"`
+    ReservationID int        `json:"reservation_id"`
+    InvoiceID     int        `json:"invoice_id"`
+    InvoiceLineID *int       `json:"invoice_line_id,omitempty"`
+    UserID        int        `json:"user_id"`
+    Kind          string     `json:"kind"`
+    Description   string     `json:"description"`
+    AmountCents   int64      `json:"amount_cents"`
+    Status        string     `json:"status"` // Charged, Disputed
+    DisputeReason string     `json:"dispute_reason,omitempty"`
+    DisputedAt    *time.Time `json:"disputed_at,omitempty"`
+    CreatedAt     time.Time  `json:"created_at"`
+}
+
+type DisputeRequest struct {
+    Reason string `json:"reason"`
+}
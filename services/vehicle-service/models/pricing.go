@@ -0,0 +1,118 @@
+// Path: services/vehicle-service/models/pricing.go
+package models
+
+import (
+    "time"
+)
+
+// RateCard is one version of a vehicle type's prices. All amounts are in
+// minor units of the card's currency.
+type RateCard struct {
+    ID                    int       `json:"id"` // 0 for the built-in fallback
+    VehicleType           string    `json:"vehicle_type"`
+    Version               int       `json:"version"`
+    Currency              string    `json:"currency"`
+    HourlyRateCents       int64     `json:"hourly_rate_cents"`
+    PerKmCents            int64     `json:"per_km_cents"`
+    IncludedKm            int       `json:"included_km"`
+    EnergyCentsPerPercent int64     `json:"energy_cents_per_percent"`
+    EffectiveFrom         time.Time `json:"effective_from"`
+    CreatedAt             time.Time `json:"created_at"`
+}
+
+type RateCardRequest struct {
+    VehicleType           string     `json:"vehicle_type"`
+    Currency              string     `json:"currency"` // defaults to SGD
+    HourlyRateCents       int64      `json:"hourly_rate_cents"`
+    PerKmCents            int64      `json:"per_km_cents"`
+    IncludedKm            int        `json:"included_km"`
+    EnergyCentsPerPercent int64      `json:"energy_cents_per_percent"`
+    EffectiveFrom         *time.Time `json:"effective_from,omitempty"` // defaults to now
+}
+
+// TripUsage is what was actually used on a trip, from the readings taken at
+// pickup and return. Readings are nil when the vehicle never reported them.
+type TripUsage struct {
+    PickedUpAt        *time.Time `json:"picked_up_at,omitempty"`
+    ReturnedAt        *time.Time `json:"returned_at,omitempty"`
+    PickupOdometerKm  *int       `json:"pickup_odometer_km,omitempty"`
+    ReturnOdometerKm  *int       `json:"return_odometer_km,omitempty"`
+    PickupChargeLevel *int       `json:"pickup_charge_level,omitempty"`
+    ReturnChargeLevel *int       `json:"return_charge_level,omitempty"`
+    PickupLocation    string     `json:"pickup_location,omitempty"`
+    ReturnLocation    string     `json:"return_location,omitempty"`
+    PickupCleanliness string     `json:"pickup_cleanliness,omitempty"`
+    ReturnCleanliness string     `json:"return_cleanliness,omitempty"`
+}
+
+// DistanceKm is the distance driven, or 0 without both odometer readings
+func (u TripUsage) DistanceKm() int {
+    if u.PickupOdometerKm == nil || u.ReturnOdometerKm == nil || *u.ReturnOdometerKm < *u.PickupOdometerKm {
+        return 0
+    }
+    return *u.ReturnOdometerKm - *u.PickupOdometerKm
+}
+
+// EnergyUsedPercent is how many percentage points of charge or fuel were
+// used. Refuelling or charging during the trip can make this 0.
+func (u TripUsage) EnergyUsedPercent() int {
+    if u.PickupChargeLevel == nil || u.ReturnChargeLevel == nil || *u.ReturnChargeLevel > *u.PickupChargeLevel {
+        return 0
+    }
+    return *u.PickupChargeLevel - *u.ReturnChargeLevel
+}
+
+// PricingRule makes a time window dearer or cheaper
+type PricingRule struct {
+    ID                int     `json:"id"`
+    Name              string  `json:"name"`
+    VehicleType       *string `json:"vehicle_type,omitempty"`
+    DaysOfWeek        []int   `json:"days_of_week"`
+    StartMinute       int     `json:"start_minute"`
+    EndMinute         int     `json:"end_minute"`
+    MultiplierPercent int     `json:"multiplier_percent"`
+}
+
+// DemandMultiplier applies once a zone's utilization reaches the threshold
+type DemandMultiplier struct {
+    UtilizationPercent int `json:"utilization_percent"`
+    MultiplierPercent  int `json:"multiplier_percent"`
+}
+
+// AppliedRule is how much of a booking a pricing rule covered
+type AppliedRule struct {
+    Name              string `json:"name"`
+    Minutes           int64  `json:"minutes"`
+    MultiplierPercent int    `json:"multiplier_percent"`
+}
+
+type EstimateRequest struct {
+    VehicleID int       `json:"vehicle_id"`
+    StartTime time.Time `json:"start_time"`
+    EndTime   time.Time `json:"end_time"`
+}
+
+// PriceEstimate is what a booking would cost before it's made. All amounts
+// are in minor units of Currency; tax is added on the subtotal.
+type PriceEstimate struct {
+    VehicleID               int           `json:"vehicle_id"`
+    VehicleType             string        `json:"vehicle_type"`
+    Zone                    string        `json:"zone"`
+    Currency                string        `json:"currency"`
+    Minutes                 int64         `json:"minutes"`
+    HourlyRateCents         int64         `json:"hourly_rate_cents"`
+    BaseCents               int64         `json:"base_cents"`
+    DiscountPercent         int           `json:"discount_percent"`
+    DiscountCents           int64         `json:"discount_cents"`
+    PeakRules               []AppliedRule `json:"peak_rules"`
+    PeakAdjustmentCents     int64         `json:"peak_adjustment_cents"`
+    UtilizationPercent      int           `json:"utilization_percent"`
+    DemandMultiplierPercent int           `json:"demand_multiplier_percent"`
+    DemandAdjustmentCents   int64         `json:"demand_adjustment_cents"`
+    Surge                   bool          `json:"surge"` // priced above the normal rate
+    SubtotalCents           int64         `json:"subtotal_cents"`
+    TaxName                 string        `json:"tax_name,omitempty"`
+    TaxRateBps              int           `json:"tax_rate_bps"`
+    TaxCents                int64         `json:"tax_cents"`
+    TotalCents              int64         `json:"total_cents"`
+}
@@ -0,0 +1,63 @@
+// Path: services/vehicle-service/models/promotion.go
+package models
+
+import (
+    "time"
+)
+
+const (
+    PromoPercent = "percent"
+    PromoFixed   = "fixed"
+)
+
+const (
+    RedemptionApplied  = "Applied"
+    RedemptionReversed = "Reversed"
+)
+
+type Promotion struct {
+    ID             int        `json:"id"`
+    Code           string     `json:"code"`
+    Description    string     `json:"description"`
+    DiscountType   string     `json:"discount_type"`      // percent, fixed
+    DiscountValue  int64      `json:"discount_value"`     // percent, or cents off for fixed
+    Currency       string     `json:"currency,omitempty"` // of a fixed discount
+    StartsAt       time.Time  `json:"starts_at"`
+    ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+    MaxRedemptions *int       `json:"max_redemptions,omitempty"`
+    PerUserLimit   int        `json:"per_user_limit"`
+    Tiers          []string   `json:"tiers,omitempty"`
+    FirstRideOnly  bool       `json:"first_ride_only"`
+    Active         bool       `json:"active"`
+}
+
+type PromotionRedemption struct {
+    ID            int       `json:"id"`
+    PromotionID   int       `json:"promotion_id"`
+    UserID        int       `json:"user_id"`
+    ReservationID int       `json:"reservation_id"`
+    DiscountCents int64     `json:"discount_cents"`
+    Status        string    `json:"status"` // Applied, Reversed
+    CreatedAt     time.Time `json:"created_at"`
+}
+
+type PromoValidationRequest struct {
+    Code      string    `json:"code"`
+    VehicleID int       `json:"vehicle_id"`
+    StartTime time.Time `json:"start_time"`
+    EndTime   time.Time `json:"end_time"`
+}
+
+// PromoPreview shows what a code would take off a booking. All amounts are
+// in minor units of Currency, before tax; RentalCents already includes the
+// tier discount.
+type PromoPreview struct {
+    Valid         bool   `json:"valid"`
+    Reason        string `json:"reason,omitempty"`
+    Code          string `json:"code"`
+    Description   string `json:"description,omitempty"`
+    Currency      string `json:"currency"`
+    RentalCents   int64  `json:"rental_cents"`
+    DiscountCents int64  `json:"discount_cents"`
+    TotalCents    int64  `json:"total_cents"`
+}
@@ -0,0 +1,32 @@
+// Path: services/vehicle-service/models/referral.go
+package models
+
+import (
+    "time"
+)
+
+// Referral states, set by the user service at sign-up and again when the
+// referral is paid out
+const (
+    ReferralPending  = "Pending"
+    ReferralRewarded = "Rewarded"
+    ReferralRejected = "Rejected"
+)
+
+// Referral is a user who signed up with another user's referral code
+type Referral struct {
+    ID                  int        `json:"id"`
+    ReferrerID          int        `json:"referrer_id"`
+    RefereeID           int        `json:"referee_id"`
+    Status              string     `json:"status"`
+    ReferrerCreditCents int64      `json:"referrer_credit_cents"`
+    RefereeCreditCents  int64      `json:"referee_credit_cents"`
+    RewardedAt          *time.Time `json:"rewarded_at,omitempty"`
+}
+
+// ReferralContact is what a self-referral is recognised by. VerifiedPhone
+// is empty unless the user has verified their number.
+type ReferralContact struct {
+    Email         string
+    VerifiedPhone string
+}
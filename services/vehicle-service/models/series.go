@@ -0,0 +1,34 @@
+// Path: services/vehicle-service/models/series.go
+package models
+
+import (
+    "time"
+)
+
+// ReservationSeries is a recurring booking, expanded into one Reservation
+// per occurrence when it is created.
+type ReservationSeries struct {
+    ID           int           `json:"id"`
+    UserID       int           `json:"user_id"`
+    VehicleID    int           `json:"vehicle_id"`
+    RRule        string        `json:"rrule"`
+    StartTime    time.Time     `json:"start_time"`
+    EndTime      time.Time     `json:"end_time"`
+    Status       string        `json:"status"` // Active, Cancelled
+    CreatedAt    time.Time     `json:"created_at"`
+    UpdatedAt    time.Time     `json:"updated_at"`
+    Reservations []Reservation `json:"reservations,omitempty"`
+}
+
+// OccurrenceConflict is an occurrence that couldn't be booked
+type OccurrenceConflict struct {
+    StartTime time.Time `json:"start_time"`
+    EndTime   time.Time `json:"end_time"`
+    Reason    string    `json:"reason"`
+}
+
+type SeriesResult struct {
+    Series    ReservationSeries    `json:"series"`
+    Created   []Reservation        `json:"created"`
+    Conflicts []OccurrenceConflict `json:"conflicts"`
+}
@@ -0,0 +1,48 @@
+// Path: services/vehicle-service/models/statement.go
+package models
+
+import (
+    "time"
+)
+
+// Statement summarises a user's trips and charges for one calendar month.
+// Money is totalled per currency, as trips in different regions are billed
+// in different currencies.
+type Statement struct {
+    ID        int              `json:"id"`
+    UserID    int              `json:"user_id"`
+    Month     time.Time        `json:"month"` // first day of the month
+    TripCount int              `json:"trip_count"`
+    Minutes   int              `json:"minutes"`
+    Totals    []StatementTotal `json:"totals"`
+    CreatedAt time.Time        `json:"created_at"`
+    Trips     []StatementTrip  `json:"trips,omitempty"`
+}
+
+// StatementTotal is what a statement's trips and fees in one currency came
+// to, in its minor units
+type StatementTotal struct {
+    Currency          string `json:"currency"`
+    TripCount         int    `json:"trip_count"`
+    RentalCents       int64  `json:"rental_cents"`
+    TierSavingsCents  int64  `json:"tier_savings_cents"`
+    PromoSavingsCents int64  `json:"promo_savings_cents"`
+    FeesCents         int64  `json:"fees_cents"`
+    TotalCents        int64  `json:"total_cents"`
+}
+
+// StatementTrip is one completed trip on a statement
+type StatementTrip struct {
+    ReservationID     int       `json:"reservation_id"`
+    InvoiceID         int       `json:"invoice_id"`
+    VehicleModel      string    `json:"vehicle_model"`
+    VehicleType       string    `json:"vehicle_type"`
+    StartTime         time.Time `json:"start_time"`
+    ReturnedAt        time.Time `json:"returned_at"`
+    Minutes           int       `json:"minutes"`
+    Currency          string    `json:"currency"`
+    RentalCents       int64     `json:"rental_cents"`
+    TierSavingsCents  int64     `json:"tier_savings_cents"`
+    PromoSavingsCents int64     `json:"promo_savings_cents"`
+    TotalCents        int64     `json:"total_cents"`
+}
@@ -0,0 +1,42 @@
+// Path: services/vehicle-service/models/waitlist.go
+package models
+
+import (
+    "time"
+)
+
+const (
+    WaitlistWaiting   = "Waiting"
+    WaitlistOffered   = "Offered"
+    WaitlistAccepted  = "Accepted"
+    WaitlistExpired   = "Expired"
+    WaitlistCancelled = "Cancelled"
+)
+
+type WaitlistEntry struct {
+    ID               int        `json:"id"`
+    UserID           int        `json:"user_id"`
+    StartTime        time.Time  `json:"start_time"`
+    EndTime          time.Time  `json:"end_time"`
+    VehicleType      *string    `json:"vehicle_type,omitempty"`
+    Zone             *string    `json:"zone,omitempty"`
+    Status           string     `json:"status"` // Waiting, Offered, Accepted, Expired, Cancelled
+    OfferedVehicleID *int       `json:"offered_vehicle_id,omitempty"`
+    OfferExpiresAt   *time.Time `json:"offer_expires_at,omitempty"`
+    HoldID           *int       `json:"hold_id,omitempty"`
+    ReservationID    *int       `json:"reservation_id,omitempty"`
+    CreatedAt        time.Time  `json:"created_at"`
+    UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type WaitlistRequest struct {
+    StartTime   time.Time `json:"start_time"`
+    EndTime     time.Time `json:"end_time"`
+    VehicleType *string   `json:"vehicle_type,omitempty"`
+    Zone        *string   `json:"zone,omitempty"`
+}
+
+// AcceptOfferRequest is the optional body when a waitlist offer is taken
+type AcceptOfferRequest struct {
+    PromoCode string `json:"promo_code,omitempty"`
+}
@@ -0,0 +1,50 @@
+// Path: services/vehicle-service/models/wallet.go
+package models
+
+import (
+    "time"
+)
+
+// Ledger account kinds
+const (
+    AccountWallet           = "wallet"
+    AccountPaymentsClearing = "payments_clearing"
+    AccountGoodwill         = "goodwill"
+    AccountRevenue          = "revenue"
+    AccountReferrals        = "referrals"
+)
+
+// Ledger transaction kinds
+const (
+    LedgerTopUp         = "topup"
+    LedgerGoodwill      = "goodwill"
+    LedgerSpend         = "spend"
+    LedgerSpendReversal = "spend_reversal"
+    LedgerRefund        = "refund"
+    LedgerReferral      = "referral"
+)
+
+// WalletTransaction is one movement on a user's wallet as the user sees it
+type WalletTransaction struct {
+    ID          int       `json:"id"`
+    Kind        string    `json:"kind"` // topup, goodwill, spend, spend_reversal, refund, referral
+    Description string    `json:"description"`
+    AmountCents int64     `json:"amount_cents"` // negative when money left the wallet
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+type Wallet struct {
+    UserID       int                 `json:"user_id"`
+    BalanceCents int64               `json:"balance_cents"`
+    Transactions []WalletTransaction `json:"transactions"`
+}
+
+type TopUpRequest struct {
+    AmountCents int64 `json:"amount_cents"`
+}
+
+type CreditRequest struct {
+    UserID      int    `json:"user_id"`
+    AmountCents int64  `json:"amount_cents"`
+    Reason      string `json:"reason"`
+}
@@ -0,0 +1,121 @@
+// Path: services/vehicle-service/notifications/mailer.go
+package notifications
+
+import (
+    "bytes"
+    "encoding/base64"
+    "fmt"
+    "log"
+    "mime/multipart"
+    "net/smtp"
+    "net/textproto"
+    "os"
+    "strings"
+)
+
+type Attachment struct {
+    Filename    string
+    ContentType string
+    Data        []byte
+}
+
+type Message struct {
+    To          string
+    Subject     string
+    Body        string
+    Attachments []Attachment
+}
+
+// Mailer sends transactional emails to users
+type Mailer interface {
+    Send(msg Message) error
+}
+
+// NewMailerFromEnv returns an SMTP mailer when SMTP_ADDR is set and a log
+// mailer otherwise, so local development needs no mail server.
+func NewMailerFromEnv() Mailer {
+    addr := os.Getenv("SMTP_ADDR")
+    if addr == "" {
+        return &LogMailer{}
+    }
+
+    from := os.Getenv("SMTP_FROM")
+    if from == "" {
+        from = "no-reply@cnad-carsharing.local"
+    }
+
+    var auth smtp.Auth
+    if user := os.Getenv("SMTP_USER"); user != "" {
+        host := strings.Split(addr, ":")[0]
+        auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+    }
+
+    return &SMTPMailer{Addr: addr, From: from, Auth: auth}
+}
+
+// LogMailer writes emails to the service log instead of sending them
+type LogMailer struct{}
+
+func (m *LogMailer) Send(msg Message) error {
+    log.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+    for _, a := range msg.Attachments {
+        log.Printf("  attachment %s (%s, %d bytes)", a.Filename, a.ContentType, len(a.Data))
+    }
+    return nil
+}
+
+type SMTPMailer struct {
+    Addr string
+    From string
+    Auth smtp.Auth
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+    body, err := buildMIME(m.From, msg)
+    if err != nil {
+        return err
+    }
+    return smtp.SendMail(m.Addr, m.Auth, m.From, []string{msg.To}, body)
+}
+
+func buildMIME(from string, msg Message) ([]byte, error) {
+    var b bytes.Buffer
+    w := multipart.NewWriter(&b)
+
+    fmt.Fprintf(&b, "From: %s\r\n", from)
+    fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+    fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+    fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+    fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", w.Boundary())
+
+    part, err := w.CreatePart(textproto.MIMEHeader{
+        "Content-Type": {"text/plain; charset=utf-8"},
+    })
+    if err != nil {
+        return nil, err
+    }
+    part.Write([]byte(msg.Body))
+
+    for _, a := range msg.Attachments {
+        part, err := w.CreatePart(textproto.MIMEHeader{
+            "Content-Type":              {a.ContentType},
+            "Content-Transfer-Encoding": {"base64"},
+            "Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", a.Filename)},
+        })
+        if err != nil {
+            return nil, err
+        }
+
+        encoded := base64.StdEncoding.EncodeToString(a.Data)
+        for len(encoded) > 76 {
+            part.Write([]byte(encoded[:76] + "\r\n"))
+            encoded = encoded[76:]
+        }
+        part.Write([]byte(encoded + "\r\n"))
+    }
+
+    if err := w.Close(); err != nil {
+        return nil, err
+    }
+    return b.Bytes(), nil
+}
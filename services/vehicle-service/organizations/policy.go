@@ -0,0 +1,66 @@
+// Path: services/vehicle-service/organizations/policy.go
+package organizations
+
+import (
+    "fmt"
+    "time"
+
+    "vehicle-service/models"
+)
+
+// Violation explains why a business booking breaks the organization's
+// policy. It is the user's problem, not a server error.
+type Violation struct {
+    Reason string
+}
+
+func (e *Violation) Error() string {
+    return e.Reason
+}
+
+var (
+    ErrVehicleType       = &Violation{"your organization does not allow business bookings of this vehicle type"}
+    ErrWeekdaysOnly      = &Violation{"your organization only allows business bookings on weekdays"}
+    ErrCostCenterMissing = &Violation{"your organization requires a cost center on business bookings"}
+)
+
+// CheckPolicy returns a Violation when a business booking of vehicleType
+// from start to end doesn't meet the policy. costCenterID is 0 when none was
+// given.
+func CheckPolicy(policy *models.OrganizationPolicy, vehicleType string, start, end time.Time, costCenterID int) error {
+    if policy.MaxRentalHours != nil && end.Sub(start) > time.Duration(*policy.MaxRentalHours)*time.Hour {
+        return &Violation{fmt.Sprintf("your organization limits business bookings to %d hours", *policy.MaxRentalHours)}
+    }
+    if len(policy.AllowedVehicleTypes) > 0 && !contains(policy.AllowedVehicleTypes, vehicleType) {
+        return ErrVehicleType
+    }
+    if policy.WeekdaysOnly && touchesWeekend(start, end) {
+        return ErrWeekdaysOnly
+    }
+    if policy.RequireCostCenter && costCenterID == 0 {
+        return ErrCostCenterMissing
+    }
+    return nil
+}
+
+// touchesWeekend reports whether any part of the booking falls on a
+// Saturday or Sunday
+func touchesWeekend(start, end time.Time) bool {
+    day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+    for day.Before(end) {
+        if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+            return true
+        }
+        day = day.AddDate(0, 0, 1)
+    }
+    return false
+}
+
+func contains(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,78 @@
+// Path: services/vehicle-service/payments/payments.go
+package payments
+
+import (
+    "errors"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+)
+
+var (
+    ErrDeclined        = errors.New("payment declined")
+    ErrUnknownPayment  = errors.New("unknown payment reference")
+    ErrInvalidState    = errors.New("payment is not in a state that allows this operation")
+    ErrExceedsCaptured = errors.New("amount exceeds what is available on the payment")
+)
+
+// Operations, also used to tell the simulator what to fail
+const (
+    OpAuthorize = "authorize"
+    OpCapture   = "capture"
+    OpRefund    = "refund"
+    OpVoid      = "void"
+)
+
+// Result is the provider's answer to one operation. Reference identifies
+// the payment in later calls.
+type Result struct {
+    Reference   string `json:"reference"`
+    AmountCents int64  `json:"amount_cents"`
+}
+
+// PaymentProvider moves money for billing. A booking is authorized up
+// front, then either captured (fully or partially) when the trip is over or
+// voided; captured money can be refunded.
+type PaymentProvider interface {
+    Name() string
+    // Authorize reserves amountCents, in minor units of the ISO 4217
+    // currency, on the user's payment method. Later calls on the reference
+    // are in the same currency.
+    Authorize(userID int, amountCents int64, currency string, description string) (*Result, error)
+    // Capture takes up to the authorized amount and releases the rest
+    Capture(reference string, amountCents int64) (*Result, error)
+    // Refund returns part or all of a captured amount. Calls with the same
+    // idempotencyKey refund only once and get the first call's result.
+    Refund(reference string, amountCents int64, idempotencyKey string) (*Result, error)
+    // Void releases an authorization without taking anything
+    Void(reference string) (*Result, error)
+}
+
+// NewProviderFromEnv returns the payment provider for this deployment.
+// Only the local simulator exists so far; it is tuned with:
+//
+//	PAYMENT_SIM_FAIL      operations to decline, e.g. "authorize,capture" or "all"
+//	PAYMENT_SIM_FAIL_RATE chance (0-1) that any operation is declined
+//	PAYMENT_SIM_DELAY     latency added to every call, e.g. "2s"
+func NewProviderFromEnv() PaymentProvider {
+    sim := NewSimulator()
+
+    for _, op := range strings.Split(os.Getenv("PAYMENT_SIM_FAIL"), ",") {
+        if op = strings.TrimSpace(strings.ToLower(op)); op != "" {
+            sim.FailOn(op)
+        }
+    }
+    if v := os.Getenv("PAYMENT_SIM_FAIL_RATE"); v != "" {
+        if rate, err := strconv.ParseFloat(v, 64); err == nil {
+            sim.FailRate = rate
+        }
+    }
+    if v := os.Getenv("PAYMENT_SIM_DELAY"); v != "" {
+        if delay, err := time.ParseDuration(v); err == nil {
+            sim.Delay = delay
+        }
+    }
+
+    return sim
+}
@@ -0,0 +1,209 @@
+// Path: services/vehicle-service/payments/simulator.go
+package payments
+
+import (
+    "fmt"
+    "log"
+    "math/rand"
+    "sync"
+    "time"
+)
+
+// Simulated payment states
+const (
+    StatusAuthorized = "authorized"
+    StatusCaptured   = "captured"
+    StatusVoided     = "voided"
+)
+
+type simPayment struct {
+    userID     int
+    authorized int64
+    captured   int64
+    refunded   int64
+    status     string // StatusAuthorized, StatusCaptured, StatusVoided
+}
+
+// Record is a payment the simulator handed out earlier, as billing stored
+// it. See Restore.
+type Record struct {
+    Reference       string
+    UserID          int
+    AuthorizedCents int64
+    CapturedCents   int64
+    RefundedCents   int64
+    Status          string // StatusAuthorized, StatusCaptured, StatusVoided
+}
+
+// Simulator is an in-memory PaymentProvider for local development and
+// testing. It approves everything unless told to fail or slow down.
+type Simulator struct {
+    Delay    time.Duration
+    FailRate float64
+
+    mu       sync.Mutex
+    failOn   map[string]bool
+    payments map[string]*simPayment
+    refunds  map[string]*Result // by idempotency key
+    nextID   int
+}
+
+func NewSimulator() *Simulator {
+    return &Simulator{
+        failOn:   make(map[string]bool),
+        payments: make(map[string]*simPayment),
+        refunds:  make(map[string]*Result),
+    }
+}
+
+// Restore loads payments from before a restart, so the references billing
+// stored can still be captured, voided or refunded
+func (s *Simulator) Restore(records []Record) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    for _, r := range records {
+        s.payments[r.Reference] = &simPayment{
+            userID:     r.UserID,
+            authorized: r.AuthorizedCents,
+            captured:   r.CapturedCents,
+            refunded:   r.RefundedCents,
+            status:     r.Status,
+        }
+    }
+    log.Printf("Payment simulator: restored %d payments", len(records))
+}
+
+// FailOn makes every call of the given operation ("all" for every
+// operation) get declined until Reset is called.
+func (s *Simulator) FailOn(op string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.failOn[op] = true
+}
+
+// Reset approves everything again
+func (s *Simulator) Reset() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.failOn = make(map[string]bool)
+    s.FailRate = 0
+    s.Delay = 0
+}
+
+func (s *Simulator) Name() string {
+    return "simulator"
+}
+
+func (s *Simulator) Authorize(userID int, amountCents int64, currency string, description string) (*Result, error) {
+    if err := s.begin(OpAuthorize); err != nil {
+        return nil, err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.nextID++
+    ref := fmt.Sprintf("sim_%d_%d", time.Now().Unix(), s.nextID)
+    s.payments[ref] = &simPayment{userID: userID, authorized: amountCents, status: StatusAuthorized}
+
+    log.Printf("Payment simulator: authorized %d %s for user %d (%s) as %s", amountCents, currency, userID, description, ref)
+    return &Result{Reference: ref, AmountCents: amountCents}, nil
+}
+
+func (s *Simulator) Capture(reference string, amountCents int64) (*Result, error) {
+    if err := s.begin(OpCapture); err != nil {
+        return nil, err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    p, ok := s.payments[reference]
+    if !ok {
+        return nil, ErrUnknownPayment
+    }
+    if p.status != StatusAuthorized {
+        return nil, ErrInvalidState
+    }
+    if amountCents > p.authorized {
+        return nil, ErrExceedsCaptured
+    }
+
+    p.captured = amountCents
+    p.status = StatusCaptured
+
+    log.Printf("Payment simulator: captured %d of %d cents on %s", amountCents, p.authorized, reference)
+    return &Result{Reference: reference, AmountCents: amountCents}, nil
+}
+
+func (s *Simulator) Refund(reference string, amountCents int64, idempotencyKey string) (*Result, error) {
+    s.mu.Lock()
+    previous, ok := s.refunds[idempotencyKey]
+    s.mu.Unlock()
+    if ok {
+        return previous, nil
+    }
+
+    if err := s.begin(OpRefund); err != nil {
+        return nil, err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    p, ok := s.payments[reference]
+    if !ok {
+        return nil, ErrUnknownPayment
+    }
+    if p.status != StatusCaptured {
+        return nil, ErrInvalidState
+    }
+    if amountCents > p.captured-p.refunded {
+        return nil, ErrExceedsCaptured
+    }
+
+    p.refunded += amountCents
+    result := &Result{Reference: reference, AmountCents: amountCents}
+    if idempotencyKey != "" {
+        s.refunds[idempotencyKey] = result
+    }
+
+    log.Printf("Payment simulator: refunded %d cents on %s", amountCents, reference)
+    return result, nil
+}
+
+func (s *Simulator) Void(reference string) (*Result, error) {
+    if err := s.begin(OpVoid); err != nil {
+        return nil, err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    p, ok := s.payments[reference]
+    if !ok {
+        return nil, ErrUnknownPayment
+    }
+    if p.status != StatusAuthorized {
+        return nil, ErrInvalidState
+    }
+
+    p.status = StatusVoided
+
+    log.Printf("Payment simulator: voided %s", reference)
+    return &Result{Reference: reference}, nil
+}
+
+// begin applies the configured delay and decides whether to decline
+func (s *Simulator) begin(op string) error {
+    s.mu.Lock()
+    delay, rate := s.Delay, s.FailRate
+    fail := s.failOn[op] || s.failOn["all"]
+    s.mu.Unlock()
+
+    if delay > 0 {
+        time.Sleep(delay)
+    }
+    if fail || (rate > 0 && rand.Float64() < rate) {
+        log.Printf("Payment simulator: declining %s", op)
+        return ErrDeclined
+    }
+    return nil
+}
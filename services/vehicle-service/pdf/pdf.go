@@ -0,0 +1,130 @@
+// Path: services/vehicle-service/pdf/pdf.go
+package pdf
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+)
+
+// A4 in points
+const (
+    PageWidth  = 595.0
+    PageHeight = 842.0
+)
+
+// Font is one of the standard PDF fonts, so nothing has to be embedded
+type Font int
+
+const (
+    Regular Font = iota // Helvetica
+    Bold                // Helvetica-Bold
+    Mono                // Courier, handy for aligning amounts
+)
+
+var fontNames = []string{"Helvetica", "Helvetica-Bold", "Courier"}
+
+// Document is a minimal PDF writer for simple text documents such as
+// invoices: text in the standard fonts and straight lines, nothing else.
+// Coordinates are in points from the top left corner of the page.
+type Document struct {
+    pages []*bytes.Buffer
+}
+
+func New() *Document {
+    d := &Document{}
+    d.AddPage()
+    return d
+}
+
+func (d *Document) AddPage() {
+    d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) page() *bytes.Buffer {
+    return d.pages[len(d.pages)-1]
+}
+
+// Text writes s with its baseline at y
+func (d *Document) Text(x, y float64, font Font, size float64, s string) {
+    fmt.Fprintf(d.page(), "BT /F%d %.1f Tf %.2f %.2f Td (%s) Tj ET\n", int(font)+1, size, x, PageHeight-y, escape(s))
+}
+
+// TextRight writes monospaced s so that it ends at x
+func (d *Document) TextRight(x, y float64, size float64, s string) {
+    d.Text(x-MonoWidth(s, size), y, Mono, size, s)
+}
+
+// Line draws a thin line between two points
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+    fmt.Fprintf(d.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// MonoWidth is the width of s in the Mono font
+func MonoWidth(s string, size float64) float64 {
+    return float64(len(s)) * 0.6 * size
+}
+
+// Bytes assembles the file: catalog, page tree, fonts, then each page
+// followed by its content stream, and the cross-reference table.
+func (d *Document) Bytes() []byte {
+    var out bytes.Buffer
+    var offsets []int
+
+    object := func(body string) {
+        offsets = append(offsets, out.Len())
+        fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+    }
+
+    out.WriteString("%PDF-1.4\n")
+
+    firstPage := 3 + len(fontNames)
+    var kids []string
+    for i := range d.pages {
+        kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+    }
+
+    object("<< /Type /Catalog /Pages 2 0 R >>")
+    object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+    var fonts []string
+    for i, name := range fontNames {
+        object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+        fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i+1, 3+i))
+    }
+
+    for i, content := range d.pages {
+        object(fmt.Sprintf(
+            "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+            PageWidth, PageHeight, strings.Join(fonts, " "), firstPage+2*i+1,
+        ))
+        object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+    }
+
+    xref := out.Len()
+    fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+    for _, off := range offsets {
+        fmt.Fprintf(&out, "%010d 00000 n \n", off)
+    }
+    fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+    return out.Bytes()
+}
+
+// escape makes s safe inside a PDF string. Characters outside printable
+// ASCII are replaced, as the standard fonts only cover WinAnsi.
+func escape(s string) string {
+    var b strings.Builder
+    for _, r := range s {
+        switch {
+        case r == '(' || r == ')' || r == '\\':
+            b.WriteByte('\\')
+            b.WriteRune(r)
+        case r < 32 || r > 126:
+            b.WriteByte('?')
+        default:
+            b.WriteRune(r)
+        }
+    }
+    return b.String()
+}
@@ -0,0 +1,76 @@
+// Path: services/vehicle-service/penalties/penalties.go
+package penalties
+
+import (
+    "fmt"
+    "math"
+
+    "vehicle-service/models"
+)
+
+// Evaluate returns the fines a return earns under the rules in the report's
+// currency. Fines only carry kind, description and amount; the caller
+// attaches them to the trip.
+func Evaluate(rules []models.PenaltyRule, report models.ReturnReport) []models.Fine {
+    var fines []models.Fine
+
+    for _, rule := range rules {
+        if rule.Currency != report.Currency {
+            continue
+        }
+
+        var fine *models.Fine
+        switch rule.Kind {
+        case models.PenaltyLateReturn:
+            fine = lateReturn(rule, report)
+        case models.PenaltyLowCharge:
+            if report.ChargeLevel != nil && *report.ChargeLevel < rule.Threshold {
+                fine = &models.Fine{Description: fmt.Sprintf("Returned with %d%% charge, below the %d%% minimum", *report.ChargeLevel, rule.Threshold)}
+            }
+        case models.PenaltyDirty:
+            // Only a vehicle that was clean when the trip started is the
+            // driver's fault
+            if report.PickupCleanliness == models.CleanlinessClean && report.ReturnCleanliness == models.CleanlinessNeedsCleaning {
+                fine = &models.Fine{Description: "Vehicle returned dirty"}
+            }
+        case models.PenaltyOutOfZone:
+            if report.PickupLocation != "" && report.ReturnLocation != "" && report.ReturnLocation != report.PickupLocation {
+                fine = &models.Fine{Description: fmt.Sprintf("Returned to %s instead of %s", report.ReturnLocation, report.PickupLocation)}
+            }
+        }
+        if fine == nil {
+            continue
+        }
+
+        fine.Kind = rule.Kind
+        if fine.AmountCents == 0 {
+            fine.AmountCents = capped(rule, rule.AmountCents)
+        }
+        if fine.AmountCents > 0 {
+            fines = append(fines, *fine)
+        }
+    }
+
+    return fines
+}
+
+// lateReturn charges per minute past the booked end once the grace period
+// is used up, plus the flat amount
+func lateReturn(rule models.PenaltyRule, report models.ReturnReport) *models.Fine {
+    late := int64(math.Ceil(report.ReturnedAt.Sub(report.BookedEnd).Minutes()))
+    if late <= int64(rule.Threshold) {
+        return nil
+    }
+
+    return &models.Fine{
+        Description: fmt.Sprintf("Returned %d min late (%d min grace)", late, rule.Threshold),
+        AmountCents: capped(rule, rule.AmountCents+(late-int64(rule.Threshold))*rule.PerUnitCents),
+    }
+}
+
+func capped(rule models.PenaltyRule, amount int64) int64 {
+    if rule.MaxCents != nil && amount > *rule.MaxCents {
+        return *rule.MaxCents
+    }
+    return amount
+}
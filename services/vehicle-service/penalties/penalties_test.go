@@ -0,0 +1,94 @@
+// Path: services/vehicle-service/penalties/penalties_test.go
+package penalties
+
+import (
+    "reflect"
+    "testing"
+    "time"
+
+    "vehicle-service/models"
+)
+
+func TestEvaluate(t *testing.T) {
+    maxLate := int64(5000)
+    rules := []models.PenaltyRule{
+        {Kind: models.PenaltyLateReturn, Currency: "SGD", Threshold: 15, PerUnitCents: 50, MaxCents: &maxLate},
+        {Kind: models.PenaltyLowCharge, Currency: "SGD", Threshold: 20, AmountCents: 1500},
+        {Kind: models.PenaltyDirty, Currency: "SGD", AmountCents: 2500},
+        {Kind: models.PenaltyOutOfZone, Currency: "SGD", AmountCents: 3000},
+        {Kind: models.PenaltyDirty, Currency: "MYR", AmountCents: 8000},
+    }
+
+    end := time.Date(2025, time.June, 2, 12, 0, 0, 0, time.UTC)
+    charge := func(level int) *int {
+        return &level
+    }
+    report := func(late time.Duration, change func(*models.ReturnReport)) models.ReturnReport {
+        r := models.ReturnReport{
+            Currency:          "SGD",
+            BookedEnd:         end,
+            ReturnedAt:        end.Add(late),
+            ChargeLevel:       charge(80),
+            PickupCleanliness: models.CleanlinessClean,
+            ReturnCleanliness: models.CleanlinessClean,
+            PickupLocation:    "Orchard",
+            ReturnLocation:    "Orchard",
+        }
+        if change != nil {
+            change(&r)
+        }
+        return r
+    }
+
+    tests := []struct {
+        name   string
+        report models.ReturnReport
+        want   map[string]int64
+    }{
+        {"clean return", report(0, nil), map[string]int64{}},
+        {"early return", report(-30*time.Minute, nil), map[string]int64{}},
+        {"within grace", report(15*time.Minute, nil), map[string]int64{}},
+        {"first minute past grace", report(16*time.Minute, nil), map[string]int64{models.PenaltyLateReturn: 50}},
+        {"started minute counts", report(15*time.Minute+time.Second, nil), map[string]int64{models.PenaltyLateReturn: 50}},
+        {"per minute", report(45*time.Minute, nil), map[string]int64{models.PenaltyLateReturn: 30 * 50}},
+        {"capped", report(200*time.Minute, nil), map[string]int64{models.PenaltyLateReturn: 5000}},
+        {"low charge", report(0, func(r *models.ReturnReport) { r.ChargeLevel = charge(19) }),
+            map[string]int64{models.PenaltyLowCharge: 1500}},
+        {"charge at the minimum", report(0, func(r *models.ReturnReport) { r.ChargeLevel = charge(20) }), map[string]int64{}},
+        {"charge not reported", report(0, func(r *models.ReturnReport) { r.ChargeLevel = nil }), map[string]int64{}},
+        {"clean to dirty", report(0, func(r *models.ReturnReport) { r.ReturnCleanliness = models.CleanlinessNeedsCleaning }),
+            map[string]int64{models.PenaltyDirty: 2500}},
+        {"already dirty at pickup", report(0, func(r *models.ReturnReport) {
+            r.PickupCleanliness = models.CleanlinessNeedsCleaning
+            r.ReturnCleanliness = models.CleanlinessNeedsCleaning
+        }), map[string]int64{}},
+        {"pickup cleanliness unknown", report(0, func(r *models.ReturnReport) {
+            r.PickupCleanliness = ""
+            r.ReturnCleanliness = models.CleanlinessNeedsCleaning
+        }), map[string]int64{}},
+        {"out of zone", report(0, func(r *models.ReturnReport) { r.ReturnLocation = "Tampines" }),
+            map[string]int64{models.PenaltyOutOfZone: 3000}},
+        {"pickup location unknown", report(0, func(r *models.ReturnReport) { r.PickupLocation = "" }), map[string]int64{}},
+        {"return location unknown", report(0, func(r *models.ReturnReport) { r.ReturnLocation = "" }), map[string]int64{}},
+        {"several at once", report(45*time.Minute, func(r *models.ReturnReport) {
+            r.ChargeLevel = charge(5)
+            r.ReturnLocation = "Tampines"
+        }), map[string]int64{models.PenaltyLateReturn: 1500, models.PenaltyLowCharge: 1500, models.PenaltyOutOfZone: 3000}},
+        {"rules in the invoice currency only", report(45*time.Minute, func(r *models.ReturnReport) {
+            r.Currency = "MYR"
+            r.ReturnCleanliness = models.CleanlinessNeedsCleaning
+        }), map[string]int64{models.PenaltyDirty: 8000}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := map[string]int64{}
+            for _, fine := range Evaluate(rules, tt.report) {
+                got[fine.Kind] = fine.AmountCents
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,181 @@
+// Path: services/vehicle-service/pricing/pricing.go
+package pricing
+
+import (
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/tiers"
+)
+
+// Hourly rates in cents by vehicle type, used when the database has no rate
+// card for the type
+var hourlyRates = map[string]int64{
+    "Sedan":    1200,
+    "SUV":      1800,
+    "Electric": 1500,
+    "Van":      2000,
+}
+
+const defaultHourlyRate = 1500
+
+// Quote is a price breakdown. All amounts are in cents.
+type Quote struct {
+    Minutes         int64 `json:"minutes"`
+    HourlyRateCents int64 `json:"hourly_rate_cents"`
+    BaseCents       int64 `json:"base_cents"`
+    DiscountPercent int   `json:"discount_percent"`
+    DiscountCents   int64 `json:"discount_cents"`
+    TotalCents      int64 `json:"total_cents"`
+}
+
+// UsageQuote prices a finished trip: actual time with the tier discount,
+// plus distance beyond the included kilometres and energy used
+type UsageQuote struct {
+    Time          Quote `json:"time"`
+    DistanceKm    int   `json:"distance_km"`
+    BillableKm    int   `json:"billable_km"`
+    DistanceCents int64 `json:"distance_cents"`
+    EnergyPercent int   `json:"energy_percent"`
+    EnergyCents   int64 `json:"energy_cents"`
+    TotalCents    int64 `json:"total_cents"`
+}
+
+// DefaultRateCard is a time-only card for vehicle types without one
+func DefaultRateCard(vehicleType string) models.RateCard {
+    rate, ok := hourlyRates[vehicleType]
+    if !ok {
+        rate = defaultHourlyRate
+    }
+    return models.RateCard{VehicleType: vehicleType, Currency: models.DefaultCurrency, HourlyRateCents: rate}
+}
+
+// Estimate prices a rental of the given length, billed per started minute,
+// with the tier discount applied.
+func Estimate(card models.RateCard, tier string, duration time.Duration) Quote {
+    rate := card.HourlyRateCents
+
+    minutes := int64((duration + time.Minute - 1) / time.Minute)
+    if minutes < 0 {
+        minutes = 0
+    }
+
+    base := rate * minutes / 60
+    discountPercent := tiers.For(tier).DiscountPercent
+    discount := base * int64(discountPercent) / 100
+
+    return Quote{
+        Minutes:         minutes,
+        HourlyRateCents: rate,
+        BaseCents:       base,
+        DiscountPercent: discountPercent,
+        DiscountCents:   discount,
+        TotalCents:      base - discount,
+    }
+}
+
+// Usage prices a trip from what was actually used. The tier discount only
+// applies to time; distance and energy are charged at cost.
+func Usage(card models.RateCard, tier string, duration time.Duration, distanceKm int, energyPercent int) UsageQuote {
+    q := UsageQuote{
+        Time:          Estimate(card, tier, duration),
+        DistanceKm:    distanceKm,
+        EnergyPercent: energyPercent,
+    }
+
+    if distanceKm > card.IncludedKm {
+        q.BillableKm = distanceKm - card.IncludedKm
+    }
+    q.DistanceCents = int64(q.BillableKm) * card.PerKmCents
+    q.EnergyCents = int64(energyPercent) * card.EnergyCentsPerPercent
+    q.TotalCents = q.Time.TotalCents + q.DistanceCents + q.EnergyCents
+
+    return q
+}
+
+// Tax is the tax on subtotalCents at rateBps basis points, rounded half up.
+// Nothing is charged on a credit.
+func Tax(subtotalCents int64, rateBps int) int64 {
+    if subtotalCents <= 0 || rateBps <= 0 {
+        return 0
+    }
+    return (subtotalCents*int64(rateBps) + 5000) / 10000
+}
+
+// PeakAdjustment works out how much the pricing rules add to (or take off)
+// baseCents for a booking from start to end. Every minute is priced with the
+// rule covering it, the strongest one if several overlap, or at 100% if
+// none does.
+func PeakAdjustment(rules []models.PricingRule, start, end time.Time, baseCents int64) (int64, []models.AppliedRule) {
+    applied := []models.AppliedRule{}
+    if !end.After(start) {
+        return 0, applied
+    }
+
+    byRule := map[int]int{}
+    var minutes, weighted int64
+    for t := start.In(time.Local); t.Before(end); t = t.Add(time.Minute) {
+        minutes++
+        rule := matchRule(rules, t)
+        if rule == nil {
+            weighted += 100
+            continue
+        }
+        weighted += int64(rule.MultiplierPercent)
+
+        i, ok := byRule[rule.ID]
+        if !ok {
+            i = len(applied)
+            byRule[rule.ID] = i
+            applied = append(applied, models.AppliedRule{Name: rule.Name, MultiplierPercent: rule.MultiplierPercent})
+        }
+        applied[i].Minutes++
+    }
+
+    return baseCents * (weighted - 100*minutes) / (100 * minutes), applied
+}
+
+func matchRule(rules []models.PricingRule, t time.Time) *models.PricingRule {
+    minute := t.Hour()*60 + t.Minute()
+    day := int(t.Weekday())
+
+    var best *models.PricingRule
+    for i := range rules {
+        rule := &rules[i]
+        if minute < rule.StartMinute || minute >= rule.EndMinute || !containsDay(rule.DaysOfWeek, day) {
+            continue
+        }
+        if best == nil || abs(rule.MultiplierPercent-100) > abs(best.MultiplierPercent-100) {
+            best = rule
+        }
+    }
+    return best
+}
+
+// DemandMultiplier picks the multiplier for the highest utilization
+// threshold reached, or 100 when none is
+func DemandMultiplier(multipliers []models.DemandMultiplier, utilizationPercent int) int {
+    percent, threshold := 100, -1
+    for _, m := range multipliers {
+        if utilizationPercent >= m.UtilizationPercent && m.UtilizationPercent > threshold {
+            percent, threshold = m.MultiplierPercent, m.UtilizationPercent
+        }
+    }
+    return percent
+}
+
+func containsDay(days []int, day int) bool {
+    for _, d := range days {
+        if d == day {
+            return true
+        }
+    }
+    return false
+}
+
+func abs(n int) int {
+    if n < 0 {
+        return -n
+    }
+    return n
+}
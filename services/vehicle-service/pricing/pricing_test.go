@@ -0,0 +1,140 @@
+// Path: services/vehicle-service/pricing/pricing_test.go
+package pricing
+
+import (
+    "reflect"
+    "testing"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/tiers"
+)
+
+func TestEstimate(t *testing.T) {
+    card := models.RateCard{HourlyRateCents: 1200}
+
+    tests := []struct {
+        name     string
+        tier     string
+        duration time.Duration
+        want     Quote
+    }{
+        {"one hour", tiers.Basic, time.Hour, Quote{Minutes: 60, HourlyRateCents: 1200, BaseCents: 1200, TotalCents: 1200}},
+        {"started minute is billed", tiers.Basic, 61 * time.Second, Quote{Minutes: 2, HourlyRateCents: 1200, BaseCents: 40, TotalCents: 40}},
+        {"premium discount", tiers.Premium, 90 * time.Minute, Quote{Minutes: 90, HourlyRateCents: 1200, BaseCents: 1800, DiscountPercent: 10, DiscountCents: 180, TotalCents: 1620}},
+        {"vip discount", tiers.VIP, time.Hour, Quote{Minutes: 60, HourlyRateCents: 1200, BaseCents: 1200, DiscountPercent: 20, DiscountCents: 240, TotalCents: 960}},
+        {"unknown tier pays basic", "Gold", time.Hour, Quote{Minutes: 60, HourlyRateCents: 1200, BaseCents: 1200, TotalCents: 1200}},
+        {"negative duration", tiers.Basic, -time.Hour, Quote{HourlyRateCents: 1200}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := Estimate(card, tt.tier, tt.duration); got != tt.want {
+                t.Errorf("Estimate() = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestUsage(t *testing.T) {
+    card := models.RateCard{HourlyRateCents: 1200, PerKmCents: 50, IncludedKm: 20, EnergyCentsPerPercent: 10}
+
+    tests := []struct {
+        name          string
+        tier          string
+        distanceKm    int
+        energyPercent int
+        wantBillable  int
+        wantTotal     int64
+    }{
+        {"within included distance", tiers.Basic, 10, 0, 0, 1200},
+        {"exactly included distance", tiers.Basic, 20, 0, 0, 1200},
+        {"extra distance and energy", tiers.Basic, 35, 30, 15, 1200 + 750 + 300},
+        {"discount only on time", tiers.Premium, 35, 30, 15, 1080 + 750 + 300},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := Usage(card, tt.tier, time.Hour, tt.distanceKm, tt.energyPercent)
+            if got.BillableKm != tt.wantBillable {
+                t.Errorf("BillableKm = %d, want %d", got.BillableKm, tt.wantBillable)
+            }
+            if got.TotalCents != tt.wantTotal {
+                t.Errorf("TotalCents = %d, want %d", got.TotalCents, tt.wantTotal)
+            }
+        })
+    }
+}
+
+func TestPeakAdjustment(t *testing.T) {
+    weekdays := []int{1, 2, 3, 4, 5}
+    allDays := []int{0, 1, 2, 3, 4, 5, 6}
+    peak := models.PricingRule{ID: 1, Name: "Evening peak", DaysOfWeek: weekdays, StartMinute: 17 * 60, EndMinute: 19 * 60, MultiplierPercent: 150}
+    busy := models.PricingRule{ID: 2, Name: "Busy", DaysOfWeek: weekdays, StartMinute: 16 * 60, EndMinute: 20 * 60, MultiplierPercent: 120}
+    night := models.PricingRule{ID: 3, Name: "Night", DaysOfWeek: allDays, StartMinute: 0, EndMinute: 6 * 60, MultiplierPercent: 80}
+
+    // 2 June 2025 is a Monday
+    at := func(day, hour, minute int) time.Time {
+        return time.Date(2025, time.June, day, hour, minute, 0, 0, time.Local)
+    }
+
+    tests := []struct {
+        name        string
+        rules       []models.PricingRule
+        start, end  time.Time
+        want        int64
+        wantApplied []models.AppliedRule
+    }{
+        {"no rules", nil, at(2, 17, 0), at(2, 18, 0), 0, []models.AppliedRule{}},
+        {"empty booking", []models.PricingRule{peak}, at(2, 18, 0), at(2, 17, 0), 0, []models.AppliedRule{}},
+        {"fully in peak", []models.PricingRule{peak}, at(2, 17, 0), at(2, 18, 0), 600,
+            []models.AppliedRule{{Name: "Evening peak", Minutes: 60, MultiplierPercent: 150}}},
+        {"half in peak", []models.PricingRule{peak}, at(2, 16, 30), at(2, 17, 30), 300,
+            []models.AppliedRule{{Name: "Evening peak", Minutes: 30, MultiplierPercent: 150}}},
+        {"weekend is off peak", []models.PricingRule{peak}, at(7, 17, 0), at(7, 18, 0), 0, []models.AppliedRule{}},
+        {"discount", []models.PricingRule{night}, at(3, 2, 0), at(3, 3, 0), -240,
+            []models.AppliedRule{{Name: "Night", Minutes: 60, MultiplierPercent: 80}}},
+        {"strongest overlapping rule wins", []models.PricingRule{busy, peak}, at(2, 16, 30), at(2, 17, 30), 1200 * (30*20 + 30*50) / 6000,
+            []models.AppliedRule{
+                {Name: "Busy", Minutes: 30, MultiplierPercent: 120},
+                {Name: "Evening peak", Minutes: 30, MultiplierPercent: 150},
+            }},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, applied := PeakAdjustment(tt.rules, tt.start, tt.end, 1200)
+            if got != tt.want {
+                t.Errorf("PeakAdjustment() = %d, want %d", got, tt.want)
+            }
+            if !reflect.DeepEqual(applied, tt.wantApplied) {
+                t.Errorf("applied = %+v, want %+v", applied, tt.wantApplied)
+            }
+        })
+    }
+}
+
+func TestTax(t *testing.T) {
+    tests := []struct {
+        name     string
+        subtotal int64
+        rateBps  int
+        want     int64
+    }{
+        {"gst", 10000, 900, 900},
+        {"rounds half up", 50, 900, 5},         // 4.5
+        {"rounds down below half", 49, 900, 4}, // 4.41
+        {"half a cent", 1, 5000, 1},
+        {"no rate", 10000, 0, 0},
+        {"nothing charged", 0, 900, 0},
+        {"no tax on a credit", -10000, 900, 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := Tax(tt.subtotal, tt.rateBps); got != tt.want {
+                t.Errorf("Tax(%d, %d) = %d, want %d", tt.subtotal, tt.rateBps, got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,98 @@
+// Path: services/vehicle-service/promotions/promotions.go
+package promotions
+
+import (
+    "strings"
+    "time"
+
+    "vehicle-service/models"
+)
+
+// Rejection explains why a code can't be used. It is the user's problem,
+// not a server error.
+type Rejection struct {
+    Reason string
+}
+
+func (e *Rejection) Error() string {
+    return e.Reason
+}
+
+var (
+    ErrNotFound       = &Rejection{"promo code not found"}
+    ErrInactive       = &Rejection{"promo code is not active yet"}
+    ErrExpired        = &Rejection{"promo code has expired"}
+    ErrExhausted      = &Rejection{"promo code has been fully redeemed"}
+    ErrUserLimit      = &Rejection{"you have already used this promo code"}
+    ErrTierNotAllowed = &Rejection{"promo code is not available for your membership tier"}
+    ErrNotFirstRide   = &Rejection{"promo code is only valid on your first ride"}
+    ErrWrongCurrency  = &Rejection{"promo code is not valid in this currency"}
+)
+
+// Usage is how often a promotion has been redeemed so far
+type Usage struct {
+    Total      int // applied redemptions by anyone
+    ByUser     int // applied redemptions by this user
+    PriorTrips int // the user's other active or completed reservations
+}
+
+// Normalize upper-cases a code as typed by the user
+func Normalize(code string) string {
+    return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Check returns a Rejection when the promotion can't be used by a member of
+// tier on a booking in currency right now. A fixed discount only applies in
+// its own currency.
+func Check(p *models.Promotion, usage Usage, tier string, currency string, now time.Time) error {
+    if !p.Active || now.Before(p.StartsAt) {
+        return ErrInactive
+    }
+    if p.ExpiresAt != nil && !now.Before(*p.ExpiresAt) {
+        return ErrExpired
+    }
+    if p.MaxRedemptions != nil && usage.Total >= *p.MaxRedemptions {
+        return ErrExhausted
+    }
+    if usage.ByUser >= p.PerUserLimit {
+        return ErrUserLimit
+    }
+    if len(p.Tiers) > 0 && !contains(p.Tiers, tier) {
+        return ErrTierNotAllowed
+    }
+    if p.FirstRideOnly && usage.PriorTrips > 0 {
+        return ErrNotFirstRide
+    }
+    if p.DiscountType == models.PromoFixed && p.Currency != currency {
+        return ErrWrongCurrency
+    }
+    return nil
+}
+
+// Discount is the amount taken off amountCents, never more than the amount
+func Discount(p *models.Promotion, amountCents int64) int64 {
+    var discount int64
+    switch p.DiscountType {
+    case models.PromoPercent:
+        discount = amountCents * p.DiscountValue / 100
+    case models.PromoFixed:
+        discount = p.DiscountValue
+    }
+
+    if discount > amountCents {
+        discount = amountCents
+    }
+    if discount < 0 {
+        discount = 0
+    }
+    return discount
+}
+
+func contains(list []string, s string) bool {
+    for _, v := range list {
+        if strings.EqualFold(v, s) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,92 @@
+// Path: services/vehicle-service/promotions/promotions_test.go
+package promotions
+
+import (
+    "testing"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/tiers"
+)
+
+func TestDiscount(t *testing.T) {
+    tests := []struct {
+        name   string
+        promo  models.Promotion
+        amount int64
+        want   int64
+    }{
+        {"percent", models.Promotion{DiscountType: models.PromoPercent, DiscountValue: 20}, 1000, 200},
+        {"percent rounds down", models.Promotion{DiscountType: models.PromoPercent, DiscountValue: 15}, 999, 149}, // 149.85
+        {"full price off", models.Promotion{DiscountType: models.PromoPercent, DiscountValue: 100}, 999, 999},
+        {"fixed", models.Promotion{DiscountType: models.PromoFixed, DiscountValue: 500, Currency: "SGD"}, 1200, 500},
+        {"fixed above the subtotal", models.Promotion{DiscountType: models.PromoFixed, DiscountValue: 500, Currency: "SGD"}, 300, 300},
+        {"nothing to discount", models.Promotion{DiscountType: models.PromoFixed, DiscountValue: 500, Currency: "SGD"}, 0, 0},
+        {"unknown type", models.Promotion{DiscountType: "bogo", DiscountValue: 500}, 1200, 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := Discount(&tt.promo, tt.amount); got != tt.want {
+                t.Errorf("Discount() = %d, want %d", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCheck(t *testing.T) {
+    now := time.Date(2025, time.June, 2, 12, 0, 0, 0, time.UTC)
+    later := now.Add(time.Hour)
+    limit := 10
+
+    promo := func(change func(*models.Promotion)) models.Promotion {
+        p := models.Promotion{
+            DiscountType:  models.PromoPercent,
+            DiscountValue: 10,
+            StartsAt:      now.Add(-time.Hour),
+            PerUserLimit:  1,
+            Active:        true,
+        }
+        if change != nil {
+            change(&p)
+        }
+        return p
+    }
+
+    tests := []struct {
+        name     string
+        promo    models.Promotion
+        usage    Usage
+        tier     string
+        currency string
+        want     error
+    }{
+        {"valid", promo(nil), Usage{}, tiers.Basic, "SGD", nil},
+        {"switched off", promo(func(p *models.Promotion) { p.Active = false }), Usage{}, tiers.Basic, "SGD", ErrInactive},
+        {"not started", promo(func(p *models.Promotion) { p.StartsAt = later }), Usage{}, tiers.Basic, "SGD", ErrInactive},
+        {"starts now", promo(func(p *models.Promotion) { p.StartsAt = now }), Usage{}, tiers.Basic, "SGD", nil},
+        {"expires now", promo(func(p *models.Promotion) { p.ExpiresAt = &now }), Usage{}, tiers.Basic, "SGD", ErrExpired},
+        {"expires later", promo(func(p *models.Promotion) { p.ExpiresAt = &later }), Usage{}, tiers.Basic, "SGD", nil},
+        {"below total limit", promo(func(p *models.Promotion) { p.MaxRedemptions = &limit }), Usage{Total: 9}, tiers.Basic, "SGD", nil},
+        {"total limit reached", promo(func(p *models.Promotion) { p.MaxRedemptions = &limit }), Usage{Total: 10}, tiers.Basic, "SGD", ErrExhausted},
+        {"used by someone else", promo(nil), Usage{Total: 5}, tiers.Basic, "SGD", nil},
+        {"per user limit reached", promo(nil), Usage{Total: 1, ByUser: 1}, tiers.Basic, "SGD", ErrUserLimit},
+        {"below per user limit", promo(func(p *models.Promotion) { p.PerUserLimit = 3 }), Usage{ByUser: 2}, tiers.Basic, "SGD", nil},
+        {"tier allowed", promo(func(p *models.Promotion) { p.Tiers = []string{tiers.Premium, tiers.VIP} }), Usage{}, tiers.VIP, "SGD", nil},
+        {"tier matched ignoring case", promo(func(p *models.Promotion) { p.Tiers = []string{"vip"} }), Usage{}, tiers.VIP, "SGD", nil},
+        {"tier not allowed", promo(func(p *models.Promotion) { p.Tiers = []string{tiers.Premium, tiers.VIP} }), Usage{}, tiers.Basic, "SGD", ErrTierNotAllowed},
+        {"first ride", promo(func(p *models.Promotion) { p.FirstRideOnly = true }), Usage{}, tiers.Basic, "SGD", nil},
+        {"not the first ride", promo(func(p *models.Promotion) { p.FirstRideOnly = true }), Usage{PriorTrips: 1}, tiers.Basic, "SGD", ErrNotFirstRide},
+        {"percent in any currency", promo(nil), Usage{}, tiers.Basic, "MYR", nil},
+        {"fixed in its currency", promo(func(p *models.Promotion) { p.DiscountType, p.Currency = models.PromoFixed, "SGD" }), Usage{}, tiers.Basic, "SGD", nil},
+        {"fixed in another currency", promo(func(p *models.Promotion) { p.DiscountType, p.Currency = models.PromoFixed, "SGD" }), Usage{}, tiers.Basic, "MYR", ErrWrongCurrency},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := Check(&tt.promo, tt.usage, tt.tier, tt.currency, now); got != tt.want {
+                t.Errorf("Check() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,226 @@
+// Path: services/vehicle-service/recurrence/rrule.go
+package recurrence
+
+import (
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Hard caps so one request can't book a car for the rest of the decade
+const (
+    MaxOccurrences = 100
+    MaxHorizon     = 365 * 24 * time.Hour
+)
+
+const (
+    Daily  = "DAILY"
+    Weekly = "WEEKLY"
+)
+
+var weekdays = map[string]time.Weekday{
+    "SU": time.Sunday,
+    "MO": time.Monday,
+    "TU": time.Tuesday,
+    "WE": time.Wednesday,
+    "TH": time.Thursday,
+    "FR": time.Friday,
+    "SA": time.Saturday,
+}
+
+// Rule is the subset of RFC 5545 RRULE we support: FREQ (DAILY or WEEKLY),
+// INTERVAL, COUNT, UNTIL, BYDAY (plain weekdays, no ordinals) and WKST.
+type Rule struct {
+    Freq      string
+    Interval  int
+    Count     int
+    Until     time.Time
+    ByDay     []time.Weekday
+    WeekStart time.Weekday // Monday unless WKST says otherwise
+
+    // floatingUntil is set when UNTIL had no UTC "Z" suffix. Its wall clock
+    // time is then read in the location of the series' start.
+    floatingUntil bool
+}
+
+// Parse reads an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;COUNT=20".
+// A leading "RRULE:" is accepted. Either COUNT or UNTIL is required.
+func Parse(s string) (Rule, error) {
+    rule := Rule{Interval: 1, WeekStart: time.Monday}
+    s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+    if s == "" {
+        return rule, errors.New("empty recurrence rule")
+    }
+
+    for _, part := range strings.Split(s, ";") {
+        kv := strings.SplitN(part, "=", 2)
+        if len(kv) != 2 {
+            return rule, fmt.Errorf("invalid rule part %q", part)
+        }
+        key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+        switch key {
+        case "FREQ":
+            if value != Daily && value != Weekly {
+                return rule, fmt.Errorf("unsupported FREQ %q, only DAILY and WEEKLY are supported", value)
+            }
+            rule.Freq = value
+        case "INTERVAL":
+            n, err := strconv.Atoi(value)
+            if err != nil || n < 1 {
+                return rule, fmt.Errorf("invalid INTERVAL %q", value)
+            }
+            rule.Interval = n
+        case "COUNT":
+            n, err := strconv.Atoi(value)
+            if err != nil || n < 1 {
+                return rule, fmt.Errorf("invalid COUNT %q", value)
+            }
+            rule.Count = n
+        case "UNTIL":
+            until, floating, err := parseUntil(value)
+            if err != nil {
+                return rule, err
+            }
+            rule.Until = until
+            rule.floatingUntil = floating
+        case "BYDAY":
+            for _, day := range strings.Split(value, ",") {
+                wd, ok := weekdays[day]
+                if !ok {
+                    return rule, fmt.Errorf("unsupported BYDAY value %q", day)
+                }
+                rule.ByDay = append(rule.ByDay, wd)
+            }
+        case "WKST":
+            wd, ok := weekdays[value]
+            if !ok {
+                return rule, fmt.Errorf("invalid WKST %q", value)
+            }
+            rule.WeekStart = wd
+        default:
+            return rule, fmt.Errorf("unsupported rule part %s", key)
+        }
+    }
+
+    if rule.Freq == "" {
+        return rule, errors.New("FREQ is required")
+    }
+    if rule.Count == 0 && rule.Until.IsZero() {
+        return rule, errors.New("either COUNT or UNTIL is required")
+    }
+    if rule.Count > 0 && !rule.Until.IsZero() {
+        return rule, errors.New("COUNT and UNTIL can't be combined")
+    }
+    if rule.Count > MaxOccurrences {
+        return rule, fmt.Errorf("COUNT can be at most %d", MaxOccurrences)
+    }
+
+    // Days are walked in week order, which starts on WeekStart
+    sort.Slice(rule.ByDay, func(i, j int) bool {
+        return rule.daysIntoWeek(rule.ByDay[i]) < rule.daysIntoWeek(rule.ByDay[j])
+    })
+    return rule, nil
+}
+
+// parseUntil reads a UTC, floating or date-only UNTIL. Only the UTC form is
+// an absolute time; floating reports whether the caller still has to place
+// the wall clock time in a location.
+func parseUntil(value string) (until time.Time, floating bool, err error) {
+    if t, err := time.Parse("20060102T150405Z", value); err == nil {
+        return t, false, nil
+    }
+    if t, err := time.Parse("20060102T150405", value); err == nil {
+        return t, true, nil
+    }
+    if t, err := time.Parse("20060102", value); err == nil {
+        // A date-only UNTIL includes that whole day
+        return t.Add(24*time.Hour - time.Second), true, nil
+    }
+    return time.Time{}, false, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// daysIntoWeek is how many days wd comes after the start of the week
+func (r Rule) daysIntoWeek(wd time.Weekday) int {
+    return (int(wd) - int(r.WeekStart) + 7) % 7
+}
+
+// Expand returns the start times of every occurrence, the first being start
+// itself when it matches the rule. Times keep start's location and wall clock
+// time, so a 08:00 booking stays at 08:00 across DST changes. A floating
+// UNTIL is read in start's location too.
+func (r Rule) Expand(start time.Time) []time.Time {
+    var occurrences []time.Time
+    horizon := start.Add(MaxHorizon)
+
+    until := r.Until
+    if r.floatingUntil {
+        until = time.Date(until.Year(), until.Month(), until.Day(),
+            until.Hour(), until.Minute(), until.Second(), 0, start.Location())
+    }
+
+    matches := func(t time.Time) bool {
+        if len(r.ByDay) == 0 {
+            return true
+        }
+        for _, wd := range r.ByDay {
+            if t.Weekday() == wd {
+                return true
+            }
+        }
+        return false
+    }
+
+    done := func(t time.Time) bool {
+        if r.Count > 0 && len(occurrences) >= r.Count {
+            return true
+        }
+        if !until.IsZero() && t.After(until) {
+            return true
+        }
+        return len(occurrences) >= MaxOccurrences || t.After(horizon)
+    }
+
+    switch r.Freq {
+    case Daily:
+        for i := 0; ; i++ {
+            t := start.AddDate(0, 0, i*r.Interval)
+            if done(t) {
+                break
+            }
+            if matches(t) {
+                occurrences = append(occurrences, t)
+            }
+        }
+    case Weekly:
+        byDay := r.ByDay
+        if len(byDay) == 0 {
+            byDay = []time.Weekday{start.Weekday()}
+        }
+        // Walk week by week from the first day of start's week, so INTERVAL
+        // counts weeks as WKST defines them
+        weekStart := start.AddDate(0, 0, -r.daysIntoWeek(start.Weekday()))
+        for week := 0; ; week += r.Interval {
+            stop := false
+            for _, wd := range byDay {
+                t := weekStart.AddDate(0, 0, week*7+r.daysIntoWeek(wd))
+                if t.Before(start) {
+                    continue
+                }
+                if done(t) {
+                    stop = true
+                    break
+                }
+                occurrences = append(occurrences, t)
+            }
+            if stop {
+                break
+            }
+        }
+    }
+
+    return occurrences
+}
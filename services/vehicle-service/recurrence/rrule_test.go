@@ -0,0 +1,136 @@
+// Path: services/vehicle-service/recurrence/rrule_test.go
+package recurrence
+
+import (
+    "testing"
+    "time"
+)
+
+func TestParse(t *testing.T) {
+    tests := []struct {
+        name    string
+        rule    string
+        wantErr bool
+    }{
+        {"weekly with days", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;COUNT=20", false},
+        {"rrule prefix", "RRULE:FREQ=DAILY;COUNT=2", false},
+        {"lower case", "freq=daily;until=20250601", false},
+        {"week start", "FREQ=WEEKLY;WKST=SU;COUNT=2", false},
+        {"empty", "", true},
+        {"no freq", "COUNT=2", true},
+        {"monthly", "FREQ=MONTHLY;COUNT=2", true},
+        {"no end", "FREQ=DAILY", true},
+        {"count and until", "FREQ=DAILY;COUNT=2;UNTIL=20250601T000000Z", true},
+        {"too many", "FREQ=DAILY;COUNT=101", true},
+        {"zero interval", "FREQ=DAILY;INTERVAL=0;COUNT=2", true},
+        {"ordinal day", "FREQ=WEEKLY;BYDAY=1MO;COUNT=2", true},
+        {"bad week start", "FREQ=WEEKLY;WKST=XX;COUNT=2", true},
+        {"bad until", "FREQ=DAILY;UNTIL=tomorrow", true},
+        {"unknown part", "FREQ=DAILY;COUNT=2;BYMONTH=1", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            _, err := Parse(tt.rule)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("Parse(%q) error = %v, want error %v", tt.rule, err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestExpand(t *testing.T) {
+    sgt := time.FixedZone("SGT", 8*60*60)
+    at := func(day int, hour int) time.Time {
+        return time.Date(2025, time.June, day, hour, 0, 0, 0, sgt)
+    }
+
+    tests := []struct {
+        name  string
+        rule  string
+        start time.Time
+        want  []time.Time
+    }{
+        {
+            name:  "daily",
+            rule:  "FREQ=DAILY;COUNT=3",
+            start: at(2, 9),
+            want:  []time.Time{at(2, 9), at(3, 9), at(4, 9)},
+        },
+        {
+            name:  "daily on weekdays only",
+            rule:  "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=3",
+            start: at(5, 9), // Thursday
+            want:  []time.Time{at(5, 9), at(6, 9), at(9, 9)},
+        },
+        {
+            name:  "weekly on the start's weekday",
+            rule:  "FREQ=WEEKLY;COUNT=3",
+            start: at(2, 9),
+            want:  []time.Time{at(2, 9), at(9, 9), at(16, 9)},
+        },
+        {
+            // Weeks run Monday to Sunday, so the Sunday start is the end of
+            // the first week and the next week counted is the one after
+            name:  "fortnightly weeks start on Monday",
+            rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU;COUNT=4",
+            start: at(1, 9), // Sunday
+            want:  []time.Time{at(1, 9), at(9, 9), at(15, 9), at(23, 9)},
+        },
+        {
+            name:  "fortnightly weeks start on Sunday",
+            rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU;WKST=SU;COUNT=4",
+            start: at(1, 9),
+            want:  []time.Time{at(1, 9), at(2, 9), at(15, 9), at(16, 9)},
+        },
+        {
+            name:  "floating until is local time",
+            rule:  "FREQ=DAILY;UNTIL=20250604T080000",
+            start: at(2, 9),
+            want:  []time.Time{at(2, 9), at(3, 9)},
+        },
+        {
+            name:  "utc until",
+            rule:  "FREQ=DAILY;UNTIL=20250604T010000Z", // 09:00 SGT
+            start: at(2, 9),
+            want:  []time.Time{at(2, 9), at(3, 9), at(4, 9)},
+        },
+        {
+            name:  "date until includes the whole day",
+            rule:  "FREQ=DAILY;UNTIL=20250604",
+            start: at(2, 23),
+            want:  []time.Time{at(2, 23), at(3, 23), at(4, 23)},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rule, err := Parse(tt.rule)
+            if err != nil {
+                t.Fatalf("Parse(%q): %v", tt.rule, err)
+            }
+
+            got := rule.Expand(tt.start)
+            if len(got) != len(tt.want) {
+                t.Fatalf("Expand() = %v, want %v", got, tt.want)
+            }
+            for i := range got {
+                if !got[i].Equal(tt.want[i]) {
+                    t.Errorf("occurrence %d = %v, want %v", i, got[i], tt.want[i])
+                }
+            }
+        })
+    }
+}
+
+func TestExpandCaps(t *testing.T) {
+    rule, err := Parse("FREQ=DAILY;UNTIL=20991231T000000Z")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    got := rule.Expand(time.Date(2025, time.June, 2, 9, 0, 0, 0, time.UTC))
+    if len(got) != MaxOccurrences {
+        t.Errorf("Expand() returned %d occurrences, want the cap of %d", len(got), MaxOccurrences)
+    }
+}
@@ -0,0 +1,43 @@
+// Path: services/vehicle-service/referrals/referrals.go
+package referrals
+
+import (
+    "strings"
+
+    "vehicle-service/models"
+)
+
+// SelfReferralReason explains why a referral looks like the referrer
+// referring themselves, or returns "" if it doesn't. The user service
+// checks the details given at sign-up; this runs again at payout on the
+// current ones, where phone numbers only count once verified, so they are
+// both in E.164 form and really belong to the user.
+func SelfReferralReason(referrer, referee models.ReferralContact) string {
+    if normalizeEmail(referrer.Email) == normalizeEmail(referee.Email) {
+        return "email matches the referrer's"
+    }
+    if referrer.VerifiedPhone != "" && referrer.VerifiedPhone == referee.VerifiedPhone {
+        return "phone number matches the referrer's"
+    }
+    return ""
+}
+
+// normalizeEmail reduces an address to the mailbox it is delivered to:
+// lower-cased, without a +tag, and for Gmail without dots
+func normalizeEmail(email string) string {
+    email = strings.ToLower(strings.TrimSpace(email))
+    at := strings.LastIndex(email, "@")
+    if at < 0 {
+        return email
+    }
+
+    local, domain := email[:at], email[at+1:]
+    if plus := strings.Index(local, "+"); plus >= 0 {
+        local = local[:plus]
+    }
+    if domain == "gmail.com" || domain == "googlemail.com" {
+        local = strings.ReplaceAll(local, ".", "")
+        domain = "gmail.com"
+    }
+    return local + "@" + domain
+}
@@ -0,0 +1,131 @@
+// Path: services/vehicle-service/refunds/refunds.go
+package refunds
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/payments"
+    "vehicle-service/repository"
+)
+
+// ErrInvalidMethod means a refund was asked for without a card or wallet
+// to pay it to
+var ErrInvalidMethod = errors.New("refund method must be card or wallet")
+
+// Pending refunds older than this are taken to have been interrupted
+const retryPendingAfter = 5 * time.Minute
+
+// Service resolves invoice disputes and pays refunds back out through the
+// payment provider or onto the user's wallet
+type Service struct {
+    Repo        *repository.DisputeRepository
+    BillingRepo *repository.BillingRepository
+    PaymentRepo *repository.PaymentRepository
+    Payments    payments.PaymentProvider
+}
+
+func NewService(dRepo *repository.DisputeRepository, bRepo *repository.BillingRepository, payRepo *repository.PaymentRepository, provider payments.PaymentProvider) *Service {
+    return &Service{
+        Repo:        dRepo,
+        BillingRepo: bRepo,
+        PaymentRepo: payRepo,
+        Payments:    provider,
+    }
+}
+
+// Approve resolves the dispute in the user's favour with a refund of
+// amountCents, which may be 0. The refund is recorded as pending together
+// with the dispute's claim, so two agents can't both pay it out, and only
+// then is the money moved. A dispute whose refund was interrupted part way
+// is finished with the refund already recorded instead of a new one.
+func (s *Service) Approve(disputeID int, staffID int, amountCents int64, method string, note string) (*models.Dispute, error) {
+    if amountCents > 0 && method != models.RefundToCard && method != models.RefundToWallet {
+        return nil, ErrInvalidMethod
+    }
+
+    dispute, err := s.Repo.GetDispute(disputeID, 0)
+    if err != nil {
+        return nil, err
+    }
+
+    switch {
+    case dispute.Status == models.DisputeRefunding:
+        refund, err := s.Repo.GetPendingRefund(disputeID)
+        if err != nil {
+            return nil, err
+        }
+        if err := s.payOut(refund); err != nil {
+            return nil, err
+        }
+    case amountCents == 0:
+        if err := s.Repo.Approve(disputeID, staffID, note); err != nil {
+            return nil, err
+        }
+    default:
+        refund := &models.Refund{
+            DisputeID:   dispute.ID,
+            InvoiceID:   dispute.InvoiceID,
+            UserID:      dispute.UserID,
+            AmountCents: amountCents,
+            Method:      method,
+            CreatedBy:   staffID,
+        }
+        if err := s.Repo.StartRefund(dispute, refund, note); err != nil {
+            return nil, err
+        }
+        if err := s.payOut(refund); err != nil {
+            return nil, err
+        }
+    }
+
+    return s.Repo.GetDispute(disputeID, 0)
+}
+
+// RetryPendingRefunds finishes refunds that were recorded but never
+// completed, e.g. because the service stopped between paying and recording
+func (s *Service) RetryPendingRefunds() error {
+    pending, err := s.Repo.GetPendingRefunds(time.Now().Add(-retryPendingAfter))
+    if err != nil {
+        return err
+    }
+
+    for i := range pending {
+        if err := s.payOut(&pending[i]); err != nil {
+            log.Printf("Refunds: failed to finish refund %d for dispute %d: %v", pending[i].ID, pending[i].DisputeID, err)
+        }
+    }
+    return nil
+}
+
+// payOut moves a pending refund's money and completes it. Card refunds are
+// sent with the refund's id as idempotency key, so paying out the same
+// refund again returns the provider's first answer instead of refunding
+// twice. Wallet refunds are credited when the refund is completed.
+func (s *Service) payOut(refund *models.Refund) error {
+    var reference string
+    if refund.Method == models.RefundToCard {
+        invoice, err := s.BillingRepo.GetInvoice(refund.InvoiceID, refund.UserID)
+        if err != nil {
+            return err
+        }
+        payment, err := s.PaymentRepo.GetPaymentByReservation(invoice.ReservationID)
+        if err != nil {
+            return err
+        }
+
+        result, err := s.Payments.Refund(payment.Reference, refund.AmountCents, fmt.Sprintf("refund-%d", refund.ID))
+        if err != nil {
+            if err := s.Repo.FailRefund(refund, err.Error()); err != nil {
+                log.Printf("Refunds: failed to record failed refund %d: %v", refund.ID, err)
+            }
+            return err
+        }
+        reference = result.Reference
+    }
+
+    return s.Repo.CompleteRefund(refund, reference)
+}
@@ -0,0 +1,82 @@
+// Path: services/vehicle-service/repository/availability_repository.go
+package repository
+
+import (
+    "database/sql"
+    "time"
+
+    "vehicle-service/models"
+)
+
+type AvailabilityRepository struct {
+    DB *sql.DB
+}
+
+func NewAvailabilityRepository(db *sql.DB) *AvailabilityRepository {
+    return &AvailabilityRepository{DB: db}
+}
+
+// GetTurnaroundMinutes returns the buffer currently kept around the vehicle's
+// bookings, which depends on its type and charge level.
+func (r *AvailabilityRepository) GetTurnaroundMinutes(vehicleID int) (int, error) {
+    var minutes int
+    err := r.DB.QueryRow(`
+        SELECT `+turnaroundMinutes+`
+        FROM vehicles v
+        LEFT JOIN turnaround_buffers tb ON tb.vehicle_type = v.type
+        WHERE v.id = $1
+    `, vehicleID).Scan(&minutes)
+    if err == sql.ErrNoRows {
+        return 0, ErrVehicleNotFound
+    }
+    return minutes, err
+}
+
+// GetBusyPeriods lists everything that blocks the vehicle for userID and
+// reaches into [from, to) once the buffer is added. The user's own holds
+// are left out since they never block the user. Periods are not clipped.
+func (r *AvailabilityRepository) GetBusyPeriods(vehicleID int, from, to time.Time, userID int, bufferMinutes int) ([]models.AvailabilityInterval, error) {
+    query := `
+        SELECT 'reservation', start_time, end_time, user_id = $4 FROM reservations
+        WHERE vehicle_id = $1
+        AND status = 'Active'
+        AND start_time - make_interval(mins => $5) < $3
+        AND end_time + make_interval(mins => $5) > $2
+
+        UNION ALL
+
+        SELECT 'hold', start_time, end_time, false FROM booking_holds
+        WHERE vehicle_id = $1
+        AND status = 'Held'
+        AND expires_at > $6
+        AND user_id <> $4
+        AND start_time - make_interval(mins => $5) < $3
+        AND end_time + make_interval(mins => $5) > $2
+
+        UNION ALL
+
+        SELECT 'maintenance', start_time, end_time, false FROM maintenance_windows
+        WHERE vehicle_id = $1
+        AND start_time < $3
+        AND end_time > $2
+
+        ORDER BY 2
+    `
+
+    rows, err := r.DB.Query(query, vehicleID, from, to, userID, bufferMinutes, time.Now())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var periods []models.AvailabilityInterval
+    for rows.Next() {
+        var p models.AvailabilityInterval
+        if err := rows.Scan(&p.Kind, &p.StartTime, &p.EndTime, &p.Own); err != nil {
+            return nil, err
+        }
+        periods = append(periods, p)
+    }
+
+    return periods, nil
+}
@@ -0,0 +1,473 @@
+// Path: services/vehicle-service/repository/billing_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/pricing"
+)
+
+var ErrInvoiceNotFound = errors.New("invoice not found or unauthorized")
+
+type BillingRepository struct {
+    DB *sql.DB
+}
+
+func NewBillingRepository(db *sql.DB) *BillingRepository {
+    return &BillingRepository{DB: db}
+}
+
+const invoiceColumns = `
+    id, user_id, reservation_id, rate_card_id, organization_id, cost_center_id, status, currency,
+    subtotal_cents, tax_name, tax_rate_bps, tax_cents, total_cents, balance_due_cents, created_at,
+    updated_at, issued_at
+`
+
+const rateCardColumns = `
+    id, vehicle_type, version, currency, hourly_rate_cents, per_km_cents, included_km,
+    energy_cents_per_percent, effective_from, created_at
+`
+
+const taxRateColumns = `
+    id, region, currency, name, rate_bps, effective_from, created_at
+`
+
+// CreateInvoice opens an invoice priced with the given rate card, or with
+// the built-in fallback prices when rateCardID is 0, and taxed at the given
+// rate in its currency
+func (r *BillingRepository) CreateInvoice(userID int, reservationID int, rateCardID int, tax models.TaxRate, lines []models.InvoiceLine) (*models.Invoice, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    now := time.Now()
+    invoice := &models.Invoice{
+        UserID:        userID,
+        ReservationID: reservationID,
+        Status:        models.InvoiceOpen,
+        CreatedAt:     now,
+        UpdatedAt:     now,
+    }
+
+    var cardID sql.NullInt64
+    if rateCardID != 0 {
+        cardID = sql.NullInt64{Int64: int64(rateCardID), Valid: true}
+    }
+
+    // Business bookings are billed to the organization they were made for
+    err = tx.QueryRow(`
+        INSERT INTO invoices (user_id, reservation_id, rate_card_id, organization_id, cost_center_id, status,
+                              currency, tax_name, tax_rate_bps, subtotal_cents, tax_cents, total_cents,
+                              created_at, updated_at)
+        SELECT $1, $2, $3, r.organization_id, r.cost_center_id, $4, $5, $6, $7, 0, 0, 0, $8, $8
+        FROM reservations r WHERE r.id = $2
+        RETURNING id
+    `, userID, reservationID, cardID, invoice.Status, tax.Currency, tax.Name, tax.RateBps, now).Scan(&invoice.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := insertLines(tx, invoice.ID, lines); err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    return r.getInvoice(r.DB.QueryRow("SELECT "+invoiceColumns+" FROM invoices WHERE id = $1", invoice.ID))
+}
+
+// AddLines appends lines to an invoice and updates its totals
+func (r *BillingRepository) AddLines(invoiceID int, lines []models.InvoiceLine) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := insertLines(tx, invoiceID, lines); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// IssueInvoice freezes an invoice; no more lines are expected after this
+func (r *BillingRepository) IssueInvoice(invoiceID int) error {
+    now := time.Now()
+    _, err := r.DB.Exec(
+        "UPDATE invoices SET status = 'Issued', issued_at = $1, updated_at = $1 WHERE id = $2 AND status = 'Open'",
+        now, invoiceID,
+    )
+    return err
+}
+
+// SetBalanceDue records what is still owed on an issued invoice after
+// everything that could be collected was
+func (r *BillingRepository) SetBalanceDue(invoiceID int, amountCents int64) error {
+    _, err := r.DB.Exec(
+        "UPDATE invoices SET balance_due_cents = $1, updated_at = $2 WHERE id = $3",
+        amountCents, time.Now(), invoiceID,
+    )
+    return err
+}
+
+func (r *BillingRepository) GetInvoice(id int, userID int) (*models.Invoice, error) {
+    return r.getInvoice(r.DB.QueryRow(
+        "SELECT "+invoiceColumns+" FROM invoices WHERE id = $1 AND user_id = $2",
+        id, userID,
+    ))
+}
+
+func (r *BillingRepository) GetInvoiceByReservation(reservationID int) (*models.Invoice, error) {
+    return r.getInvoice(r.DB.QueryRow(
+        "SELECT "+invoiceColumns+" FROM invoices WHERE reservation_id = $1",
+        reservationID,
+    ))
+}
+
+func (r *BillingRepository) GetUserInvoices(userID int) ([]models.Invoice, error) {
+    rows, err := r.DB.Query(
+        "SELECT "+invoiceColumns+" FROM invoices WHERE user_id = $1 ORDER BY created_at DESC",
+        userID,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var invoices []models.Invoice
+    for rows.Next() {
+        invoice, err := scanInvoice(rows)
+        if err != nil {
+            return nil, err
+        }
+        invoices = append(invoices, *invoice)
+    }
+
+    return invoices, nil
+}
+
+// GetUnsettledInvoices returns invoices issued before the given time whose
+// payment or deposit is still only authorized, i.e. settling them failed.
+// A deposit only counts once its damage review is over: the invoice was
+// issued before reviewedBefore, damage has been charged or the vehicle was
+// never picked up.
+func (r *BillingRepository) GetUnsettledInvoices(issuedBefore time.Time, reviewedBefore time.Time) ([]models.Invoice, error) {
+    rows, err := r.DB.Query(`
+        SELECT `+invoiceColumns+` FROM invoices i
+        WHERE i.status = 'Issued' AND i.issued_at < $1
+          AND (EXISTS (SELECT 1 FROM payments p WHERE p.reservation_id = i.reservation_id AND p.status = 'Authorized')
+               OR (EXISTS (SELECT 1 FROM deposits d WHERE d.reservation_id = i.reservation_id AND d.status = 'Authorized')
+                   AND (i.issued_at < $2
+                        OR NOT EXISTS (SELECT 1 FROM reservations r WHERE r.id = i.reservation_id AND r.picked_up_at IS NOT NULL)
+                        OR EXISTS (SELECT 1 FROM invoice_lines l WHERE l.invoice_id = i.id AND l.kind = $3))))
+        ORDER BY i.issued_at
+        LIMIT 100
+    `, issuedBefore, reviewedBefore, models.LineDamage)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var invoices []models.Invoice
+    for rows.Next() {
+        invoice, err := scanInvoice(rows)
+        if err != nil {
+            return nil, err
+        }
+        invoices = append(invoices, *invoice)
+    }
+
+    return invoices, nil
+}
+
+func (r *BillingRepository) GetCancellationPolicy(tier string) (*models.CancellationPolicy, error) {
+    var policy models.CancellationPolicy
+    err := r.DB.QueryRow(`
+        SELECT tier, free_until_hours, late_cancel_fee_percent, no_show_fee_percent
+        FROM cancellation_policies WHERE tier = $1
+    `, tier).Scan(&policy.Tier, &policy.FreeUntilHours, &policy.LateCancelFeePercent, &policy.NoShowFeePercent)
+    if err != nil {
+        return nil, err
+    }
+    return &policy, nil
+}
+
+// GetDepositRule returns sql.ErrNoRows when the vehicle type and tier take
+// no deposit in the currency
+func (r *BillingRepository) GetDepositRule(vehicleType string, tier string, currency string) (*models.DepositRule, error) {
+    var rule models.DepositRule
+    err := r.DB.QueryRow(`
+        SELECT vehicle_type, tier, currency, amount_cents
+        FROM deposit_rules WHERE vehicle_type = $1 AND tier = $2 AND currency = $3
+    `, vehicleType, tier, currency).Scan(&rule.VehicleType, &rule.Tier, &rule.Currency, &rule.AmountCents)
+    if err != nil {
+        return nil, err
+    }
+    return &rule, nil
+}
+
+func (r *BillingRepository) GetDepositRules() ([]models.DepositRule, error) {
+    rows, err := r.DB.Query("SELECT vehicle_type, tier, currency, amount_cents FROM deposit_rules ORDER BY vehicle_type, tier, currency")
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    rules := []models.DepositRule{}
+    for rows.Next() {
+        var rule models.DepositRule
+        if err := rows.Scan(&rule.VehicleType, &rule.Tier, &rule.Currency, &rule.AmountCents); err != nil {
+            return nil, err
+        }
+        rules = append(rules, rule)
+    }
+
+    return rules, nil
+}
+
+// SetDepositRule creates or changes the deposit for a vehicle type and tier.
+// Deposits already held keep their amount.
+func (r *BillingRepository) SetDepositRule(rule *models.DepositRule, updatedBy int) error {
+    _, err := r.DB.Exec(`
+        INSERT INTO deposit_rules (vehicle_type, tier, currency, amount_cents, updated_by, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (vehicle_type, tier, currency)
+        DO UPDATE SET amount_cents = EXCLUDED.amount_cents, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+    `, rule.VehicleType, rule.Tier, rule.Currency, rule.AmountCents, updatedBy, time.Now())
+    return err
+}
+
+// GetCurrentRateCard returns the newest card for the vehicle type in the
+// currency that is in effect at the given time
+func (r *BillingRepository) GetCurrentRateCard(vehicleType string, currency string, at time.Time) (*models.RateCard, error) {
+    return scanRateCard(r.DB.QueryRow(`
+        SELECT `+rateCardColumns+` FROM rate_cards
+        WHERE vehicle_type = $1 AND currency = $2 AND effective_from <= $3
+        ORDER BY version DESC LIMIT 1
+    `, vehicleType, currency, at))
+}
+
+func (r *BillingRepository) GetRateCard(id int) (*models.RateCard, error) {
+    return scanRateCard(r.DB.QueryRow("SELECT "+rateCardColumns+" FROM rate_cards WHERE id = $1", id))
+}
+
+// GetRateCards lists every version of every card, newest first
+func (r *BillingRepository) GetRateCards() ([]models.RateCard, error) {
+    rows, err := r.DB.Query("SELECT " + rateCardColumns + " FROM rate_cards ORDER BY vehicle_type, version DESC")
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    cards := []models.RateCard{}
+    for rows.Next() {
+        card, err := scanRateCard(rows)
+        if err != nil {
+            return nil, err
+        }
+        cards = append(cards, *card)
+    }
+
+    return cards, nil
+}
+
+// CreateRateCard publishes the next version of the vehicle type's card.
+// Versions are counted per vehicle type across all currencies.
+func (r *BillingRepository) CreateRateCard(card *models.RateCard, createdBy int) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    // Serialize publishing so two new cards can't get the same version
+    if _, err := tx.Exec("LOCK TABLE rate_cards IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+        return err
+    }
+
+    err = tx.QueryRow(
+        "SELECT COALESCE(MAX(version), 0) + 1 FROM rate_cards WHERE vehicle_type = $1",
+        card.VehicleType,
+    ).Scan(&card.Version)
+    if err != nil {
+        return err
+    }
+
+    card.CreatedAt = time.Now()
+    err = tx.QueryRow(`
+        INSERT INTO rate_cards (vehicle_type, version, currency, hourly_rate_cents, per_km_cents, included_km,
+                                energy_cents_per_percent, effective_from, created_by, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id
+    `, card.VehicleType, card.Version, card.Currency, card.HourlyRateCents, card.PerKmCents, card.IncludedKm,
+        card.EnergyCentsPerPercent, card.EffectiveFrom, createdBy, card.CreatedAt,
+    ).Scan(&card.ID)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// GetTaxRate returns the rate in effect at the given time for the region
+// the vehicle is in
+func (r *BillingRepository) GetTaxRate(vehicleID int, at time.Time) (*models.TaxRate, error) {
+    return scanTaxRate(r.DB.QueryRow(`
+        SELECT t.id, t.region, t.currency, t.name, t.rate_bps, t.effective_from, t.created_at
+        FROM tax_rates t
+        JOIN vehicles v ON v.region = t.region
+        WHERE v.id = $1 AND t.effective_from <= $2
+        ORDER BY t.effective_from DESC LIMIT 1
+    `, vehicleID, at))
+}
+
+// GetTaxRates lists every rate of every region, newest first
+func (r *BillingRepository) GetTaxRates() ([]models.TaxRate, error) {
+    rows, err := r.DB.Query("SELECT " + taxRateColumns + " FROM tax_rates ORDER BY region, effective_from DESC")
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    rates := []models.TaxRate{}
+    for rows.Next() {
+        rate, err := scanTaxRate(rows)
+        if err != nil {
+            return nil, err
+        }
+        rates = append(rates, *rate)
+    }
+
+    return rates, nil
+}
+
+// CreateTaxRate schedules a region's rate from its effective time. Open
+// invoices keep the rate they were opened with.
+func (r *BillingRepository) CreateTaxRate(rate *models.TaxRate, createdBy int) error {
+    rate.CreatedAt = time.Now()
+    return r.DB.QueryRow(`
+        INSERT INTO tax_rates (region, currency, name, rate_bps, effective_from, created_by, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `, rate.Region, rate.Currency, rate.Name, rate.RateBps, rate.EffectiveFrom, createdBy, rate.CreatedAt,
+    ).Scan(&rate.ID)
+}
+
+func (r *BillingRepository) getInvoice(row *sql.Row) (*models.Invoice, error) {
+    invoice, err := scanInvoice(row)
+    if err == sql.ErrNoRows {
+        return nil, ErrInvoiceNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err := r.DB.Query(`
+        SELECT id, invoice_id, kind, description, amount_cents, created_at
+        FROM invoice_lines WHERE invoice_id = $1 ORDER BY id
+    `, invoice.ID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    invoice.Lines = []models.InvoiceLine{}
+    for rows.Next() {
+        var line models.InvoiceLine
+        err := rows.Scan(&line.ID, &line.InvoiceID, &line.Kind, &line.Description, &line.AmountCents, &line.CreatedAt)
+        if err != nil {
+            return nil, err
+        }
+        invoice.Lines = append(invoice.Lines, line)
+    }
+
+    return invoice, nil
+}
+
+func insertLines(tx *sql.Tx, invoiceID int, lines []models.InvoiceLine) error {
+    now := time.Now()
+    for i, line := range lines {
+        err := tx.QueryRow(`
+            INSERT INTO invoice_lines (invoice_id, kind, description, amount_cents, created_at)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id
+        `, invoiceID, line.Kind, line.Description, line.AmountCents, now).Scan(&lines[i].ID)
+        if err != nil {
+            return err
+        }
+    }
+
+    var subtotal int64
+    var rateBps int
+    err := tx.QueryRow(`
+        SELECT (SELECT COALESCE(SUM(amount_cents), 0) FROM invoice_lines WHERE invoice_id = $1), tax_rate_bps
+        FROM invoices WHERE id = $1
+        FOR UPDATE
+    `, invoiceID).Scan(&subtotal, &rateBps)
+    if err != nil {
+        return err
+    }
+
+    tax := pricing.Tax(subtotal, rateBps)
+    _, err = tx.Exec(`
+        UPDATE invoices
+        SET subtotal_cents = $2, tax_cents = $3, total_cents = $4, updated_at = $5
+        WHERE id = $1
+    `, invoiceID, subtotal, tax, subtotal+tax, now)
+    return err
+}
+
+func scanInvoice(row rowScanner) (*models.Invoice, error) {
+    var invoice models.Invoice
+    var rateCardID, organizationID, costCenterID sql.NullInt64
+    var issuedAt sql.NullTime
+
+    err := row.Scan(
+        &invoice.ID, &invoice.UserID, &invoice.ReservationID, &rateCardID, &organizationID, &costCenterID,
+        &invoice.Status, &invoice.Currency, &invoice.SubtotalCents, &invoice.TaxName, &invoice.TaxRateBps,
+        &invoice.TaxCents, &invoice.TotalCents, &invoice.BalanceDueCents, &invoice.CreatedAt, &invoice.UpdatedAt,
+        &issuedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    invoice.RateCardID = nullInt(rateCardID)
+    invoice.OrganizationID = nullInt(organizationID)
+    invoice.CostCenterID = nullInt(costCenterID)
+
+    if issuedAt.Valid {
+        invoice.IssuedAt = &issuedAt.Time
+    }
+
+    return &invoice, nil
+}
+
+func scanRateCard(row rowScanner) (*models.RateCard, error) {
+    var card models.RateCard
+    err := row.Scan(
+        &card.ID, &card.VehicleType, &card.Version, &card.Currency, &card.HourlyRateCents, &card.PerKmCents, &card.IncludedKm,
+        &card.EnergyCentsPerPercent, &card.EffectiveFrom, &card.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return &card, nil
+}
+
+func scanTaxRate(row rowScanner) (*models.TaxRate, error) {
+    var rate models.TaxRate
+    err := row.Scan(&rate.ID, &rate.Region, &rate.Currency, &rate.Name, &rate.RateBps, &rate.EffectiveFrom, &rate.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &rate, nil
+}
@@ -0,0 +1,61 @@
+// Path: services/vehicle-service/repository/calendar_repository.go
+package repository
+
+import (
+    "crypto/rand"
+    "database/sql"
+    "encoding/hex"
+    "time"
+)
+
+type CalendarRepository struct {
+    DB *sql.DB
+}
+
+func NewCalendarRepository(db *sql.DB) *CalendarRepository {
+    return &CalendarRepository{DB: db}
+}
+
+// GetFeedToken returns the user's secret feed token, creating one on first use
+func (r *CalendarRepository) GetFeedToken(userID int) (string, error) {
+    var token string
+    err := r.DB.QueryRow("SELECT token FROM calendar_feed_tokens WHERE user_id = $1", userID).Scan(&token)
+    if err == sql.ErrNoRows {
+        return r.RotateFeedToken(userID)
+    }
+    return token, err
+}
+
+// RotateFeedToken replaces the token, invalidating any previously shared URL
+func (r *CalendarRepository) RotateFeedToken(userID int) (string, error) {
+    token, err := newFeedToken()
+    if err != nil {
+        return "", err
+    }
+
+    query := `
+        INSERT INTO calendar_feed_tokens (user_id, token, created_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, created_at = EXCLUDED.created_at
+    `
+    _, err = r.DB.Exec(query, userID, token, time.Now())
+    if err != nil {
+        return "", err
+    }
+
+    return token, nil
+}
+
+func (r *CalendarRepository) GetUserIDByFeedToken(token string) (int, error) {
+    var userID int
+    err := r.DB.QueryRow("SELECT user_id FROM calendar_feed_tokens WHERE token = $1", token).Scan(&userID)
+    return userID, err
+}
+
+func newFeedToken() (string, error) {
+    buf := make([]byte, 24)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,206 @@
+// Path: services/vehicle-service/repository/command_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "vehicle-service/models"
+)
+
+const (
+    // How long a vehicle has to pick up and acknowledge a command
+    commandTimeout = 30 * time.Second
+    // Lets the holder unlock a little early and lock after a slightly late return
+    commandWindowGrace = 15 * time.Minute
+)
+
+var (
+    ErrReservationNotFound = errors.New("reservation not found or unauthorized")
+    ErrOutsideWindow       = errors.New("reservation is not in its active window")
+    ErrCommandNotFound     = errors.New("command not found")
+)
+
+type CommandRepository struct {
+    DB *sql.DB
+}
+
+func NewCommandRepository(db *sql.DB) *CommandRepository {
+    return &CommandRepository{DB: db}
+}
+
+// QueueCommand queues a lock/unlock for the vehicle on the given reservation.
+// Only the reservation holder may do this, and only around the booked window.
+func (r *CommandRepository) QueueCommand(reservationID int, userID int, command string) (*models.VehicleCommand, error) {
+    var vehicleID int
+    var status string
+    var startTime, endTime time.Time
+    err := r.DB.QueryRow(
+        "SELECT vehicle_id, status, start_time, end_time FROM reservations WHERE id = $1 AND user_id = $2",
+        reservationID, userID,
+    ).Scan(&vehicleID, &status, &startTime, &endTime)
+    if err == sql.ErrNoRows {
+        return nil, ErrReservationNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    if status != "Active" || now.Before(startTime.Add(-commandWindowGrace)) || now.After(endTime.Add(commandWindowGrace)) {
+        return nil, ErrOutsideWindow
+    }
+
+    cmd := &models.VehicleCommand{
+        VehicleID:     vehicleID,
+        ReservationID: reservationID,
+        UserID:        userID,
+        Command:       command,
+        Status:        models.CommandPending,
+        CreatedAt:     now,
+        ExpiresAt:     now.Add(commandTimeout),
+    }
+
+    query := `
+        INSERT INTO vehicle_commands (vehicle_id, reservation_id, user_id, command, status, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+    err = r.DB.QueryRow(
+        query,
+        cmd.VehicleID,
+        cmd.ReservationID,
+        cmd.UserID,
+        cmd.Command,
+        cmd.Status,
+        cmd.CreatedAt,
+        cmd.ExpiresAt,
+    ).Scan(&cmd.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    return cmd, nil
+}
+
+func (r *CommandRepository) GetCommand(id int, userID int) (*models.VehicleCommand, error) {
+    query := `
+        SELECT id, vehicle_id, reservation_id, user_id, command, status, error_message,
+               created_at, sent_at, acknowledged_at, expires_at
+        FROM vehicle_commands
+        WHERE id = $1 AND user_id = $2
+    `
+
+    cmd, err := scanCommand(r.DB.QueryRow(query, id, userID))
+    if err == sql.ErrNoRows {
+        return nil, ErrCommandNotFound
+    }
+    return cmd, err
+}
+
+// NextCommand hands the oldest pending command for a vehicle to its agent and
+// marks it as sent. Returns nil when the queue is empty.
+func (r *CommandRepository) NextCommand(vehicleID int) (*models.VehicleCommand, error) {
+    query := `
+        UPDATE vehicle_commands
+        SET status = 'Sent', sent_at = $2
+        WHERE id = (
+            SELECT id FROM vehicle_commands
+            WHERE vehicle_id = $1 AND status = 'Pending' AND expires_at > $2
+            ORDER BY created_at
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, vehicle_id, reservation_id, user_id, command, status, error_message,
+                  created_at, sent_at, acknowledged_at, expires_at
+    `
+
+    cmd, err := scanCommand(r.DB.QueryRow(query, vehicleID, time.Now()))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    return cmd, err
+}
+
+// AcknowledgeCommand records the agent's result. Acks that arrive after the
+// command timed out are rejected so the user never sees a stale success.
+func (r *CommandRepository) AcknowledgeCommand(id int, vehicleID int, ack models.CommandAck) error {
+    status := models.CommandAcknowledged
+    if !ack.Success {
+        status = models.CommandFailed
+    }
+
+    result, err := r.DB.Exec(`
+        UPDATE vehicle_commands
+        SET status = $1, error_message = $2, acknowledged_at = $3
+        WHERE id = $4 AND vehicle_id = $5 AND status = 'Sent' AND expires_at > $3
+    `, status, ack.Error, time.Now(), id, vehicleID)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return errors.New("command not awaiting acknowledgement")
+    }
+
+    if status == models.CommandAcknowledged {
+        return r.recordPickup(id)
+    }
+    return nil
+}
+
+// recordPickup marks the trip as started on its first successful unlock and
+// takes the vehicle's odometer, charge, location and cleanliness as the
+// pickup readings
+func (r *CommandRepository) recordPickup(commandID int) error {
+    _, err := r.DB.Exec(`
+        UPDATE reservations r
+        SET picked_up_at = c.acknowledged_at,
+            pickup_odometer_km = v.odometer_km, pickup_charge_level = v.charge_level,
+            pickup_location = v.location, pickup_cleanliness = v.cleanliness
+        FROM vehicle_commands c, vehicles v
+        WHERE c.id = $1 AND c.command = $2 AND r.id = c.reservation_id
+          AND v.id = r.vehicle_id AND r.picked_up_at IS NULL
+    `, commandID, models.CommandUnlock)
+    return err
+}
+
+// ExpireCommands times out every command that was not acknowledged in time.
+func (r *CommandRepository) ExpireCommands() (int64, error) {
+    result, err := r.DB.Exec(`
+        UPDATE vehicle_commands
+        SET status = 'TimedOut', error_message = 'vehicle did not acknowledge in time'
+        WHERE status IN ('Pending', 'Sent') AND expires_at <= $1
+    `, time.Now())
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
+
+func scanCommand(row *sql.Row) (*models.VehicleCommand, error) {
+    var cmd models.VehicleCommand
+    var sentAt, acknowledgedAt sql.NullTime
+    err := row.Scan(
+        &cmd.ID, &cmd.VehicleID, &cmd.ReservationID, &cmd.UserID, &cmd.Command,
+        &cmd.Status, &cmd.ErrorMessage, &cmd.CreatedAt, &sentAt, &acknowledgedAt,
+        &cmd.ExpiresAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if sentAt.Valid {
+        cmd.SentAt = &sentAt.Time
+    }
+    if acknowledgedAt.Valid {
+        cmd.AcknowledgedAt = &acknowledgedAt.Time
+    }
+
+    return &cmd, nil
+}
@@ -0,0 +1,87 @@
+// Path: services/vehicle-service/repository/conflicts.go
+package repository
+
+import (
+    "database/sql"
+    "time"
+)
+
+// querier lets the conflict checks run on the DB or inside a transaction
+type querier interface {
+    QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// turnaroundMinutes is the buffer in minutes kept free before and after each
+// booking of vehicle v. Expects turnaround_buffers to be left joined as tb;
+// vehicle types without a row get no buffer.
+const turnaroundMinutes = `COALESCE(tb.buffer_minutes + CASE WHEN v.charge_level < tb.low_charge_threshold THEN tb.low_charge_extra_minutes ELSE 0 END, 0)`
+
+// Conflict describes what blocks a requested time range
+type Conflict struct {
+    Kind          string    `json:"kind"` // reservation, hold, maintenance
+    ID            int       `json:"id"`
+    StartTime     time.Time `json:"start_time"`
+    EndTime       time.Time `json:"end_time"`
+    BufferMinutes int       `json:"buffer_minutes"` // turnaround kept free around the booking
+}
+
+// FreeFrom is the earliest the vehicle can be handed back before this booking
+func (c Conflict) FreeFrom() time.Time {
+    return c.StartTime.Add(-time.Duration(c.BufferMinutes) * time.Minute)
+}
+
+// findConflict returns the earliest booking on the vehicle that overlaps
+// [start, end) once the vehicle's turnaround buffer is added on both sides:
+// an active reservation other than excludeID, a live booking hold belonging
+// to someone other than userID, or a maintenance window (which needs no
+// buffer). Returns nil when the range is free.
+func findConflict(q querier, vehicleID int, start, end time.Time, excludeID int, userID int) (*Conflict, error) {
+    query := `
+        WITH buffer AS (
+            SELECT ` + turnaroundMinutes + ` AS minutes
+            FROM vehicles v
+            LEFT JOIN turnaround_buffers tb ON tb.vehicle_type = v.type
+            WHERE v.id = $1
+        )
+        SELECT 'reservation', r.id, r.start_time, r.end_time, b.minutes
+        FROM reservations r, buffer b
+        WHERE r.vehicle_id = $1
+        AND r.status = 'Active'
+        AND r.id <> $4
+        AND r.start_time - make_interval(mins => b.minutes) < $3
+        AND r.end_time + make_interval(mins => b.minutes) > $2
+
+        UNION ALL
+
+        SELECT 'hold', h.id, h.start_time, h.end_time, b.minutes
+        FROM booking_holds h, buffer b
+        WHERE h.vehicle_id = $1
+        AND h.status = 'Held'
+        AND h.expires_at > $6
+        AND h.user_id <> $5
+        AND h.start_time - make_interval(mins => b.minutes) < $3
+        AND h.end_time + make_interval(mins => b.minutes) > $2
+
+        UNION ALL
+
+        SELECT 'maintenance', m.id, m.start_time, m.end_time, 0
+        FROM maintenance_windows m
+        WHERE m.vehicle_id = $1
+        AND m.start_time < $3
+        AND m.end_time > $2
+
+        ORDER BY 3
+        LIMIT 1
+    `
+
+    var c Conflict
+    err := q.QueryRow(query, vehicleID, start, end, excludeID, userID, time.Now()).Scan(&c.Kind, &c.ID, &c.StartTime, &c.EndTime, &c.BufferMinutes)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    return &c, nil
+}
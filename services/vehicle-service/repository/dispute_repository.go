@@ -0,0 +1,593 @@
+// Path: services/vehicle-service/repository/dispute_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "vehicle-service/models"
+)
+
+var (
+    ErrDisputeNotFound   = errors.New("dispute not found or unauthorized")
+    ErrLineNotFound      = errors.New("invoice line not found")
+    ErrInvoiceNotIssued  = errors.New("only issued invoices can be disputed")
+    ErrLineNotDisputable = errors.New("only charges can be disputed")
+    ErrDisputeExists     = errors.New("this line already has an open dispute")
+    ErrDisputeNotOpen    = errors.New("dispute is already resolved")
+    ErrRefundNotPending  = errors.New("refund is no longer pending")
+    ErrExceedsLine       = errors.New("refund exceeds what is left of the disputed line")
+    ErrExceedsPaid       = errors.New("refund exceeds what was paid for the invoice")
+    ErrExceedsCapture    = errors.New("refund exceeds what was charged to the card")
+    ErrWalletCurrency    = errors.New("the wallet can only be refunded in its own currency")
+)
+
+type DisputeRepository struct {
+    DB *sql.DB
+}
+
+func NewDisputeRepository(db *sql.DB) *DisputeRepository {
+    return &DisputeRepository{DB: db}
+}
+
+const disputeColumns = `
+    id, invoice_id, invoice_line_id, user_id, reason, status, refund_cents,
+    resolved_by, resolution_note, created_at, resolved_at
+`
+
+const refundColumns = `
+    id, dispute_id, invoice_id, user_id, amount_cents, method, status, provider_reference,
+    created_by, created_at, completed_at
+`
+
+// OpenDispute records the user's objection to one of their invoice lines
+func (r *DisputeRepository) OpenDispute(userID int, invoiceID int, lineID int, reason string) (*models.Dispute, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    dispute, err := openDispute(tx, userID, invoiceID, lineID, reason)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+    return dispute, nil
+}
+
+// DisputeFine opens a dispute on the invoice line a fine was charged as, so
+// support resolves and refunds it like any other charge. A fine can be
+// disputed once, within disputeWindow of being charged.
+func (r *DisputeRepository) DisputeFine(fineID int, userID int, reason string) (*models.Dispute, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    fine, err := scanFine(tx.QueryRow(
+        "SELECT "+fineColumns+" FROM fines WHERE id = $1 AND user_id = $2 FOR UPDATE",
+        fineID, userID,
+    ))
+    if err == sql.ErrNoRows {
+        return nil, ErrFineNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if fine.Status != models.FineCharged {
+        return nil, ErrFineAlreadyDisputed
+    }
+    if time.Since(fine.CreatedAt) > disputeWindow {
+        return nil, ErrDisputeWindowClosed
+    }
+    if fine.InvoiceLineID == nil {
+        return nil, ErrLineNotFound
+    }
+
+    dispute, err := openDispute(tx, userID, fine.InvoiceID, *fine.InvoiceLineID, reason)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+    return dispute, nil
+}
+
+// openDispute validates the line and records the dispute. A fine charged as
+// the line is marked as disputed along with it.
+func openDispute(tx *sql.Tx, userID int, invoiceID int, lineID int, reason string) (*models.Dispute, error) {
+    var status string
+    err := tx.QueryRow("SELECT status FROM invoices WHERE id = $1 AND user_id = $2", invoiceID, userID).Scan(&status)
+    if err == sql.ErrNoRows {
+        return nil, ErrInvoiceNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    if status != models.InvoiceIssued {
+        return nil, ErrInvoiceNotIssued
+    }
+
+    var amount int64
+    err = tx.QueryRow("SELECT amount_cents FROM invoice_lines WHERE id = $1 AND invoice_id = $2", lineID, invoiceID).Scan(&amount)
+    if err == sql.ErrNoRows {
+        return nil, ErrLineNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    if amount <= 0 {
+        return nil, ErrLineNotDisputable
+    }
+
+    var exists bool
+    err = tx.QueryRow(
+        "SELECT EXISTS (SELECT 1 FROM invoice_disputes WHERE invoice_line_id = $1 AND status IN ('Open', 'Refunding'))",
+        lineID,
+    ).Scan(&exists)
+    if err != nil {
+        return nil, err
+    }
+    if exists {
+        return nil, ErrDisputeExists
+    }
+
+    dispute := &models.Dispute{
+        InvoiceID:     invoiceID,
+        InvoiceLineID: lineID,
+        UserID:        userID,
+        Reason:        reason,
+        Status:        models.DisputeOpen,
+        CreatedAt:     time.Now(),
+    }
+    err = tx.QueryRow(`
+        INSERT INTO invoice_disputes (invoice_id, invoice_line_id, user_id, reason, status, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `, invoiceID, lineID, userID, reason, dispute.Status, dispute.CreatedAt).Scan(&dispute.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := addDisputeEvent(tx, dispute.ID, userID, models.DisputeActionOpened, 0, reason); err != nil {
+        return nil, err
+    }
+
+    _, err = tx.Exec(`
+        UPDATE fines SET status = $1, dispute_reason = $2, disputed_at = $3
+        WHERE invoice_line_id = $4 AND status = $5
+    `, models.FineDisputed, reason, dispute.CreatedAt, lineID, models.FineCharged)
+    if err != nil {
+        return nil, err
+    }
+
+    return dispute, nil
+}
+
+// GetDispute returns a dispute with its line and audit trail. A userID of 0
+// skips the ownership check, for support.
+func (r *DisputeRepository) GetDispute(id int, userID int) (*models.Dispute, error) {
+    query := "SELECT " + disputeColumns + " FROM invoice_disputes WHERE id = $1"
+    args := []interface{}{id}
+    if userID != 0 {
+        query += " AND user_id = $2"
+        args = append(args, userID)
+    }
+
+    dispute, err := scanDispute(r.DB.QueryRow(query, args...))
+    if err == sql.ErrNoRows {
+        return nil, ErrDisputeNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var line models.InvoiceLine
+    err = r.DB.QueryRow(`
+        SELECT id, invoice_id, kind, description, amount_cents, created_at
+        FROM invoice_lines WHERE id = $1
+    `, dispute.InvoiceLineID).Scan(&line.ID, &line.InvoiceID, &line.Kind, &line.Description, &line.AmountCents, &line.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    dispute.Line = &line
+
+    rows, err := r.DB.Query(`
+        SELECT id, dispute_id, actor_id, action, amount_cents, note, created_at
+        FROM dispute_events WHERE dispute_id = $1 ORDER BY created_at, id
+    `, id)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    dispute.Events = []models.DisputeEvent{}
+    for rows.Next() {
+        var e models.DisputeEvent
+        if err := rows.Scan(&e.ID, &e.DisputeID, &e.ActorID, &e.Action, &e.AmountCents, &e.Note, &e.CreatedAt); err != nil {
+            return nil, err
+        }
+        dispute.Events = append(dispute.Events, e)
+    }
+
+    return dispute, nil
+}
+
+func (r *DisputeRepository) GetUserDisputes(userID int) ([]models.Dispute, error) {
+    return r.listDisputes("SELECT "+disputeColumns+" FROM invoice_disputes WHERE user_id = $1 ORDER BY created_at DESC", userID)
+}
+
+// GetDisputesByStatus is the support queue, oldest first
+func (r *DisputeRepository) GetDisputesByStatus(status string) ([]models.Dispute, error) {
+    return r.listDisputes("SELECT "+disputeColumns+" FROM invoice_disputes WHERE status = $1 ORDER BY created_at", status)
+}
+
+// Reject closes the dispute without a refund
+func (r *DisputeRepository) Reject(id int, staffID int, note string) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := resolveDispute(tx, id, models.DisputeOpen, models.DisputeRejected, 0, staffID, note); err != nil {
+        return err
+    }
+    if err := addDisputeEvent(tx, id, staffID, models.DisputeActionRejected, 0, note); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// Approve closes the dispute in the user's favour without a refund
+func (r *DisputeRepository) Approve(id int, staffID int, note string) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := resolveDispute(tx, id, models.DisputeOpen, models.DisputeApproved, 0, staffID, note); err != nil {
+        return err
+    }
+    if err := addDisputeEvent(tx, id, staffID, models.DisputeActionApproved, 0, note); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// StartRefund claims an open dispute and records its refund as Pending in
+// one transaction, after checking the amount against what is left of the
+// line and of what was paid. The payment is locked so refunds on other
+// lines of the invoice are checked one at a time. The money is moved
+// afterwards, and the refund finished with CompleteRefund or FailRefund.
+func (r *DisputeRepository) StartRefund(dispute *models.Dispute, refund *models.Refund, note string) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    result, err := tx.Exec(
+        "UPDATE invoice_disputes SET status = $1, resolved_by = $2, resolution_note = $3 WHERE id = $4 AND status = $5",
+        models.DisputeRefunding, refund.CreatedBy, note, dispute.ID, models.DisputeOpen,
+    )
+    if err != nil {
+        return err
+    }
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return ErrDisputeNotOpen
+    }
+
+    var currency string
+    var captured, refunded, wallet int64
+    err = tx.QueryRow(`
+        SELECT p.currency, p.captured_cents, p.refunded_cents, p.wallet_cents
+        FROM payments p
+        JOIN invoices i ON i.reservation_id = p.reservation_id
+        WHERE i.id = $1
+        FOR UPDATE OF p
+    `, dispute.InvoiceID).Scan(&currency, &captured, &refunded, &wallet)
+    if err == sql.ErrNoRows {
+        return ErrExceedsPaid
+    }
+    if err != nil {
+        return err
+    }
+
+    // Pending refunds count as paid out until they fail
+    var lineRefunded, invoiceRefunded, cardPending int64
+    err = tx.QueryRow(`
+        SELECT COALESCE(SUM(f.amount_cents) FILTER (WHERE d.invoice_line_id = $2), 0),
+               COALESCE(SUM(f.amount_cents), 0),
+               COALESCE(SUM(f.amount_cents) FILTER (WHERE f.method = $3 AND f.status = $4), 0)
+        FROM refunds f
+        JOIN invoice_disputes d ON d.id = f.dispute_id
+        WHERE f.invoice_id = $1 AND f.status IN ($4, $5)
+    `, dispute.InvoiceID, dispute.InvoiceLineID, models.RefundToCard, models.RefundPending, models.RefundCompleted,
+    ).Scan(&lineRefunded, &invoiceRefunded, &cardPending)
+    if err != nil {
+        return err
+    }
+
+    switch {
+    case refund.AmountCents > dispute.Line.AmountCents-lineRefunded:
+        return ErrExceedsLine
+    case refund.AmountCents > captured+wallet-invoiceRefunded:
+        return ErrExceedsPaid
+    case refund.Method == models.RefundToCard && refund.AmountCents > captured-refunded-cardPending:
+        return ErrExceedsCapture
+    case refund.Method == models.RefundToWallet && currency != models.DefaultCurrency:
+        return ErrWalletCurrency
+    }
+
+    refund.Status = models.RefundPending
+    refund.CreatedAt = time.Now()
+    err = tx.QueryRow(`
+        INSERT INTO refunds (dispute_id, invoice_id, user_id, amount_cents, method, status, created_by, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id
+    `, refund.DisputeID, refund.InvoiceID, refund.UserID, refund.AmountCents, refund.Method,
+        refund.Status, refund.CreatedBy, refund.CreatedAt,
+    ).Scan(&refund.ID)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// CompleteRefund records a pending refund as paid out: the payment's
+// refunded amount for card refunds or the wallet credit for wallet
+// refunds, a credit line on the invoice, and the dispute's approval. A
+// wallet refund moves its money here, in the same transaction.
+func (r *DisputeRepository) CompleteRefund(refund *models.Refund, providerReference string) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    now := time.Now()
+    result, err := tx.Exec(`
+        UPDATE refunds SET status = $1, provider_reference = $2, completed_at = $3
+        WHERE id = $4 AND status = $5
+    `, models.RefundCompleted, providerReference, now, refund.ID, models.RefundPending)
+    if err != nil {
+        return err
+    }
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return ErrRefundNotPending
+    }
+
+    switch refund.Method {
+    case models.RefundToCard:
+        _, err := tx.Exec(`
+            UPDATE payments SET refunded_cents = refunded_cents + $1, updated_at = $2
+            WHERE reservation_id = (SELECT reservation_id FROM invoices WHERE id = $3)
+        `, refund.AmountCents, now, refund.InvoiceID)
+        if err != nil {
+            return err
+        }
+    case models.RefundToWallet:
+        wallet, err := ledgerAccount(tx, &refund.UserID, models.AccountWallet)
+        if err != nil {
+            return err
+        }
+        revenue, err := ledgerAccount(tx, nil, models.AccountRevenue)
+        if err != nil {
+            return err
+        }
+        err = postTransaction(tx, models.LedgerRefund, fmt.Sprintf("Refund for dispute #%d", refund.DisputeID),
+            fmt.Sprintf("dispute:%d", refund.DisputeID), &refund.CreatedBy,
+            []ledgerEntry{{wallet, refund.AmountCents}, {revenue, -refund.AmountCents}})
+        if err != nil {
+            return err
+        }
+    }
+
+    var note string
+    err = tx.QueryRow("SELECT resolution_note FROM invoice_disputes WHERE id = $1", refund.DisputeID).Scan(&note)
+    if err != nil {
+        return err
+    }
+    if err := resolveDispute(tx, refund.DisputeID, models.DisputeRefunding, models.DisputeApproved, refund.AmountCents, refund.CreatedBy, note); err != nil {
+        return err
+    }
+    if err := addDisputeEvent(tx, refund.DisputeID, refund.CreatedBy, models.DisputeActionApproved, refund.AmountCents, note); err != nil {
+        return err
+    }
+
+    err = insertLines(tx, refund.InvoiceID, []models.InvoiceLine{{
+        Kind:        models.LineRefund,
+        Description: fmt.Sprintf("Refund for dispute #%d (%s)", refund.DisputeID, refund.Method),
+        AmountCents: -refund.AmountCents,
+    }})
+    if err != nil {
+        return err
+    }
+
+    eventNote := fmt.Sprintf("to %s", refund.Method)
+    if providerReference != "" {
+        eventNote += ", reference " + providerReference
+    }
+    if err := addDisputeEvent(tx, refund.DisputeID, refund.CreatedBy, models.DisputeActionRefunded, refund.AmountCents, eventNote); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// FailRefund marks a pending refund the provider turned down as failed and
+// reopens its dispute
+func (r *DisputeRepository) FailRefund(refund *models.Refund, reason string) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    _, err = tx.Exec("UPDATE refunds SET status = $1 WHERE id = $2 AND status = $3",
+        models.RefundFailed, refund.ID, models.RefundPending)
+    if err != nil {
+        return err
+    }
+    _, err = tx.Exec(`
+        UPDATE invoice_disputes SET status = $1, resolved_by = NULL, resolution_note = ''
+        WHERE id = $2 AND status = $3
+    `, models.DisputeOpen, refund.DisputeID, models.DisputeRefunding)
+    if err != nil {
+        return err
+    }
+    if err := addDisputeEvent(tx, refund.DisputeID, refund.CreatedBy, models.DisputeActionRefundFailed, refund.AmountCents, reason); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// GetPendingRefund returns the refund a dispute in Refunding is waiting on
+func (r *DisputeRepository) GetPendingRefund(disputeID int) (*models.Refund, error) {
+    refund, err := scanRefund(r.DB.QueryRow(
+        "SELECT "+refundColumns+" FROM refunds WHERE dispute_id = $1 AND status = $2",
+        disputeID, models.RefundPending,
+    ))
+    if err == sql.ErrNoRows {
+        return nil, ErrRefundNotPending
+    }
+    return refund, err
+}
+
+// GetPendingRefunds returns refunds started before the given time that
+// never finished, e.g. because the service stopped part way
+func (r *DisputeRepository) GetPendingRefunds(createdBefore time.Time) ([]models.Refund, error) {
+    rows, err := r.DB.Query(
+        "SELECT "+refundColumns+" FROM refunds WHERE status = $1 AND created_at < $2 ORDER BY created_at",
+        models.RefundPending, createdBefore,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var refunds []models.Refund
+    for rows.Next() {
+        refund, err := scanRefund(rows)
+        if err != nil {
+            return nil, err
+        }
+        refunds = append(refunds, *refund)
+    }
+
+    return refunds, nil
+}
+
+func (r *DisputeRepository) listDisputes(query string, arg interface{}) ([]models.Dispute, error) {
+    rows, err := r.DB.Query(query, arg)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    disputes := []models.Dispute{}
+    for rows.Next() {
+        dispute, err := scanDispute(rows)
+        if err != nil {
+            return nil, err
+        }
+        disputes = append(disputes, *dispute)
+    }
+
+    return disputes, nil
+}
+
+func resolveDispute(tx *sql.Tx, id int, from string, status string, refundCents int64, staffID int, note string) error {
+    result, err := tx.Exec(`
+        UPDATE invoice_disputes
+        SET status = $1, refund_cents = $2, resolved_by = $3, resolution_note = $4, resolved_at = $5
+        WHERE id = $6 AND status = $7
+    `, status, refundCents, staffID, note, time.Now(), id, from)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return ErrDisputeNotOpen
+    }
+    return nil
+}
+
+func addDisputeEvent(tx *sql.Tx, disputeID int, actorID int, action string, amountCents int64, note string) error {
+    _, err := tx.Exec(`
+        INSERT INTO dispute_events (dispute_id, actor_id, action, amount_cents, note, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, disputeID, actorID, action, amountCents, note, time.Now())
+    return err
+}
+
+func scanDispute(row rowScanner) (*models.Dispute, error) {
+    var d models.Dispute
+    var resolvedBy sql.NullInt64
+    var resolvedAt sql.NullTime
+
+    err := row.Scan(
+        &d.ID, &d.InvoiceID, &d.InvoiceLineID, &d.UserID, &d.Reason, &d.Status, &d.RefundCents,
+        &resolvedBy, &d.ResolutionNote, &d.CreatedAt, &resolvedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if resolvedBy.Valid {
+        id := int(resolvedBy.Int64)
+        d.ResolvedBy = &id
+    }
+    if resolvedAt.Valid {
+        d.ResolvedAt = &resolvedAt.Time
+    }
+
+    return &d, nil
+}
+
+func scanRefund(row rowScanner) (*models.Refund, error) {
+    var f models.Refund
+    var completedAt sql.NullTime
+
+    err := row.Scan(
+        &f.ID, &f.DisputeID, &f.InvoiceID, &f.UserID, &f.AmountCents, &f.Method, &f.Status,
+        &f.ProviderReference, &f.CreatedBy, &f.CreatedAt, &completedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if completedAt.Valid {
+        f.CompletedAt = &completedAt.Time
+    }
+
+    return &f, nil
+}
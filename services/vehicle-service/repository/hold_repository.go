@@ -0,0 +1,297 @@
+// Path: services/vehicle-service/repository/hold_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "vehicle-service/events"
+    "vehicle-service/models"
+)
+
+const (
+    // How long a checkout hold lasts before it lapses on its own
+    bookingHoldTTL = 10 * time.Minute
+    // Stops one user from sitting on half the fleet
+    maxActiveHoldsPerUser = 3
+)
+
+var (
+    ErrHoldNotFound  = errors.New("hold not found or unauthorized")
+    ErrHoldNotActive = errors.New("hold has expired or was already used")
+    ErrTooManyHolds  = errors.New("too many active holds")
+)
+
+type HoldRepository struct {
+    DB     *sql.DB
+    Events *events.Broker
+}
+
+func NewHoldRepository(db *sql.DB, broker *events.Broker) *HoldRepository {
+    return &HoldRepository{DB: db, Events: broker}
+}
+
+const holdColumns = `
+    id, user_id, vehicle_id, start_time, end_time, status, expires_at,
+    reservation_id, created_at, updated_at
+`
+
+// CreateHold reserves the vehicle for the user for a few minutes. The hold is
+// invisible to everyone else's availability searches until it is confirmed,
+// released or expires.
+func (r *HoldRepository) CreateHold(hold *models.BookingHold) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    // Locking the user serialises their hold requests, so two at once can't
+    // both get under the limit
+    if _, err := tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", hold.UserID); err != nil {
+        return err
+    }
+
+    var active int
+    err = tx.QueryRow(
+        "SELECT COUNT(*) FROM booking_holds WHERE user_id = $1 AND status = 'Held' AND expires_at > $2",
+        hold.UserID, time.Now(),
+    ).Scan(&active)
+    if err != nil {
+        return err
+    }
+    if active >= maxActiveHoldsPerUser {
+        return ErrTooManyHolds
+    }
+
+    created, err := insertHold(tx, hold.UserID, hold.VehicleID, hold.StartTime, hold.EndTime, bookingHoldTTL)
+    if err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return err
+    }
+
+    *hold = *created
+    publishVehicleChange(r.DB, r.Events, hold.VehicleID, "hold_created")
+    return nil
+}
+
+func (r *HoldRepository) GetUserHolds(userID int) ([]models.BookingHold, error) {
+    rows, err := r.DB.Query(
+        "SELECT "+holdColumns+" FROM booking_holds WHERE user_id = $1 AND status = 'Held' AND expires_at > $2 ORDER BY expires_at",
+        userID, time.Now(),
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var holds []models.BookingHold
+    for rows.Next() {
+        hold, err := scanHold(rows)
+        if err != nil {
+            return nil, err
+        }
+        holds = append(holds, *hold)
+    }
+
+    return holds, nil
+}
+
+// ConfirmHold turns a live hold into a reservation
+func (r *HoldRepository) ConfirmHold(id int, userID int) (*models.Reservation, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    reservation, err := confirmHold(tx, id, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    publishVehicleChange(r.DB, r.Events, reservation.VehicleID, "reservation_created")
+    return reservation, nil
+}
+
+func (r *HoldRepository) ReleaseHold(id int, userID int) (*models.BookingHold, error) {
+    hold, err := scanHold(r.DB.QueryRow(`
+        UPDATE booking_holds SET status = 'Released', updated_at = $3
+        WHERE id = $1 AND user_id = $2 AND status = 'Held'
+        RETURNING `+holdColumns, id, userID, time.Now()))
+    if err == sql.ErrNoRows {
+        return nil, ErrHoldNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    publishVehicleChange(r.DB, r.Events, hold.VehicleID, "hold_released")
+    return hold, nil
+}
+
+// ExpireHolds marks lapsed holds as expired. Lapsed holds already stop
+// blocking the vehicle the moment expires_at passes; this just tidies up.
+// Only checkout holds are returned: a lapsed waitlist offer is passed on by
+// the waitlist itself.
+func (r *HoldRepository) ExpireHolds() ([]models.BookingHold, error) {
+    rows, err := r.DB.Query(`
+        WITH expired AS (
+            UPDATE booking_holds SET status = 'Expired', updated_at = $1
+            WHERE status = 'Held' AND expires_at <= $1
+            RETURNING `+holdColumns+`
+        )
+        SELECT `+holdColumns+` FROM expired e
+        WHERE NOT EXISTS (SELECT 1 FROM waitlist_entries w WHERE w.hold_id = e.id)
+    `, time.Now())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var expired []models.BookingHold
+    for rows.Next() {
+        hold, err := scanHold(rows)
+        if err != nil {
+            return nil, err
+        }
+        expired = append(expired, *hold)
+    }
+
+    return expired, nil
+}
+
+// insertHold places a hold inside an existing transaction after checking the
+// vehicle is free for the window
+func insertHold(tx *sql.Tx, userID, vehicleID int, start, end time.Time, ttl time.Duration) (*models.BookingHold, error) {
+    _, err := tx.Exec("SELECT id FROM vehicles WHERE id = $1 FOR UPDATE", vehicleID)
+    if err != nil {
+        return nil, err
+    }
+
+    conflict, err := findConflict(tx, vehicleID, start, end, 0, userID)
+    if err != nil {
+        return nil, err
+    }
+    if conflict != nil {
+        return nil, &ConflictError{Conflict: *conflict}
+    }
+
+    now := time.Now()
+    hold := &models.BookingHold{
+        UserID:    userID,
+        VehicleID: vehicleID,
+        StartTime: start,
+        EndTime:   end,
+        Status:    models.HoldHeld,
+        ExpiresAt: now.Add(ttl),
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+
+    err = tx.QueryRow(`
+        INSERT INTO booking_holds (user_id, vehicle_id, start_time, end_time, status, expires_at, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+        RETURNING id
+    `, userID, vehicleID, start, end, hold.Status, hold.ExpiresAt, now).Scan(&hold.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    return hold, nil
+}
+
+// confirmHold books the held window inside an existing transaction
+func confirmHold(tx *sql.Tx, id int, userID int) (*models.Reservation, error) {
+    hold, err := scanHold(tx.QueryRow(
+        "SELECT "+holdColumns+" FROM booking_holds WHERE id = $1 AND user_id = $2 FOR UPDATE",
+        id, userID,
+    ))
+    if err == sql.ErrNoRows {
+        return nil, ErrHoldNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    if hold.Status != models.HoldHeld || !hold.ExpiresAt.After(now) {
+        return nil, ErrHoldNotActive
+    }
+
+    _, err = tx.Exec("SELECT id FROM vehicles WHERE id = $1 FOR UPDATE", hold.VehicleID)
+    if err != nil {
+        return nil, err
+    }
+
+    // The user's own holds never conflict, so this only catches bookings
+    // that slipped in some other way
+    conflict, err := findConflict(tx, hold.VehicleID, hold.StartTime, hold.EndTime, 0, userID)
+    if err != nil {
+        return nil, err
+    }
+    if conflict != nil {
+        return nil, &ConflictError{Conflict: *conflict}
+    }
+
+    reservation := &models.Reservation{
+        UserID:    userID,
+        VehicleID: hold.VehicleID,
+        StartTime: hold.StartTime,
+        EndTime:   hold.EndTime,
+        Status:    "Active",
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    err = tx.QueryRow(`
+        INSERT INTO reservations (user_id, vehicle_id, start_time, end_time, status, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, 'Active', $5, $5)
+        RETURNING id
+    `, userID, hold.VehicleID, hold.StartTime, hold.EndTime, now).Scan(&reservation.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    _, err = tx.Exec("UPDATE vehicles SET status = 'In-Use' WHERE id = $1", hold.VehicleID)
+    if err != nil {
+        return nil, err
+    }
+
+    _, err = tx.Exec(
+        "UPDATE booking_holds SET status = 'Confirmed', reservation_id = $1, updated_at = $2 WHERE id = $3",
+        reservation.ID, now, id,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    return reservation, nil
+}
+
+func scanHold(row rowScanner) (*models.BookingHold, error) {
+    var hold models.BookingHold
+    var reservationID sql.NullInt64
+
+    err := row.Scan(
+        &hold.ID, &hold.UserID, &hold.VehicleID, &hold.StartTime, &hold.EndTime,
+        &hold.Status, &hold.ExpiresAt, &reservationID, &hold.CreatedAt, &hold.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if reservationID.Valid {
+        id := int(reservationID.Int64)
+        hold.ReservationID = &id
+    }
+
+    return &hold, nil
+}
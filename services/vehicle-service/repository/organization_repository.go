@@ -0,0 +1,281 @@
+// Path: services/vehicle-service/repository/organization_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "github.com/lib/pq"
+    "vehicle-service/models"
+)
+
+var (
+    ErrNotMember          = errors.New("you are not a member of an organization")
+    ErrCostCenterNotFound = errors.New("cost center not found")
+    ErrCostCenterExists   = errors.New("a cost center with this code already exists")
+)
+
+// OrganizationRepository reads the organizations managed by the user
+// service and keeps their cost centers, policies and bills
+type OrganizationRepository struct {
+    DB *sql.DB
+}
+
+func NewOrganizationRepository(db *sql.DB) *OrganizationRepository {
+    return &OrganizationRepository{DB: db}
+}
+
+func (r *OrganizationRepository) GetMembership(userID int) (*models.Membership, error) {
+    var m models.Membership
+    err := r.DB.QueryRow(`
+        SELECT o.id, o.name, m.role
+        FROM organization_members m
+        JOIN organizations o ON o.id = m.organization_id
+        WHERE m.user_id = $1
+    `, userID).Scan(&m.OrganizationID, &m.Name, &m.Role)
+    if err == sql.ErrNoRows {
+        return nil, ErrNotMember
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &m, nil
+}
+
+// GetBillingContact returns the organization's name and the address its
+// invoices are sent to
+func (r *OrganizationRepository) GetBillingContact(orgID int) (name string, email string, err error) {
+    err = r.DB.QueryRow(
+        "SELECT name, billing_email FROM organizations WHERE id = $1", orgID,
+    ).Scan(&name, &email)
+    return name, email, err
+}
+
+func (r *OrganizationRepository) GetCostCenters(orgID int) ([]models.CostCenter, error) {
+    rows, err := r.DB.Query(`
+        SELECT id, organization_id, code, name, active, created_at
+        FROM cost_centers WHERE organization_id = $1
+        ORDER BY code
+    `, orgID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    centers := []models.CostCenter{}
+    for rows.Next() {
+        c, err := scanCostCenter(rows)
+        if err != nil {
+            return nil, err
+        }
+        centers = append(centers, *c)
+    }
+
+    return centers, nil
+}
+
+// GetCostCenter returns one of the organization's active cost centers
+func (r *OrganizationRepository) GetCostCenter(orgID int, id int) (*models.CostCenter, error) {
+    c, err := scanCostCenter(r.DB.QueryRow(`
+        SELECT id, organization_id, code, name, active, created_at
+        FROM cost_centers WHERE id = $1 AND organization_id = $2 AND active
+    `, id, orgID))
+    if err == sql.ErrNoRows {
+        return nil, ErrCostCenterNotFound
+    }
+    return c, err
+}
+
+func (r *OrganizationRepository) CreateCostCenter(c *models.CostCenter) error {
+    c.Active = true
+    c.CreatedAt = time.Now()
+    err := r.DB.QueryRow(`
+        INSERT INTO cost_centers (organization_id, code, name, active, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (organization_id, code) DO NOTHING
+        RETURNING id
+    `, c.OrganizationID, c.Code, c.Name, c.Active, c.CreatedAt).Scan(&c.ID)
+    if err == sql.ErrNoRows {
+        return ErrCostCenterExists
+    }
+    return err
+}
+
+// GetPolicy returns the organization's booking policy. Organizations that
+// haven't set one get an unrestricted policy.
+func (r *OrganizationRepository) GetPolicy(orgID int) (*models.OrganizationPolicy, error) {
+    policy := models.OrganizationPolicy{OrganizationID: orgID, AllowedVehicleTypes: []string{}}
+    var maxHours, updatedBy sql.NullInt64
+
+    err := r.DB.QueryRow(`
+        SELECT max_rental_hours, allowed_vehicle_types, weekdays_only, require_cost_center, updated_by, updated_at
+        FROM organization_policies WHERE organization_id = $1
+    `, orgID).Scan(
+        &maxHours, pq.Array(&policy.AllowedVehicleTypes), &policy.WeekdaysOnly, &policy.RequireCostCenter,
+        &updatedBy, &policy.UpdatedAt,
+    )
+    if err == sql.ErrNoRows {
+        return &policy, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    policy.MaxRentalHours = nullInt(maxHours)
+    policy.UpdatedBy = nullInt(updatedBy)
+    return &policy, nil
+}
+
+func (r *OrganizationRepository) SetPolicy(policy *models.OrganizationPolicy, updatedBy int) error {
+    if policy.AllowedVehicleTypes == nil {
+        policy.AllowedVehicleTypes = []string{}
+    }
+    policy.UpdatedBy = &updatedBy
+    policy.UpdatedAt = time.Now()
+
+    _, err := r.DB.Exec(`
+        INSERT INTO organization_policies (organization_id, max_rental_hours, allowed_vehicle_types,
+                                           weekdays_only, require_cost_center, updated_by, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (organization_id) DO UPDATE SET
+            max_rental_hours = EXCLUDED.max_rental_hours,
+            allowed_vehicle_types = EXCLUDED.allowed_vehicle_types,
+            weekdays_only = EXCLUDED.weekdays_only,
+            require_cost_center = EXCLUDED.require_cost_center,
+            updated_by = EXCLUDED.updated_by,
+            updated_at = EXCLUDED.updated_at
+    `, policy.OrganizationID, policy.MaxRentalHours, pq.Array(policy.AllowedVehicleTypes),
+        policy.WeekdaysOnly, policy.RequireCostCenter, updatedBy, policy.UpdatedAt)
+    return err
+}
+
+// GetMemberReservations returns the business bookings made for the
+// organization that overlap the period
+func (r *OrganizationRepository) GetMemberReservations(orgID int, from, to time.Time) ([]models.MemberReservation, error) {
+    rows, err := r.DB.Query(`
+        SELECT r.id, r.user_id, r.vehicle_id, r.start_time, r.end_time, r.status, r.returned_at,
+               r.organization_id, r.cost_center_id, r.created_at, r.updated_at,
+               v.model, v.type, v.location,
+               u.email, COALESCE(c.code, '')
+        FROM reservations r
+        JOIN vehicles v ON v.id = r.vehicle_id
+        JOIN users u ON u.id = r.user_id
+        LEFT JOIN cost_centers c ON c.id = r.cost_center_id
+        WHERE r.organization_id = $1 AND r.start_time < $3 AND r.end_time > $2
+        ORDER BY r.start_time DESC
+    `, orgID, from, to)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    reservations := []models.MemberReservation{}
+    for rows.Next() {
+        var m models.MemberReservation
+        var returnedAt sql.NullTime
+        var organizationID, costCenterID sql.NullInt64
+        m.Vehicle = &models.Vehicle{}
+        err := rows.Scan(
+            &m.ID, &m.UserID, &m.VehicleID, &m.StartTime, &m.EndTime, &m.Status, &returnedAt,
+            &organizationID, &costCenterID, &m.CreatedAt, &m.UpdatedAt,
+            &m.Vehicle.Model, &m.Vehicle.Type, &m.Vehicle.Location,
+            &m.UserEmail, &m.CostCenterCode,
+        )
+        if err != nil {
+            return nil, err
+        }
+        if returnedAt.Valid {
+            m.ReturnedAt = &returnedAt.Time
+        }
+        m.OrganizationID = nullInt(organizationID)
+        m.CostCenterID = nullInt(costCenterID)
+        reservations = append(reservations, m)
+    }
+
+    return reservations, nil
+}
+
+// GetCompanyBill lists the organization's invoices opened in the month
+// starting at month and totals them per cost center and currency
+func (r *OrganizationRepository) GetCompanyBill(orgID int, month time.Time) (*models.CompanyBill, error) {
+    bill := &models.CompanyBill{
+        OrganizationID: orgID,
+        Month:          month,
+        Invoices:       []models.CompanyInvoice{},
+        CostCenters:    []models.CostCenterTotal{},
+    }
+    end := month.AddDate(0, 1, 0)
+
+    rows, err := r.DB.Query(`
+        SELECT i.id, i.reservation_id, i.user_id, u.email, COALESCE(c.code, ''), i.status, i.currency,
+               i.subtotal_cents, i.tax_cents, i.total_cents, i.created_at, i.issued_at
+        FROM invoices i
+        JOIN users u ON u.id = i.user_id
+        LEFT JOIN cost_centers c ON c.id = i.cost_center_id
+        WHERE i.organization_id = $1 AND i.created_at >= $2 AND i.created_at < $3
+        ORDER BY i.created_at
+    `, orgID, month, end)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var inv models.CompanyInvoice
+        var issuedAt sql.NullTime
+        err := rows.Scan(
+            &inv.InvoiceID, &inv.ReservationID, &inv.UserID, &inv.UserEmail, &inv.CostCenterCode, &inv.Status,
+            &inv.Currency, &inv.SubtotalCents, &inv.TaxCents, &inv.TotalCents, &inv.CreatedAt, &issuedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        if issuedAt.Valid {
+            inv.IssuedAt = &issuedAt.Time
+        }
+        bill.Invoices = append(bill.Invoices, inv)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    totals, err := r.DB.Query(`
+        SELECT i.cost_center_id, COALESCE(c.code, ''), i.currency, COUNT(*),
+               SUM(i.subtotal_cents), SUM(i.tax_cents), SUM(i.total_cents)
+        FROM invoices i
+        LEFT JOIN cost_centers c ON c.id = i.cost_center_id
+        WHERE i.organization_id = $1 AND i.created_at >= $2 AND i.created_at < $3
+        GROUP BY i.cost_center_id, c.code, i.currency
+        ORDER BY COALESCE(c.code, ''), i.currency
+    `, orgID, month, end)
+    if err != nil {
+        return nil, err
+    }
+    defer totals.Close()
+
+    for totals.Next() {
+        var t models.CostCenterTotal
+        var costCenterID sql.NullInt64
+        err := totals.Scan(
+            &costCenterID, &t.Code, &t.Currency, &t.InvoiceCount,
+            &t.SubtotalCents, &t.TaxCents, &t.TotalCents,
+        )
+        if err != nil {
+            return nil, err
+        }
+        t.CostCenterID = nullInt(costCenterID)
+        bill.CostCenters = append(bill.CostCenters, t)
+    }
+
+    return bill, nil
+}
+
+func scanCostCenter(row rowScanner) (*models.CostCenter, error) {
+    var c models.CostCenter
+    err := row.Scan(&c.ID, &c.OrganizationID, &c.Code, &c.Name, &c.Active, &c.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &c, nil
+}
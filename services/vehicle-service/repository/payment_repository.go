@@ -0,0 +1,180 @@
+// Path: services/vehicle-service/repository/payment_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "vehicle-service/models"
+)
+
+var (
+    ErrPaymentNotFound = errors.New("payment not found")
+    ErrDepositNotFound = errors.New("deposit not found")
+)
+
+type PaymentRepository struct {
+    DB *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+    return &PaymentRepository{DB: db}
+}
+
+const paymentColumns = `
+    id, reservation_id, user_id, provider, reference, status, currency, authorized_cents,
+    captured_cents, refunded_cents, wallet_cents, error_message, created_at, updated_at
+`
+
+const depositColumns = `
+    id, reservation_id, user_id, provider, reference, status, currency,
+    authorized_cents, captured_cents, error_message, created_at, updated_at
+`
+
+func (r *PaymentRepository) CreatePayment(p *models.Payment) error {
+    now := time.Now()
+    p.CreatedAt = now
+    p.UpdatedAt = now
+
+    return r.DB.QueryRow(`
+        INSERT INTO payments (reservation_id, user_id, provider, reference, status, currency, authorized_cents,
+                              captured_cents, refunded_cents, wallet_cents, error_message, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)
+        RETURNING id
+    `, p.ReservationID, p.UserID, p.Provider, p.Reference, p.Status, p.Currency, p.AuthorizedCents,
+        p.CapturedCents, p.RefundedCents, p.WalletCents, p.ErrorMessage, now,
+    ).Scan(&p.ID)
+}
+
+func (r *PaymentRepository) GetPaymentByReservation(reservationID int) (*models.Payment, error) {
+    p, err := scanPayment(r.DB.QueryRow(
+        "SELECT "+paymentColumns+" FROM payments WHERE reservation_id = $1", reservationID,
+    ))
+    if err == sql.ErrNoRows {
+        return nil, ErrPaymentNotFound
+    }
+    return p, err
+}
+
+// GetProviderPayments returns every payment the provider has a reference
+// for, oldest first
+func (r *PaymentRepository) GetProviderPayments(provider string) ([]models.Payment, error) {
+    rows, err := r.DB.Query(
+        "SELECT "+paymentColumns+" FROM payments WHERE provider = $1 AND reference <> '' ORDER BY id",
+        provider,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var list []models.Payment
+    for rows.Next() {
+        p, err := scanPayment(rows)
+        if err != nil {
+            return nil, err
+        }
+        list = append(list, *p)
+    }
+
+    return list, rows.Err()
+}
+
+// UpdatePayment stores the payment's state after a provider call
+func (r *PaymentRepository) UpdatePayment(p *models.Payment) error {
+    p.UpdatedAt = time.Now()
+    _, err := r.DB.Exec(`
+        UPDATE payments
+        SET reference = $1, status = $2, authorized_cents = $3, captured_cents = $4,
+            refunded_cents = $5, wallet_cents = $6, error_message = $7, updated_at = $8
+        WHERE id = $9
+    `, p.Reference, p.Status, p.AuthorizedCents, p.CapturedCents,
+        p.RefundedCents, p.WalletCents, p.ErrorMessage, p.UpdatedAt, p.ID)
+    return err
+}
+
+func (r *PaymentRepository) CreateDeposit(d *models.Deposit) error {
+    now := time.Now()
+    d.CreatedAt = now
+    d.UpdatedAt = now
+
+    return r.DB.QueryRow(`
+        INSERT INTO deposits (reservation_id, user_id, provider, reference, status, currency,
+                              authorized_cents, captured_cents, error_message, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+        RETURNING id
+    `, d.ReservationID, d.UserID, d.Provider, d.Reference, d.Status, d.Currency,
+        d.AuthorizedCents, d.CapturedCents, d.ErrorMessage, now,
+    ).Scan(&d.ID)
+}
+
+func (r *PaymentRepository) GetDepositByReservation(reservationID int) (*models.Deposit, error) {
+    d, err := scanDeposit(r.DB.QueryRow(
+        "SELECT "+depositColumns+" FROM deposits WHERE reservation_id = $1", reservationID,
+    ))
+    if err == sql.ErrNoRows {
+        return nil, ErrDepositNotFound
+    }
+    return d, err
+}
+
+// GetProviderDeposits returns every deposit the provider has a reference
+// for, oldest first
+func (r *PaymentRepository) GetProviderDeposits(provider string) ([]models.Deposit, error) {
+    rows, err := r.DB.Query(
+        "SELECT "+depositColumns+" FROM deposits WHERE provider = $1 AND reference <> '' ORDER BY id",
+        provider,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var list []models.Deposit
+    for rows.Next() {
+        d, err := scanDeposit(rows)
+        if err != nil {
+            return nil, err
+        }
+        list = append(list, *d)
+    }
+
+    return list, rows.Err()
+}
+
+// UpdateDeposit stores the deposit's state after a provider call
+func (r *PaymentRepository) UpdateDeposit(d *models.Deposit) error {
+    d.UpdatedAt = time.Now()
+    _, err := r.DB.Exec(`
+        UPDATE deposits
+        SET status = $1, captured_cents = $2, error_message = $3, updated_at = $4
+        WHERE id = $5
+    `, d.Status, d.CapturedCents, d.ErrorMessage, d.UpdatedAt, d.ID)
+    return err
+}
+
+func scanPayment(row rowScanner) (*models.Payment, error) {
+    var p models.Payment
+    err := row.Scan(
+        &p.ID, &p.ReservationID, &p.UserID, &p.Provider, &p.Reference, &p.Status, &p.Currency,
+        &p.AuthorizedCents, &p.CapturedCents, &p.RefundedCents, &p.WalletCents, &p.ErrorMessage,
+        &p.CreatedAt, &p.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return &p, nil
+}
+
+func scanDeposit(row rowScanner) (*models.Deposit, error) {
+    var d models.Deposit
+    err := row.Scan(
+        &d.ID, &d.ReservationID, &d.UserID, &d.Provider, &d.Reference, &d.Status, &d.Currency,
+        &d.AuthorizedCents, &d.CapturedCents, &d.ErrorMessage, &d.CreatedAt, &d.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return &d, nil
+}
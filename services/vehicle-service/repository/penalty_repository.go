@@ -0,0 +1,164 @@
+// Path: services/vehicle-service/repository/penalty_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "vehicle-service/models"
+)
+
+var (
+    ErrFineNotFound        = errors.New("fine not found or unauthorized")
+    ErrFineAlreadyDisputed = errors.New("fine is already disputed")
+    ErrDisputeWindowClosed = errors.New("fines can only be disputed within 14 days")
+)
+
+const disputeWindow = 14 * 24 * time.Hour
+
+type PenaltyRepository struct {
+    DB *sql.DB
+}
+
+func NewPenaltyRepository(db *sql.DB) *PenaltyRepository {
+    return &PenaltyRepository{DB: db}
+}
+
+const fineColumns = `
+    id, reservation_id, invoice_id, invoice_line_id, user_id, kind, description, amount_cents,
+    status, dispute_reason, disputed_at, created_at
+`
+
+func (r *PenaltyRepository) GetRules() ([]models.PenaltyRule, error) {
+    rows, err := r.DB.Query(`
+        SELECT kind, currency, threshold, amount_cents, per_unit_cents, max_cents
+        FROM penalty_rules WHERE active ORDER BY kind, currency
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var rules []models.PenaltyRule
+    for rows.Next() {
+        var rule models.PenaltyRule
+        var maxCents sql.NullInt64
+        if err := rows.Scan(&rule.Kind, &rule.Currency, &rule.Threshold, &rule.AmountCents, &rule.PerUnitCents, &maxCents); err != nil {
+            return nil, err
+        }
+        if maxCents.Valid {
+            rule.MaxCents = &maxCents.Int64
+        }
+        rules = append(rules, rule)
+    }
+
+    return rules, nil
+}
+
+// AddFines records the fines and adds each one to the invoice as a line. A
+// trip is fined at most once per kind, so running this twice is harmless.
+func (r *PenaltyRepository) AddFines(invoice *models.Invoice, fines []models.Fine) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    now := time.Now()
+    var fineIDs []int
+    var lines []models.InvoiceLine
+    for _, fine := range fines {
+        var id int
+        err := tx.QueryRow(`
+            INSERT INTO fines (reservation_id, invoice_id, user_id, kind, description, amount_cents, status, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+            ON CONFLICT (reservation_id, kind) DO NOTHING
+            RETURNING id
+        `, invoice.ReservationID, invoice.ID, invoice.UserID, fine.Kind, fine.Description,
+            fine.AmountCents, models.FineCharged, now,
+        ).Scan(&id)
+        if err == sql.ErrNoRows {
+            continue
+        }
+        if err != nil {
+            return err
+        }
+
+        fineIDs = append(fineIDs, id)
+        lines = append(lines, models.InvoiceLine{
+            Kind:        models.LinePenalty,
+            Description: fine.Description,
+            AmountCents: fine.AmountCents,
+        })
+    }
+
+    if len(lines) > 0 {
+        if err := insertLines(tx, invoice.ID, lines); err != nil {
+            return err
+        }
+    }
+
+    // Fines are disputed through their invoice line
+    for i, id := range fineIDs {
+        if _, err := tx.Exec("UPDATE fines SET invoice_line_id = $1 WHERE id = $2", lines[i].ID, id); err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+func (r *PenaltyRepository) GetUserFines(userID int) ([]models.Fine, error) {
+    rows, err := r.DB.Query(
+        "SELECT "+fineColumns+" FROM fines WHERE user_id = $1 ORDER BY created_at DESC",
+        userID,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    fines := []models.Fine{}
+    for rows.Next() {
+        fine, err := scanFine(rows)
+        if err != nil {
+            return nil, err
+        }
+        fines = append(fines, *fine)
+    }
+
+    return fines, nil
+}
+
+func (r *PenaltyRepository) GetFine(id int, userID int) (*models.Fine, error) {
+    fine, err := scanFine(r.DB.QueryRow(
+        "SELECT "+fineColumns+" FROM fines WHERE id = $1 AND user_id = $2",
+        id, userID,
+    ))
+    if err == sql.ErrNoRows {
+        return nil, ErrFineNotFound
+    }
+    return fine, err
+}
+
+func scanFine(row rowScanner) (*models.Fine, error) {
+    var fine models.Fine
+    var lineID sql.NullInt64
+    var disputedAt sql.NullTime
+
+    err := row.Scan(
+        &fine.ID, &fine.ReservationID, &fine.InvoiceID, &lineID, &fine.UserID, &fine.Kind, &fine.Description,
+        &fine.AmountCents, &fine.Status, &fine.DisputeReason, &disputedAt, &fine.CreatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    fine.InvoiceLineID = nullInt(lineID)
+    if disputedAt.Valid {
+        fine.DisputedAt = &disputedAt.Time
+    }
+
+    return &fine, nil
+}
@@ -0,0 +1,99 @@
+// Path: services/vehicle-service/repository/pricing_repository.go
+package repository
+
+import (
+    "database/sql"
+    "time"
+
+    "github.com/lib/pq"
+    "vehicle-service/models"
+)
+
+type PricingRepository struct {
+    DB *sql.DB
+}
+
+func NewPricingRepository(db *sql.DB) *PricingRepository {
+    return &PricingRepository{DB: db}
+}
+
+// GetRules returns the active rules that apply to the vehicle type
+func (r *PricingRepository) GetRules(vehicleType string) ([]models.PricingRule, error) {
+    rows, err := r.DB.Query(`
+        SELECT id, name, vehicle_type, days_of_week, start_minute, end_minute, multiplier_percent
+        FROM pricing_rules
+        WHERE active AND (vehicle_type IS NULL OR vehicle_type = $1)
+        ORDER BY id
+    `, vehicleType)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var rules []models.PricingRule
+    for rows.Next() {
+        var rule models.PricingRule
+        var vType sql.NullString
+        var days pq.Int64Array
+        err := rows.Scan(&rule.ID, &rule.Name, &vType, &days, &rule.StartMinute, &rule.EndMinute, &rule.MultiplierPercent)
+        if err != nil {
+            return nil, err
+        }
+        if vType.Valid {
+            rule.VehicleType = &vType.String
+        }
+        for _, d := range days {
+            rule.DaysOfWeek = append(rule.DaysOfWeek, int(d))
+        }
+        rules = append(rules, rule)
+    }
+
+    return rules, nil
+}
+
+func (r *PricingRepository) GetDemandMultipliers() ([]models.DemandMultiplier, error) {
+    rows, err := r.DB.Query("SELECT utilization_percent, multiplier_percent FROM demand_multipliers ORDER BY utilization_percent")
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var multipliers []models.DemandMultiplier
+    for rows.Next() {
+        var m models.DemandMultiplier
+        if err := rows.Scan(&m.UtilizationPercent, &m.MultiplierPercent); err != nil {
+            return nil, err
+        }
+        multipliers = append(multipliers, m)
+    }
+
+    return multipliers, nil
+}
+
+// GetZoneUtilization returns the percentage of vehicles at the location that
+// are booked, held or in maintenance at some point between start and end,
+// not counting the given reservation
+func (r *PricingRepository) GetZoneUtilization(location string, start, end time.Time, excludeReservationID int) (int, error) {
+    var total, busy int
+    err := r.DB.QueryRow(`
+        SELECT COUNT(*),
+               COUNT(*) FILTER (WHERE EXISTS (
+                   SELECT 1 FROM reservations res
+                   WHERE res.vehicle_id = v.id AND res.status = 'Active' AND res.id <> $5
+                     AND res.start_time < $3 AND res.end_time > $2
+               ) OR EXISTS (
+                   SELECT 1 FROM booking_holds h
+                   WHERE h.vehicle_id = v.id AND h.status = 'Held' AND h.expires_at > $4
+                     AND h.start_time < $3 AND h.end_time > $2
+               ) OR EXISTS (
+                   SELECT 1 FROM maintenance_windows m
+                   WHERE m.vehicle_id = v.id AND m.start_time < $3 AND m.end_time > $2
+               ))
+        FROM vehicles v
+        WHERE v.location = $1
+    `, location, start, end, time.Now(), excludeReservationID).Scan(&total, &busy)
+    if err != nil || total == 0 {
+        return 0, err
+    }
+    return busy * 100 / total, nil
+}
@@ -0,0 +1,131 @@
+// Path: services/vehicle-service/repository/promotion_repository.go
+package repository
+
+import (
+    "database/sql"
+    "time"
+
+    "github.com/lib/pq"
+    "vehicle-service/models"
+    "vehicle-service/promotions"
+)
+
+type PromotionRepository struct {
+    DB *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) *PromotionRepository {
+    return &PromotionRepository{DB: db}
+}
+
+const promotionColumns = `
+    id, code, description, discount_type, discount_value, currency, starts_at, expires_at,
+    max_redemptions, per_user_limit, tiers, first_ride_only, active
+`
+
+// CheckPromotion looks up a code and returns it if userID may use it now on
+// a booking in currency. reservationID is the booking it would apply to, or
+// 0 before booking.
+func (r *PromotionRepository) CheckPromotion(code string, userID int, tier string, currency string, reservationID int) (*models.Promotion, error) {
+    return checkPromotion(r.DB, code, userID, tier, currency, reservationID, "")
+}
+
+// Redeem applies a code to a reservation. The promotion row is locked so
+// usage limits hold under concurrent bookings. amountCents is what the
+// discount is taken from, in currency.
+func (r *PromotionRepository) Redeem(code string, userID int, tier string, currency string, reservationID int, amountCents int64) (*models.Promotion, *models.PromotionRedemption, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, nil, err
+    }
+    defer tx.Rollback()
+
+    promo, err := checkPromotion(tx, code, userID, tier, currency, reservationID, "FOR UPDATE")
+    if err != nil {
+        return nil, nil, err
+    }
+
+    redemption := &models.PromotionRedemption{
+        PromotionID:   promo.ID,
+        UserID:        userID,
+        ReservationID: reservationID,
+        DiscountCents: promotions.Discount(promo, amountCents),
+        Status:        models.RedemptionApplied,
+        CreatedAt:     time.Now(),
+    }
+
+    err = tx.QueryRow(`
+        INSERT INTO promotion_redemptions (promotion_id, user_id, reservation_id, discount_cents, status, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `, redemption.PromotionID, redemption.UserID, redemption.ReservationID,
+        redemption.DiscountCents, redemption.Status, redemption.CreatedAt,
+    ).Scan(&redemption.ID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, nil, err
+    }
+
+    return promo, redemption, nil
+}
+
+// ReverseRedemption gives the use of a code back when its reservation is
+// cancelled. Reservations without a code are left alone.
+func (r *PromotionRepository) ReverseRedemption(reservationID int) error {
+    _, err := r.DB.Exec(
+        "UPDATE promotion_redemptions SET status = 'Reversed' WHERE reservation_id = $1 AND status = 'Applied'",
+        reservationID,
+    )
+    return err
+}
+
+func checkPromotion(q querier, code string, userID int, tier string, currency string, reservationID int, lock string) (*models.Promotion, error) {
+    var promo models.Promotion
+    var promoCurrency sql.NullString
+    var expiresAt sql.NullTime
+    var maxRedemptions sql.NullInt64
+
+    err := q.QueryRow(
+        "SELECT "+promotionColumns+" FROM promotions WHERE code = $1 "+lock,
+        promotions.Normalize(code),
+    ).Scan(
+        &promo.ID, &promo.Code, &promo.Description, &promo.DiscountType, &promo.DiscountValue,
+        &promoCurrency, &promo.StartsAt, &expiresAt, &maxRedemptions, &promo.PerUserLimit,
+        pq.Array(&promo.Tiers), &promo.FirstRideOnly, &promo.Active,
+    )
+    if err == sql.ErrNoRows {
+        return nil, promotions.ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    promo.Currency = promoCurrency.String
+    if expiresAt.Valid {
+        promo.ExpiresAt = &expiresAt.Time
+    }
+    if maxRedemptions.Valid {
+        limit := int(maxRedemptions.Int64)
+        promo.MaxRedemptions = &limit
+    }
+
+    var usage promotions.Usage
+    err = q.QueryRow(`
+        SELECT
+            (SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND status = 'Applied'),
+            (SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2 AND status = 'Applied'),
+            (SELECT COUNT(*) FROM reservations WHERE user_id = $2 AND id <> $3 AND status IN ('Active', 'Completed'))
+    `, promo.ID, userID, reservationID).Scan(&usage.Total, &usage.ByUser, &usage.PriorTrips)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := promotions.Check(&promo, usage, tier, currency, time.Now()); err != nil {
+        return nil, err
+    }
+
+    return &promo, nil
+}
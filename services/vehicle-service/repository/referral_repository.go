@@ -0,0 +1,141 @@
+// Path: services/vehicle-service/repository/referral_repository.go
+package repository
+
+import (
+    "database/sql"
+    "fmt"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/referrals"
+)
+
+type ReferralRepository struct {
+    DB *sql.DB
+}
+
+func NewReferralRepository(db *sql.DB) *ReferralRepository {
+    return &ReferralRepository{DB: db}
+}
+
+// RewardFirstTrip pays out a pending referral once the referred user has
+// completed a trip and verified their phone number: both users get the
+// credit fixed at sign-up in their wallet, taken from the referrals
+// account. A referral that turns out to be the referrer under another
+// account is rejected instead. It returns nil when the user has no
+// referral to be rewarded yet.
+func (r *ReferralRepository) RewardFirstTrip(refereeID int) (*models.Referral, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    referral := models.Referral{RefereeID: refereeID, Status: models.ReferralRewarded}
+    err = tx.QueryRow(`
+        SELECT id, referrer_id, referrer_credit_cents, referee_credit_cents
+        FROM referrals
+        WHERE referee_id = $1 AND status = $2
+          AND EXISTS (SELECT 1 FROM reservations WHERE user_id = $1 AND status = 'Completed')
+        FOR UPDATE
+    `, refereeID, models.ReferralPending).Scan(
+        &referral.ID, &referral.ReferrerID, &referral.ReferrerCreditCents, &referral.RefereeCreditCents,
+    )
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    referrer, err := referralContact(tx, referral.ReferrerID)
+    if err != nil {
+        return nil, err
+    }
+    referee, err := referralContact(tx, refereeID)
+    if err != nil {
+        return nil, err
+    }
+
+    // Paid on the first trip completed after the number is verified
+    if referee.VerifiedPhone == "" {
+        return nil, nil
+    }
+
+    // Details can change after sign-up, so the self-referral check runs again
+    if reason := referrals.SelfReferralReason(referrer, referee); reason != "" {
+        _, err := tx.Exec(
+            "UPDATE referrals SET status = $1, reject_reason = $2 WHERE id = $3",
+            models.ReferralRejected, reason, referral.ID,
+        )
+        if err != nil {
+            return nil, err
+        }
+        return nil, tx.Commit()
+    }
+
+    now := time.Now()
+    _, err = tx.Exec(
+        "UPDATE referrals SET status = $1, rewarded_at = $2 WHERE id = $3",
+        models.ReferralRewarded, now, referral.ID,
+    )
+    if err != nil {
+        return nil, err
+    }
+    referral.RewardedAt = &now
+
+    source, err := ledgerAccount(tx, nil, models.AccountReferrals)
+    if err != nil {
+        return nil, err
+    }
+
+    // Lock the two wallets in id order so concurrent rewards can't deadlock
+    first, second := referral.ReferrerID, referral.RefereeID
+    if first > second {
+        first, second = second, first
+    }
+    wallets := map[int]int{}
+    for _, userID := range []int{first, second} {
+        id := userID
+        if wallets[id], err = ledgerAccount(tx, &id, models.AccountWallet); err != nil {
+            return nil, err
+        }
+    }
+
+    reference := fmt.Sprintf("referral:%d", referral.ID)
+    credits := []struct {
+        userID      int
+        amountCents int64
+        description string
+    }{
+        {referral.ReferrerID, referral.ReferrerCreditCents, "Referral reward for inviting a friend"},
+        {referral.RefereeID, referral.RefereeCreditCents, "Referral reward for your first trip"},
+    }
+    for _, c := range credits {
+        if c.amountCents <= 0 {
+            continue
+        }
+        err := postTransaction(tx, models.LedgerReferral, c.description, reference, nil,
+            []ledgerEntry{{wallets[c.userID], c.amountCents}, {source, -c.amountCents}})
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+    return &referral, nil
+}
+
+// referralContact reads the user's email and, if verified, phone number
+func referralContact(q querier, userID int) (models.ReferralContact, error) {
+    var contact models.ReferralContact
+    var phone sql.NullString
+    err := q.QueryRow(`
+        SELECT email, CASE WHEN phone_verified_at IS NOT NULL THEN phone_number END
+        FROM users WHERE id = $1
+    `, userID).Scan(&contact.Email, &phone)
+    contact.VerifiedPhone = phone.String
+    return contact, err
+}
@@ -1,183 +1,585 @@
-// Path: services/vehicle-service/repository/reservation_repository.go
-package repository
-
-import (
-    "database/sql"
-    "errors"
-    "time"
-
-    "vehicle-service/models"
-)
-
-type ReservationRepository struct {
-    DB *sql.DB
-}
-
-func NewReservationRepository(db *sql.DB) *ReservationRepository {
-    return &ReservationRepository{DB: db}
-}
-
-func (r *ReservationRepository) CreateReservation(reservation *models.Reservation) error {
-    query := `
-        INSERT INTO reservations (user_id, vehicle_id, start_time, end_time, status, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $6)
-        RETURNING id
-    `
-    
-    err := r.DB.QueryRow(
-        query,
-        reservation.UserID,
-        reservation.VehicleID,
-        reservation.StartTime,
-        reservation.EndTime,
-        "Active",
-        time.Now(),
-    ).Scan(&reservation.ID)
-
-    if err != nil {
-        return err
-    }
-
-    // Update vehicle status to In-Use
-    updateQuery := `UPDATE vehicles SET status = 'In-Use' WHERE id = $1`
-    _, err = r.DB.Exec(updateQuery, reservation.VehicleID)
-    
-    return err
-}
-
-func (r *ReservationRepository) GetUserReservations(userID int) ([]models.Reservation, error) {
-    query := `
-        SELECT r.id, r.user_id, r.vehicle_id, r.start_time, r.end_time, r.status,
-               r.created_at, r.updated_at,
-               v.model, v.type, v.location
-        FROM reservations r
-        JOIN vehicles v ON r.vehicle_id = v.id
-        WHERE r.user_id = $1
-        ORDER BY r.start_time DESC
-    `
-
-    rows, err := r.DB.Query(query, userID)
-    if err != nil {
-        return nil, err
-    }
-    defer rows.Close()
-
-    var reservations []models.Reservation
-    for rows.Next() {
-        var r models.Reservation
-        r.Vehicle = &models.Vehicle{}
-        err := rows.Scan(
-            &r.ID, &r.UserID, &r.VehicleID, &r.StartTime, &r.EndTime, &r.Status,
-            &r.CreatedAt, &r.UpdatedAt,
-            &r.Vehicle.Model, &r.Vehicle.Type, &r.Vehicle.Location,
-        )
-        if err != nil {
-            return nil, err
-        }
-        reservations = append(reservations, r)
-    }
-
-    return reservations, nil
-}
-
-func (r *ReservationRepository) UpdateReservation(id int, userID int, updates models.UpdateReservationRequest) error {
-    tx, err := r.DB.Begin()
-    if err != nil {
-        return err
-    }
-    defer tx.Rollback()
-
-    // Check if reservation exists and belongs to user
-    var status string
-    err = tx.QueryRow(
-        "SELECT status FROM reservations WHERE id = $1 AND user_id = $2",
-        id, userID,
-    ).Scan(&status)
-    if err == sql.ErrNoRows {
-        return errors.New("reservation not found or unauthorized")
-    }
-    if err != nil {
-        return err
-    }
-
-    if status != "Active" {
-        return errors.New("cannot modify non-active reservation")
-    }
-
-    // Update reservation
-    query := `
-        UPDATE reservations 
-        SET start_time = COALESCE($1, start_time),
-            end_time = COALESCE($2, end_time),
-            updated_at = $3
-        WHERE id = $4 AND user_id = $5
-    `
-
-    result, err := tx.Exec(
-        query,
-        updates.StartTime,
-        updates.EndTime,
-        time.Now(),
-        id,
-        userID,
-    )
-    if err != nil {
-        return err
-    }
-
-    rowsAffected, err := result.RowsAffected()
-    if err != nil {
-        return err
-    }
-    if rowsAffected == 0 {
-        return errors.New("reservation not found")
-    }
-
-    return tx.Commit()
-}
-
-func (r *ReservationRepository) CancelReservation(id int, userID int) error {
-    tx, err := r.DB.Begin()
-    if err != nil {
-        return err
-    }
-    defer tx.Rollback()
-
-    // Get vehicle ID and check if reservation is active
-    var vehicleID int
-    var status string
-    err = tx.QueryRow(
-        "SELECT vehicle_id, status FROM reservations WHERE id = $1 AND user_id = $2",
-        id, userID,
-    ).Scan(&vehicleID, &status)
-    if err == sql.ErrNoRows {
-        return errors.New("reservation not found or unauthorized")
-    }
-    if err != nil {
-        return err
-    }
-
-    if status != "Active" {
-        return errors.New("reservation is already cancelled or completed")
-    }
-
-    // Update reservation status
-    _, err = tx.Exec(
-        "UPDATE reservations SET status = 'Cancelled', updated_at = $1 WHERE id = $2",
-        time.Now(), id,
-    )
-    if err != nil {
-        return err
-    }
-
-    // Update vehicle status back to Available
-    _, err = tx.Exec(
-        "UPDATE vehicles SET status = 'Available' WHERE id = $1",
-        vehicleID,
-    )
-    if err != nil {
-        return err
-    }
-
-    return tx.Commit()
-}
\ No newline at end of file
+// Path: services/vehicle-service/repository/reservation_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "vehicle-service/events"
+    "vehicle-service/models"
+)
+
+var (
+    ErrReservationNotOngoing = errors.New("reservation is not currently in progress")
+    ErrInvalidExtension      = errors.New("new end time must be after the current end time")
+    ErrExceedsTierLimit      = errors.New("extension exceeds the maximum rental duration for your membership tier")
+    ErrTripUnderway          = errors.New("the vehicle has been picked up; end the trip with POST /reservations/{id}/complete instead")
+)
+
+// ConflictError is returned when another booking blocks the requested time
+type ConflictError struct {
+    Conflict Conflict
+}
+
+func (e *ConflictError) Error() string {
+    if e.Conflict.BufferMinutes > 0 {
+        return fmt.Sprintf("vehicle is booked from %s and needs %d minutes to turn around between bookings",
+            e.Conflict.StartTime.Format(time.RFC3339), e.Conflict.BufferMinutes)
+    }
+    return fmt.Sprintf("vehicle is booked from %s", e.Conflict.StartTime.Format(time.RFC3339))
+}
+
+// noShowGrace is how long after the start a reservation that was never
+// picked up is kept before it is cancelled as a no-show
+const noShowGrace = 30 * time.Minute
+
+type ReservationRepository struct {
+    DB     *sql.DB
+    Events *events.Broker
+}
+
+func NewReservationRepository(db *sql.DB, broker *events.Broker) *ReservationRepository {
+    return &ReservationRepository{DB: db, Events: broker}
+}
+
+func (r *ReservationRepository) CreateReservation(reservation *models.Reservation) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    // Lock the vehicle so concurrent bookings for it are checked one at a time
+    _, err = tx.Exec("SELECT id FROM vehicles WHERE id = $1 FOR UPDATE", reservation.VehicleID)
+    if err != nil {
+        return err
+    }
+
+    conflict, err := findConflict(tx, reservation.VehicleID, reservation.StartTime, reservation.EndTime, 0, reservation.UserID)
+    if err != nil {
+        return err
+    }
+    if conflict != nil {
+        return &ConflictError{Conflict: *conflict}
+    }
+
+    query := `
+        INSERT INTO reservations (user_id, vehicle_id, start_time, end_time, status, organization_id, cost_center_id, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+        RETURNING id
+    `
+    
+    err = tx.QueryRow(
+        query,
+        reservation.UserID,
+        reservation.VehicleID,
+        reservation.StartTime,
+        reservation.EndTime,
+        "Active",
+        reservation.OrganizationID,
+        reservation.CostCenterID,
+        time.Now(),
+    ).Scan(&reservation.ID)
+
+    if err != nil {
+        return err
+    }
+
+    // Update vehicle status to In-Use
+    updateQuery := `UPDATE vehicles SET status = 'In-Use' WHERE id = $1`
+    _, err = tx.Exec(updateQuery, reservation.VehicleID)
+    if err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return err
+    }
+
+    reservation.Status = "Active"
+    publishVehicleChange(r.DB, r.Events, reservation.VehicleID, "reservation_created")
+    return nil
+}
+
+func (r *ReservationRepository) GetUserReservations(userID int) ([]models.Reservation, error) {
+    query := `
+        SELECT r.id, r.user_id, r.vehicle_id, r.start_time, r.end_time, r.status,
+               r.created_at, r.updated_at,
+               v.model, v.type, v.location
+        FROM reservations r
+        JOIN vehicles v ON r.vehicle_id = v.id
+        WHERE r.user_id = $1
+        ORDER BY r.start_time DESC
+    `
+
+    rows, err := r.DB.Query(query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var reservations []models.Reservation
+    for rows.Next() {
+        var r models.Reservation
+        r.Vehicle = &models.Vehicle{}
+        err := rows.Scan(
+            &r.ID, &r.UserID, &r.VehicleID, &r.StartTime, &r.EndTime, &r.Status,
+            &r.CreatedAt, &r.UpdatedAt,
+            &r.Vehicle.Model, &r.Vehicle.Type, &r.Vehicle.Location,
+        )
+        if err != nil {
+            return nil, err
+        }
+        reservations = append(reservations, r)
+    }
+
+    return reservations, nil
+}
+
+func (r *ReservationRepository) GetReservation(id int, userID int) (*models.Reservation, error) {
+    query := `
+        SELECT r.id, r.user_id, r.vehicle_id, r.start_time, r.end_time, r.status, r.sequence,
+               r.returned_at, r.organization_id, r.cost_center_id, r.created_at, r.updated_at,
+               v.model, v.type, v.location
+        FROM reservations r
+        JOIN vehicles v ON r.vehicle_id = v.id
+        WHERE r.id = $1 AND r.user_id = $2
+    `
+
+    var res models.Reservation
+    var returnedAt sql.NullTime
+    var organizationID, costCenterID sql.NullInt64
+    res.Vehicle = &models.Vehicle{}
+    err := r.DB.QueryRow(query, id, userID).Scan(
+        &res.ID, &res.UserID, &res.VehicleID, &res.StartTime, &res.EndTime, &res.Status, &res.Sequence,
+        &returnedAt, &organizationID, &costCenterID, &res.CreatedAt, &res.UpdatedAt,
+        &res.Vehicle.Model, &res.Vehicle.Type, &res.Vehicle.Location,
+    )
+    if err == sql.ErrNoRows {
+        return nil, ErrReservationNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if returnedAt.Valid {
+        res.ReturnedAt = &returnedAt.Time
+    }
+    res.OrganizationID = nullInt(organizationID)
+    res.CostCenterID = nullInt(costCenterID)
+
+    return &res, nil
+}
+
+// GetUpcomingReservations returns reservations that haven't ended yet,
+// including cancelled ones so calendar clients can drop them.
+func (r *ReservationRepository) GetUpcomingReservations(userID int) ([]models.Reservation, error) {
+    query := `
+        SELECT r.id, r.user_id, r.vehicle_id, r.start_time, r.end_time, r.status, r.sequence,
+               r.created_at, r.updated_at,
+               v.model, v.type, v.location
+        FROM reservations r
+        JOIN vehicles v ON r.vehicle_id = v.id
+        WHERE r.user_id = $1 AND r.end_time >= $2 AND r.status IN ('Active', 'Cancelled')
+        ORDER BY r.start_time
+    `
+
+    rows, err := r.DB.Query(query, userID, time.Now())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var reservations []models.Reservation
+    for rows.Next() {
+        var res models.Reservation
+        res.Vehicle = &models.Vehicle{}
+        err := rows.Scan(
+            &res.ID, &res.UserID, &res.VehicleID, &res.StartTime, &res.EndTime, &res.Status, &res.Sequence,
+            &res.CreatedAt, &res.UpdatedAt,
+            &res.Vehicle.Model, &res.Vehicle.Type, &res.Vehicle.Location,
+        )
+        if err != nil {
+            return nil, err
+        }
+        reservations = append(reservations, res)
+    }
+
+    return reservations, nil
+}
+
+func (r *ReservationRepository) UpdateReservation(id int, userID int, updates models.UpdateReservationRequest) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    // Check if reservation exists and belongs to user
+    var vehicleID int
+    var status string
+    var startTime, endTime time.Time
+    err = tx.QueryRow(
+        "SELECT vehicle_id, status, start_time, end_time FROM reservations WHERE id = $1 AND user_id = $2",
+        id, userID,
+    ).Scan(&vehicleID, &status, &startTime, &endTime)
+    if err == sql.ErrNoRows {
+        return errors.New("reservation not found or unauthorized")
+    }
+    if err != nil {
+        return err
+    }
+
+    if status != "Active" {
+        return errors.New("cannot modify non-active reservation")
+    }
+
+    if updates.StartTime != nil {
+        startTime = *updates.StartTime
+    }
+    if updates.EndTime != nil {
+        endTime = *updates.EndTime
+    }
+
+    _, err = tx.Exec("SELECT id FROM vehicles WHERE id = $1 FOR UPDATE", vehicleID)
+    if err != nil {
+        return err
+    }
+
+    conflict, err := findConflict(tx, vehicleID, startTime, endTime, id, userID)
+    if err != nil {
+        return err
+    }
+    if conflict != nil {
+        return &ConflictError{Conflict: *conflict}
+    }
+
+    // Update reservation
+    query := `
+        UPDATE reservations 
+        SET start_time = COALESCE($1, start_time),
+            end_time = COALESCE($2, end_time),
+            sequence = sequence + 1,
+            updated_at = $3
+        WHERE id = $4 AND user_id = $5
+    `
+
+    result, err := tx.Exec(
+        query,
+        updates.StartTime,
+        updates.EndTime,
+        time.Now(),
+        id,
+        userID,
+    )
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return errors.New("reservation not found")
+    }
+
+    if err := tx.Commit(); err != nil {
+        return err
+    }
+
+    publishVehicleChange(r.DB, r.Events, vehicleID, "reservation_updated")
+    return nil
+}
+
+func (r *ReservationRepository) CancelReservation(id int, userID int) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    // Get vehicle ID and check if reservation is active. The row is locked so
+    // a pickup can't land between the check and the cancel.
+    var vehicleID int
+    var status string
+    var pickedUpAt sql.NullTime
+    err = tx.QueryRow(
+        "SELECT vehicle_id, status, picked_up_at FROM reservations WHERE id = $1 AND user_id = $2 FOR UPDATE",
+        id, userID,
+    ).Scan(&vehicleID, &status, &pickedUpAt)
+    if err == sql.ErrNoRows {
+        return errors.New("reservation not found or unauthorized")
+    }
+    if err != nil {
+        return err
+    }
+
+    if status != "Active" {
+        return errors.New("reservation is already cancelled or completed")
+    }
+
+    // A trip under way is billed for usage, not cancelled
+    if pickedUpAt.Valid {
+        return ErrTripUnderway
+    }
+
+    // Update reservation status
+    _, err = tx.Exec(
+        "UPDATE reservations SET status = 'Cancelled', sequence = sequence + 1, updated_at = $1 WHERE id = $2",
+        time.Now(), id,
+    )
+    if err != nil {
+        return err
+    }
+
+    // Update vehicle status back to Available
+    _, err = tx.Exec(
+        "UPDATE vehicles SET status = 'Available' WHERE id = $1",
+        vehicleID,
+    )
+    if err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return err
+    }
+
+    publishVehicleChange(r.DB, r.Events, vehicleID, "reservation_cancelled")
+    return nil
+}
+
+// CancelNoShows cancels every active reservation whose vehicle was never
+// unlocked within noShowGrace of the start, and returns them so their
+// no-show fee can be charged. Only reservations that still have an open
+// invoice to charge are cancelled, and any unlock that may have reached the
+// vehicle without being acknowledged keeps the trip alive for staff to sort
+// out.
+func (r *ReservationRepository) CancelNoShows() ([]models.Reservation, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    now := time.Now()
+    rows, err := tx.Query(`
+        UPDATE reservations r
+        SET status = 'Cancelled', sequence = sequence + 1, updated_at = $1
+        WHERE r.status = 'Active' AND r.tracks_pickup AND r.picked_up_at IS NULL AND r.start_time <= $2
+          AND EXISTS (
+              SELECT 1 FROM invoices i WHERE i.reservation_id = r.id AND i.status = $3
+          )
+          AND NOT EXISTS (
+              SELECT 1 FROM vehicle_commands c
+              WHERE c.reservation_id = r.id AND c.command = $4 AND c.status <> $5
+          )
+        RETURNING r.id, r.user_id, r.vehicle_id, r.start_time, r.end_time, r.status, r.organization_id, r.created_at, r.updated_at
+    `, now, now.Add(-noShowGrace), models.InvoiceOpen, models.CommandUnlock, models.CommandFailed)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var cancelled []models.Reservation
+    for rows.Next() {
+        var res models.Reservation
+        var organizationID sql.NullInt64
+        err := rows.Scan(
+            &res.ID, &res.UserID, &res.VehicleID, &res.StartTime, &res.EndTime, &res.Status,
+            &organizationID, &res.CreatedAt, &res.UpdatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        res.OrganizationID = nullInt(organizationID)
+        cancelled = append(cancelled, res)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    rows.Close()
+
+    for _, res := range cancelled {
+        if _, err := tx.Exec("UPDATE vehicles SET status = 'Available' WHERE id = $1", res.VehicleID); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    for _, res := range cancelled {
+        publishVehicleChange(r.DB, r.Events, res.VehicleID, "reservation_cancelled")
+    }
+    return cancelled, nil
+}
+
+// ExtendReservation moves the end of an ongoing trip to newEnd, provided the
+// vehicle is free until then and the whole rental stays within maxDuration.
+func (r *ReservationRepository) ExtendReservation(id int, userID int, newEnd time.Time, maxDuration time.Duration) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    // Lock the row so two extensions can't race each other
+    var vehicleID int
+    var status string
+    var startTime, endTime time.Time
+    err = tx.QueryRow(
+        "SELECT vehicle_id, status, start_time, end_time FROM reservations WHERE id = $1 AND user_id = $2 FOR UPDATE",
+        id, userID,
+    ).Scan(&vehicleID, &status, &startTime, &endTime)
+    if err == sql.ErrNoRows {
+        return ErrReservationNotFound
+    }
+    if err != nil {
+        return err
+    }
+
+    now := time.Now()
+    if status != "Active" || now.Before(startTime) || !now.Before(endTime) {
+        return ErrReservationNotOngoing
+    }
+
+    if !newEnd.After(endTime) {
+        return ErrInvalidExtension
+    }
+
+    if newEnd.Sub(startTime) > maxDuration {
+        return ErrExceedsTierLimit
+    }
+
+    conflict, err := findConflict(tx, vehicleID, endTime, newEnd, id, userID)
+    if err != nil {
+        return err
+    }
+    if conflict != nil {
+        return &ConflictError{Conflict: *conflict}
+    }
+
+    _, err = tx.Exec(
+        "UPDATE reservations SET end_time = $1, sequence = sequence + 1, updated_at = $2 WHERE id = $3",
+        newEnd, now, id,
+    )
+    if err != nil {
+        return err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return err
+    }
+
+    publishVehicleChange(r.DB, r.Events, vehicleID, "reservation_extended")
+    return nil
+}
+
+
+// CompleteReservation ends a trip when the vehicle is handed back, which may
+// be before the booked end time.
+func (r *ReservationRepository) CompleteReservation(id int, userID int) (*models.Reservation, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    var res models.Reservation
+    err = tx.QueryRow(
+        "SELECT id, user_id, vehicle_id, start_time, end_time, status FROM reservations WHERE id = $1 AND user_id = $2 FOR UPDATE",
+        id, userID,
+    ).Scan(&res.ID, &res.UserID, &res.VehicleID, &res.StartTime, &res.EndTime, &res.Status)
+    if err == sql.ErrNoRows {
+        return nil, ErrReservationNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    if res.Status != "Active" || now.Before(res.StartTime) {
+        return nil, ErrReservationNotOngoing
+    }
+
+    // The vehicle's last reported odometer and charge are the return readings
+    _, err = tx.Exec(`
+        UPDATE reservations r
+        SET status = 'Completed', returned_at = $1, updated_at = $1,
+            return_odometer_km = v.odometer_km, return_charge_level = v.charge_level,
+            return_location = v.location, return_cleanliness = v.cleanliness
+        FROM vehicles v
+        WHERE r.id = $2 AND v.id = r.vehicle_id
+    `, now, id)
+    if err != nil {
+        return nil, err
+    }
+
+    _, err = tx.Exec("UPDATE vehicles SET status = 'Available' WHERE id = $1", res.VehicleID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    res.Status = "Completed"
+    res.ReturnedAt = &now
+    publishVehicleChange(r.DB, r.Events, res.VehicleID, "reservation_completed")
+    return &res, nil
+}
+
+// GetTripUsage returns the pickup and return readings for a reservation
+func (r *ReservationRepository) GetTripUsage(id int) (*models.TripUsage, error) {
+    var usage models.TripUsage
+    var pickedUpAt, returnedAt sql.NullTime
+    var pickupKm, returnKm, pickupCharge, returnCharge sql.NullInt64
+    var pickupLocation, returnLocation, pickupCleanliness, returnCleanliness sql.NullString
+
+    err := r.DB.QueryRow(`
+        SELECT picked_up_at, returned_at, pickup_odometer_km, return_odometer_km,
+               pickup_charge_level, return_charge_level,
+               pickup_location, return_location, pickup_cleanliness, return_cleanliness
+        FROM reservations WHERE id = $1
+    `, id).Scan(
+        &pickedUpAt, &returnedAt, &pickupKm, &returnKm, &pickupCharge, &returnCharge,
+        &pickupLocation, &returnLocation, &pickupCleanliness, &returnCleanliness,
+    )
+    if err == sql.ErrNoRows {
+        return nil, ErrReservationNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if pickedUpAt.Valid {
+        usage.PickedUpAt = &pickedUpAt.Time
+    }
+    if returnedAt.Valid {
+        usage.ReturnedAt = &returnedAt.Time
+    }
+    usage.PickupOdometerKm = nullInt(pickupKm)
+    usage.ReturnOdometerKm = nullInt(returnKm)
+    usage.PickupChargeLevel = nullInt(pickupCharge)
+    usage.ReturnChargeLevel = nullInt(returnCharge)
+    usage.PickupLocation = pickupLocation.String
+    usage.ReturnLocation = returnLocation.String
+    usage.PickupCleanliness = pickupCleanliness.String
+    usage.ReturnCleanliness = returnCleanliness.String
+
+    return &usage, nil
+}
+
+func nullInt(n sql.NullInt64) *int {
+    if !n.Valid {
+        return nil
+    }
+    v := int(n.Int64)
+    return &v
+}
@@ -0,0 +1,246 @@
+// Path: services/vehicle-service/repository/series_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "vehicle-service/events"
+    "vehicle-service/models"
+)
+
+var (
+    ErrSeriesNotFound   = errors.New("reservation series not found or unauthorized")
+    ErrNoFreeOccurrence = errors.New("none of the occurrences could be booked")
+)
+
+type SeriesRepository struct {
+    DB     *sql.DB
+    Events *events.Broker
+}
+
+func NewSeriesRepository(db *sql.DB, broker *events.Broker) *SeriesRepository {
+    return &SeriesRepository{DB: db, Events: broker}
+}
+
+// CreateSeries books every occurrence that is free and reports the ones that
+// aren't. Each occurrence lasts as long as the series' first one. Nothing is
+// stored if no occurrence can be booked.
+func (r *SeriesRepository) CreateSeries(series *models.ReservationSeries, occurrences []time.Time) (*models.SeriesResult, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    _, err = tx.Exec("SELECT id FROM vehicles WHERE id = $1 FOR UPDATE", series.VehicleID)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    series.Status = "Active"
+    series.CreatedAt = now
+    series.UpdatedAt = now
+
+    err = tx.QueryRow(`
+        INSERT INTO reservation_series (user_id, vehicle_id, rrule, start_time, end_time, status, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+        RETURNING id
+    `, series.UserID, series.VehicleID, series.RRule, series.StartTime, series.EndTime, series.Status, now).Scan(&series.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    result := &models.SeriesResult{
+        Created:   []models.Reservation{},
+        Conflicts: []models.OccurrenceConflict{},
+    }
+    duration := series.EndTime.Sub(series.StartTime)
+
+    for _, start := range occurrences {
+        end := start.Add(duration)
+
+        if !start.After(now) {
+            result.Conflicts = append(result.Conflicts, models.OccurrenceConflict{
+                StartTime: start, EndTime: end, Reason: "occurrence is in the past",
+            })
+            continue
+        }
+
+        conflict, err := findConflict(tx, series.VehicleID, start, end, 0, series.UserID)
+        if err != nil {
+            return nil, err
+        }
+        if conflict != nil {
+            result.Conflicts = append(result.Conflicts, models.OccurrenceConflict{
+                StartTime: start, EndTime: end, Reason: (&ConflictError{Conflict: *conflict}).Error(),
+            })
+            continue
+        }
+
+        seriesID := series.ID
+        reservation := models.Reservation{
+            UserID:    series.UserID,
+            VehicleID: series.VehicleID,
+            StartTime: start,
+            EndTime:   end,
+            Status:    "Active",
+            SeriesID:  &seriesID,
+            CreatedAt: now,
+            UpdatedAt: now,
+        }
+        err = tx.QueryRow(`
+            INSERT INTO reservations (user_id, vehicle_id, start_time, end_time, status, series_id, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, 'Active', $5, $6, $6)
+            RETURNING id
+        `, reservation.UserID, reservation.VehicleID, start, end, series.ID, now).Scan(&reservation.ID)
+        if err != nil {
+            return nil, err
+        }
+        result.Created = append(result.Created, reservation)
+    }
+
+    if len(result.Created) == 0 {
+        return result, ErrNoFreeOccurrence
+    }
+
+    // Matches what CreateReservation does for a single booking
+    _, err = tx.Exec("UPDATE vehicles SET status = 'In-Use' WHERE id = $1", series.VehicleID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    result.Series = *series
+    publishVehicleChange(r.DB, r.Events, series.VehicleID, "reservation_created")
+    return result, nil
+}
+
+func (r *SeriesRepository) GetSeries(id int, userID int) (*models.ReservationSeries, error) {
+    var series models.ReservationSeries
+    err := r.DB.QueryRow(`
+        SELECT id, user_id, vehicle_id, rrule, start_time, end_time, status, created_at, updated_at
+        FROM reservation_series
+        WHERE id = $1 AND user_id = $2
+    `, id, userID).Scan(
+        &series.ID, &series.UserID, &series.VehicleID, &series.RRule, &series.StartTime,
+        &series.EndTime, &series.Status, &series.CreatedAt, &series.UpdatedAt,
+    )
+    if err == sql.ErrNoRows {
+        return nil, ErrSeriesNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err := r.DB.Query(`
+        SELECT id, user_id, vehicle_id, start_time, end_time, status, created_at, updated_at
+        FROM reservations
+        WHERE series_id = $1
+        ORDER BY start_time
+    `, id)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var res models.Reservation
+        err := rows.Scan(
+            &res.ID, &res.UserID, &res.VehicleID, &res.StartTime, &res.EndTime,
+            &res.Status, &res.CreatedAt, &res.UpdatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        res.SeriesID = &series.ID
+        series.Reservations = append(series.Reservations, res)
+    }
+
+    return &series, nil
+}
+
+// CancelSeries cancels every occurrence that hasn't started yet and returns
+// them. Past and ongoing occurrences are left alone.
+func (r *SeriesRepository) CancelSeries(id int, userID int) ([]models.Reservation, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    var vehicleID int
+    var status string
+    err = tx.QueryRow(
+        "SELECT vehicle_id, status FROM reservation_series WHERE id = $1 AND user_id = $2 FOR UPDATE",
+        id, userID,
+    ).Scan(&vehicleID, &status)
+    if err == sql.ErrNoRows {
+        return nil, ErrSeriesNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if status != "Active" {
+        return nil, errors.New("series is already cancelled")
+    }
+
+    now := time.Now()
+    rows, err := tx.Query(`
+        UPDATE reservations
+        SET status = 'Cancelled', sequence = sequence + 1, updated_at = $2
+        WHERE series_id = $1 AND status = 'Active' AND start_time > $2
+        RETURNING id, user_id, vehicle_id, start_time, end_time, status, created_at, updated_at
+    `, id, now)
+    if err != nil {
+        return nil, err
+    }
+
+    var cancelled []models.Reservation
+    for rows.Next() {
+        var res models.Reservation
+        err := rows.Scan(
+            &res.ID, &res.UserID, &res.VehicleID, &res.StartTime, &res.EndTime,
+            &res.Status, &res.CreatedAt, &res.UpdatedAt,
+        )
+        if err != nil {
+            rows.Close()
+            return nil, err
+        }
+        res.SeriesID = &id
+        cancelled = append(cancelled, res)
+    }
+    rows.Close()
+
+    _, err = tx.Exec(
+        "UPDATE reservation_series SET status = 'Cancelled', updated_at = $1 WHERE id = $2",
+        now, id,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    // Free the vehicle unless the user is still driving an earlier occurrence
+    _, err = tx.Exec(`
+        UPDATE vehicles SET status = 'Available'
+        WHERE id = $1 AND NOT EXISTS (
+            SELECT 1 FROM reservations WHERE vehicle_id = $1 AND status = 'Active'
+        )
+    `, vehicleID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    publishVehicleChange(r.DB, r.Events, vehicleID, "reservation_cancelled")
+    return cancelled, nil
+}
@@ -0,0 +1,285 @@
+// Path: services/vehicle-service/repository/statement_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "vehicle-service/models"
+)
+
+var ErrStatementNotFound = errors.New("statement not found")
+
+type StatementRepository struct {
+    DB *sql.DB
+}
+
+func NewStatementRepository(db *sql.DB) *StatementRepository {
+    return &StatementRepository{DB: db}
+}
+
+const statementColumns = `
+    id, user_id, month, trip_count, minutes, created_at
+`
+
+// UsersWithoutStatement returns the users who had an invoice issued in the
+// month starting at month but have no statement for it yet. Business
+// bookings are on their organization's bill instead and left out of
+// statements.
+func (r *StatementRepository) UsersWithoutStatement(month time.Time) ([]int, error) {
+    rows, err := r.DB.Query(`
+        SELECT DISTINCT i.user_id
+        FROM invoices i
+        WHERE i.issued_at >= $1 AND i.issued_at < $2 AND i.organization_id IS NULL
+          AND NOT EXISTS (
+              SELECT 1 FROM monthly_statements s
+              WHERE s.user_id = i.user_id AND s.month = $1
+          )
+        ORDER BY i.user_id
+    `, month, month.AddDate(0, 1, 0))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var userIDs []int
+    for rows.Next() {
+        var id int
+        if err := rows.Scan(&id); err != nil {
+            return nil, err
+        }
+        userIDs = append(userIDs, id)
+    }
+
+    return userIDs, nil
+}
+
+// GetCompletedTrips returns the user's trips whose invoice was issued in the
+// month, with the invoice lines summed into rental, savings and total
+func (r *StatementRepository) GetCompletedTrips(userID int, month time.Time) ([]models.StatementTrip, error) {
+    rows, err := r.DB.Query(`
+        SELECT r.id, i.id, v.model, v.type, COALESCE(r.picked_up_at, r.start_time), COALESCE(r.returned_at, r.end_time),
+               COALESCE(SUM(l.amount_cents) FILTER (WHERE l.kind IN ('rental', 'rental_adjustment')), 0),
+               COALESCE(-SUM(l.amount_cents) FILTER (WHERE l.kind = 'tier_discount'), 0),
+               COALESCE(-SUM(l.amount_cents) FILTER (WHERE l.kind = 'promotion'), 0),
+               i.currency, i.total_cents
+        FROM invoices i
+        JOIN reservations r ON r.id = i.reservation_id
+        JOIN vehicles v ON v.id = r.vehicle_id
+        LEFT JOIN invoice_lines l ON l.invoice_id = i.id
+        WHERE i.user_id = $1 AND r.status = 'Completed' AND i.organization_id IS NULL
+          AND i.issued_at >= $2 AND i.issued_at < $3
+        GROUP BY r.id, i.id, v.model, v.type
+        ORDER BY r.start_time
+    `, userID, month, month.AddDate(0, 1, 0))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    trips := []models.StatementTrip{}
+    for rows.Next() {
+        var t models.StatementTrip
+        err := rows.Scan(
+            &t.ReservationID, &t.InvoiceID, &t.VehicleModel, &t.VehicleType, &t.StartTime, &t.ReturnedAt,
+            &t.RentalCents, &t.TierSavingsCents, &t.PromoSavingsCents, &t.Currency, &t.TotalCents,
+        )
+        if err != nil {
+            return nil, err
+        }
+        t.Minutes = int(t.ReturnedAt.Sub(t.StartTime).Minutes())
+        trips = append(trips, t)
+    }
+
+    return trips, nil
+}
+
+// GetCancellationFees sums what the user was charged in the month for
+// reservations they cancelled or didn't show up for, per currency
+func (r *StatementRepository) GetCancellationFees(userID int, month time.Time) (map[string]int64, error) {
+    rows, err := r.DB.Query(`
+        SELECT i.currency, SUM(i.total_cents)
+        FROM invoices i
+        JOIN reservations r ON r.id = i.reservation_id
+        WHERE i.user_id = $1 AND r.status = 'Cancelled' AND i.organization_id IS NULL
+          AND i.issued_at >= $2 AND i.issued_at < $3
+        GROUP BY i.currency
+    `, userID, month, month.AddDate(0, 1, 0))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    fees := make(map[string]int64)
+    for rows.Next() {
+        var currency string
+        var cents int64
+        if err := rows.Scan(&currency, &cents); err != nil {
+            return nil, err
+        }
+        fees[currency] = cents
+    }
+
+    return fees, rows.Err()
+}
+
+// CreateStatement stores a statement and its trips. A statement that already
+// exists for the user and month is left alone.
+func (r *StatementRepository) CreateStatement(s *models.Statement) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    s.CreatedAt = time.Now()
+    err = tx.QueryRow(`
+        INSERT INTO monthly_statements (user_id, month, trip_count, minutes, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (user_id, month) DO NOTHING
+        RETURNING id
+    `, s.UserID, s.Month, s.TripCount, s.Minutes, s.CreatedAt,
+    ).Scan(&s.ID)
+    if err == sql.ErrNoRows {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    for _, t := range s.Totals {
+        _, err := tx.Exec(`
+            INSERT INTO statement_totals (statement_id, currency, trip_count, rental_cents, tier_savings_cents,
+                                          promo_savings_cents, fees_cents, total_cents)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        `, s.ID, t.Currency, t.TripCount, t.RentalCents, t.TierSavingsCents,
+            t.PromoSavingsCents, t.FeesCents, t.TotalCents)
+        if err != nil {
+            return err
+        }
+    }
+
+    for _, t := range s.Trips {
+        _, err := tx.Exec(`
+            INSERT INTO statement_trips (statement_id, reservation_id, invoice_id, vehicle_model, vehicle_type,
+                                         start_time, returned_at, minutes, currency, rental_cents,
+                                         tier_savings_cents, promo_savings_cents, total_cents)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        `, s.ID, t.ReservationID, t.InvoiceID, t.VehicleModel, t.VehicleType,
+            t.StartTime, t.ReturnedAt, t.Minutes, t.Currency, t.RentalCents,
+            t.TierSavingsCents, t.PromoSavingsCents, t.TotalCents)
+        if err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+// GetStatements lists the user's statements, newest first, without trips
+func (r *StatementRepository) GetStatements(userID int) ([]models.Statement, error) {
+    rows, err := r.DB.Query(
+        "SELECT "+statementColumns+" FROM monthly_statements WHERE user_id = $1 ORDER BY month DESC",
+        userID,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    statements := []models.Statement{}
+    for rows.Next() {
+        s, err := scanStatement(rows)
+        if err != nil {
+            return nil, err
+        }
+        statements = append(statements, *s)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    for i := range statements {
+        if statements[i].Totals, err = r.getTotals(statements[i].ID); err != nil {
+            return nil, err
+        }
+    }
+
+    return statements, nil
+}
+
+// GetStatement returns the user's statement for a month with its trips
+func (r *StatementRepository) GetStatement(userID int, month time.Time) (*models.Statement, error) {
+    s, err := scanStatement(r.DB.QueryRow(
+        "SELECT "+statementColumns+" FROM monthly_statements WHERE user_id = $1 AND month = $2",
+        userID, month,
+    ))
+    if err == sql.ErrNoRows {
+        return nil, ErrStatementNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if s.Totals, err = r.getTotals(s.ID); err != nil {
+        return nil, err
+    }
+
+    rows, err := r.DB.Query(`
+        SELECT reservation_id, invoice_id, vehicle_model, vehicle_type, start_time, returned_at, minutes,
+               currency, rental_cents, tier_savings_cents, promo_savings_cents, total_cents
+        FROM statement_trips WHERE statement_id = $1 ORDER BY start_time
+    `, s.ID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    s.Trips = []models.StatementTrip{}
+    for rows.Next() {
+        var t models.StatementTrip
+        err := rows.Scan(
+            &t.ReservationID, &t.InvoiceID, &t.VehicleModel, &t.VehicleType, &t.StartTime, &t.ReturnedAt, &t.Minutes,
+            &t.Currency, &t.RentalCents, &t.TierSavingsCents, &t.PromoSavingsCents, &t.TotalCents,
+        )
+        if err != nil {
+            return nil, err
+        }
+        s.Trips = append(s.Trips, t)
+    }
+
+    return s, nil
+}
+
+func (r *StatementRepository) getTotals(statementID int) ([]models.StatementTotal, error) {
+    rows, err := r.DB.Query(`
+        SELECT currency, trip_count, rental_cents, tier_savings_cents, promo_savings_cents, fees_cents, total_cents
+        FROM statement_totals WHERE statement_id = $1 ORDER BY currency
+    `, statementID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    totals := []models.StatementTotal{}
+    for rows.Next() {
+        var t models.StatementTotal
+        err := rows.Scan(&t.Currency, &t.TripCount, &t.RentalCents, &t.TierSavingsCents, &t.PromoSavingsCents, &t.FeesCents, &t.TotalCents)
+        if err != nil {
+            return nil, err
+        }
+        totals = append(totals, t)
+    }
+
+    return totals, rows.Err()
+}
+
+func scanStatement(row rowScanner) (*models.Statement, error) {
+    var s models.Statement
+    err := row.Scan(&s.ID, &s.UserID, &s.Month, &s.TripCount, &s.Minutes, &s.CreatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &s, nil
+}
@@ -0,0 +1,56 @@
+// Path: services/vehicle-service/repository/user_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+)
+
+var ErrNoVerifiedLicense = errors.New("a verified driver's license is required to book a vehicle")
+
+// UserRepository reads the users table owned by user-service. The vehicle
+// service never writes to it.
+type UserRepository struct {
+    DB *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+    return &UserRepository{DB: db}
+}
+
+func (r *UserRepository) GetUserEmail(userID int) (string, error) {
+    var email string
+    err := r.DB.QueryRow("SELECT email FROM users WHERE id = $1", userID).Scan(&email)
+    return email, err
+}
+
+
+func (r *UserRepository) GetMembershipTier(userID int) (string, error) {
+    var tier string
+    err := r.DB.QueryRow("SELECT membership_tier FROM users WHERE id = $1", userID).Scan(&tier)
+    return tier, err
+}
+
+// GetRole returns "user" or "staff"
+func (r *UserRepository) GetRole(userID int) (string, error) {
+    var role string
+    err := r.DB.QueryRow("SELECT role FROM users WHERE id = $1", userID).Scan(&role)
+    return role, err
+}
+
+// GetLicenseExpiry returns the expiry date of the user's most recently
+// approved driver's license. The license is valid through that whole day.
+func (r *UserRepository) GetLicenseExpiry(userID int) (time.Time, error) {
+    var expiresOn time.Time
+    err := r.DB.QueryRow(`
+        SELECT expires_on FROM driver_licenses
+        WHERE user_id = $1 AND status = 'Approved'
+        ORDER BY reviewed_at DESC
+        LIMIT 1
+    `, userID).Scan(&expiresOn)
+    if err == sql.ErrNoRows {
+        return time.Time{}, ErrNoVerifiedLicense
+    }
+    return expiresOn, err
+}
@@ -1,88 +1,244 @@
-// Path: services/vehicle-service/repository/vehicle_repository.go
-package repository
-
-import (
-    "database/sql"
-    "time"
-
-     "vehicle-service/models"
-)
-
-type VehicleRepository struct {
-    DB *sql.DB
-}
-
-func NewVehicleRepository(db *sql.DB) *VehicleRepository {
-    return &VehicleRepository{DB: db}
-}
-
-func (r *VehicleRepository) GetAvailableVehicles(startTime, endTime time.Time) ([]models.Vehicle, error) {
-    query := `
-        SELECT v.id, v.model, v.type, v.status, v.location, v.charge_level, v.cleanliness,
-               v.created_at, v.updated_at
-        FROM vehicles v
-        WHERE v.status = 'Available'
-        AND v.id NOT IN (
-            SELECT vehicle_id FROM reservations
-            WHERE status = 'Active'
-            AND (
-                (start_time <= $1 AND end_time >= $1)
-                OR (start_time <= $2 AND end_time >= $2)
-                OR (start_time >= $1 AND end_time <= $2)
-            )
-        )
-    `
-    
-    rows, err := r.DB.Query(query, startTime, endTime)
-    if err != nil {
-        return nil, err
-    }
-    defer rows.Close()
-
-    var vehicles []models.Vehicle
-    for rows.Next() {
-        var v models.Vehicle
-        err := rows.Scan(
-            &v.ID, &v.Model, &v.Type, &v.Status, &v.Location, 
-            &v.ChargeLevel, &v.Cleanliness, &v.CreatedAt, &v.UpdatedAt,
-        )
-        if err != nil {
-            return nil, err
-        }
-        vehicles = append(vehicles, v)
-    }
-
-    return vehicles, nil
-}
-
-func (r *VehicleRepository) GetVehicleByID(id int) (*models.Vehicle, error) {
-    query := `
-        SELECT id, model, type, status, location, charge_level, cleanliness,
-               created_at, updated_at
-        FROM vehicles WHERE id = $1
-    `
-    
-    var vehicle models.Vehicle
-    err := r.DB.QueryRow(query, id).Scan(
-        &vehicle.ID, &vehicle.Model, &vehicle.Type, &vehicle.Status,
-        &vehicle.Location, &vehicle.ChargeLevel, &vehicle.Cleanliness,
-        &vehicle.CreatedAt, &vehicle.UpdatedAt,
-    )
-    
-    if err != nil {
-        return nil, err
-    }
-    
-    return &vehicle, nil
-}
-
-func (r *VehicleRepository) UpdateVehicleStatus(vehicleID int, status string) error {
-    query := `
-        UPDATE vehicles 
-        SET status = $1, updated_at = $2
-        WHERE id = $3
-    `
-    
-    _, err := r.DB.Exec(query, status, time.Now(), vehicleID)
-    return err
-}
\ No newline at end of file
+// Path: services/vehicle-service/repository/vehicle_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+     "vehicle-service/events"
+     "vehicle-service/models"
+)
+
+var ErrVehicleNotFound = errors.New("vehicle not found")
+
+type VehicleRepository struct {
+    DB     *sql.DB
+    Events *events.Broker
+}
+
+func NewVehicleRepository(db *sql.DB, broker *events.Broker) *VehicleRepository {
+    return &VehicleRepository{DB: db, Events: broker}
+}
+
+// GetAvailableVehicles lists vehicles free for the whole window. Vehicles
+// held for other users (checkout holds, waitlist offers) are left out; the
+// caller's own holds are not.
+func (r *VehicleRepository) GetAvailableVehicles(startTime, endTime time.Time, userID int) ([]models.Vehicle, error) {
+    query := `
+        SELECT v.id, v.model, v.type, v.status, v.location, v.charge_level, v.cleanliness,
+               v.created_at, v.updated_at
+        FROM vehicles v
+        LEFT JOIN turnaround_buffers tb ON tb.vehicle_type = v.type
+        WHERE v.status = 'Available'
+        AND NOT EXISTS (
+            SELECT 1 FROM reservations r
+            WHERE r.vehicle_id = v.id
+            AND r.status = 'Active'
+            AND r.start_time - make_interval(mins => ` + turnaroundMinutes + `) < $2
+            AND r.end_time + make_interval(mins => ` + turnaroundMinutes + `) > $1
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM booking_holds h
+            WHERE h.vehicle_id = v.id
+            AND h.status = 'Held'
+            AND h.expires_at > $4
+            AND h.user_id <> $3
+            AND h.start_time - make_interval(mins => ` + turnaroundMinutes + `) < $2
+            AND h.end_time + make_interval(mins => ` + turnaroundMinutes + `) > $1
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM maintenance_windows m
+            WHERE m.vehicle_id = v.id
+            AND m.start_time < $2
+            AND m.end_time > $1
+        )
+    `
+    
+    rows, err := r.DB.Query(query, startTime, endTime, userID, time.Now())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var vehicles []models.Vehicle
+    for rows.Next() {
+        var v models.Vehicle
+        err := rows.Scan(
+            &v.ID, &v.Model, &v.Type, &v.Status, &v.Location, 
+            &v.ChargeLevel, &v.Cleanliness, &v.CreatedAt, &v.UpdatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        vehicles = append(vehicles, v)
+    }
+
+    return vehicles, nil
+}
+
+func (r *VehicleRepository) GetVehicleByID(id int) (*models.Vehicle, error) {
+    query := `
+        SELECT id, model, type, status, location, charge_level, cleanliness,
+               created_at, updated_at
+        FROM vehicles WHERE id = $1
+    `
+    
+    var vehicle models.Vehicle
+    err := r.DB.QueryRow(query, id).Scan(
+        &vehicle.ID, &vehicle.Model, &vehicle.Type, &vehicle.Status,
+        &vehicle.Location, &vehicle.ChargeLevel, &vehicle.Cleanliness,
+        &vehicle.CreatedAt, &vehicle.UpdatedAt,
+    )
+    
+    if err == sql.ErrNoRows {
+        return nil, ErrVehicleNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    
+    return &vehicle, nil
+}
+
+func (r *VehicleRepository) GetAllVehicles() ([]models.Vehicle, error) {
+    query := `
+        SELECT id, model, type, status, location, charge_level, cleanliness,
+               created_at, updated_at
+        FROM vehicles
+        ORDER BY id
+    `
+
+    rows, err := r.DB.Query(query)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var vehicles []models.Vehicle
+    for rows.Next() {
+        var v models.Vehicle
+        err := rows.Scan(
+            &v.ID, &v.Model, &v.Type, &v.Status, &v.Location,
+            &v.ChargeLevel, &v.Cleanliness, &v.CreatedAt, &v.UpdatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        vehicles = append(vehicles, v)
+    }
+
+    return vehicles, nil
+}
+
+func (r *VehicleRepository) UpdateVehicleStatus(vehicleID int, status string) error {
+    query := `
+        UPDATE vehicles 
+        SET status = $1, updated_at = $2
+        WHERE id = $3
+    `
+    
+    _, err := r.DB.Exec(query, status, time.Now(), vehicleID)
+    if err != nil {
+        return err
+    }
+
+    publishVehicleChange(r.DB, r.Events, vehicleID, "status_changed")
+    return nil
+}
+
+// UpdateVehicle applies a partial status report (status, charge, odometer, cleanliness, location)
+func (r *VehicleRepository) UpdateVehicle(vehicleID int, update models.VehicleStatusUpdate) error {
+    var setClause []string
+    var updateValues []interface{}
+    paramCount := 1
+
+    if update.Status != nil {
+        setClause = append(setClause, fmt.Sprintf("status = $%d", paramCount))
+        updateValues = append(updateValues, *update.Status)
+        paramCount++
+    }
+
+    if update.ChargeLevel != nil {
+        setClause = append(setClause, fmt.Sprintf("charge_level = $%d", paramCount))
+        updateValues = append(updateValues, *update.ChargeLevel)
+        paramCount++
+    }
+
+    if update.OdometerKm != nil {
+        setClause = append(setClause, fmt.Sprintf("odometer_km = $%d", paramCount))
+        updateValues = append(updateValues, *update.OdometerKm)
+        paramCount++
+    }
+
+    if update.Cleanliness != nil {
+        setClause = append(setClause, fmt.Sprintf("cleanliness = $%d", paramCount))
+        updateValues = append(updateValues, *update.Cleanliness)
+        paramCount++
+    }
+
+    if update.Location != nil {
+        setClause = append(setClause, fmt.Sprintf("location = $%d", paramCount))
+        updateValues = append(updateValues, *update.Location)
+        paramCount++
+    }
+
+    if len(setClause) == 0 {
+        return errors.New("no updates provided")
+    }
+
+    setClause = append(setClause, fmt.Sprintf("updated_at = $%d", paramCount))
+    updateValues = append(updateValues, time.Now())
+    paramCount++
+
+    query := fmt.Sprintf("UPDATE vehicles SET %s WHERE id = $%d",
+        strings.Join(setClause, ", "),
+        paramCount)
+    updateValues = append(updateValues, vehicleID)
+
+    result, err := r.DB.Exec(query, updateValues...)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return errors.New("vehicle not found")
+    }
+
+    publishVehicleChange(r.DB, r.Events, vehicleID, "status_reported")
+    return nil
+}
+
+// publishVehicleChange reloads a vehicle after a committed write and pushes it
+// to live status subscribers. Failures are only logged; the write already
+// succeeded.
+func publishVehicleChange(db *sql.DB, broker *events.Broker, vehicleID int, reason string) {
+    if broker == nil {
+        return
+    }
+
+    var event models.VehicleEvent
+    err := db.QueryRow(`
+        SELECT id, model, type, status, location, charge_level, cleanliness
+        FROM vehicles WHERE id = $1
+    `, vehicleID).Scan(
+        &event.VehicleID, &event.Model, &event.Type, &event.Status,
+        &event.Location, &event.ChargeLevel, &event.Cleanliness,
+    )
+    if err != nil {
+        log.Printf("Failed to load vehicle %d for live update: %v", vehicleID, err)
+        return
+    }
+
+    event.Available = event.Status == "Available"
+    event.Reason = reason
+    event.At = time.Now()
+    broker.Publish(event)
+}
@@ -0,0 +1,338 @@
+// Path: services/vehicle-service/repository/waitlist_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "time"
+
+    "vehicle-service/events"
+    "vehicle-service/models"
+)
+
+// How long a freed vehicle is held for the waitlisted user it was offered to
+const waitlistOfferHold = 15 * time.Minute
+
+var (
+    ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+    ErrNoActiveOffer         = errors.New("no active offer on this waitlist entry")
+)
+
+type WaitlistRepository struct {
+    DB     *sql.DB
+    Events *events.Broker
+}
+
+func NewWaitlistRepository(db *sql.DB, broker *events.Broker) *WaitlistRepository {
+    return &WaitlistRepository{DB: db, Events: broker}
+}
+
+const waitlistColumns = `
+    id, user_id, start_time, end_time, vehicle_type, zone, status,
+    offered_vehicle_id, offer_expires_at, hold_id, reservation_id, created_at, updated_at
+`
+
+func (r *WaitlistRepository) JoinWaitlist(entry *models.WaitlistEntry) error {
+    now := time.Now()
+    entry.Status = models.WaitlistWaiting
+    entry.CreatedAt = now
+    entry.UpdatedAt = now
+
+    query := `
+        INSERT INTO waitlist_entries (user_id, start_time, end_time, vehicle_type, zone, status, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+        RETURNING id
+    `
+    return r.DB.QueryRow(
+        query,
+        entry.UserID,
+        entry.StartTime,
+        entry.EndTime,
+        entry.VehicleType,
+        entry.Zone,
+        entry.Status,
+        now,
+    ).Scan(&entry.ID)
+}
+
+func (r *WaitlistRepository) GetUserEntries(userID int) ([]models.WaitlistEntry, error) {
+    rows, err := r.DB.Query(
+        "SELECT "+waitlistColumns+" FROM waitlist_entries WHERE user_id = $1 ORDER BY created_at DESC",
+        userID,
+    )
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var entries []models.WaitlistEntry
+    for rows.Next() {
+        entry, err := scanWaitlistEntry(rows)
+        if err != nil {
+            return nil, err
+        }
+        entries = append(entries, *entry)
+    }
+
+    return entries, nil
+}
+
+// CancelEntry removes the user from the waitlist. If they were holding an
+// offer, the hold is released and the entry is returned as it was so the
+// vehicle can go to the next in line.
+func (r *WaitlistRepository) CancelEntry(id int, userID int) (*models.WaitlistEntry, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    entry, err := scanWaitlistEntry(tx.QueryRow(
+        "SELECT "+waitlistColumns+" FROM waitlist_entries WHERE id = $1 AND user_id = $2 AND status IN ('Waiting', 'Offered') FOR UPDATE",
+        id, userID,
+    ))
+    if err == sql.ErrNoRows {
+        return nil, ErrWaitlistEntryNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    _, err = tx.Exec("UPDATE waitlist_entries SET status = 'Cancelled', updated_at = $1 WHERE id = $2", now, id)
+    if err != nil {
+        return nil, err
+    }
+
+    if entry.HoldID != nil {
+        _, err = tx.Exec(
+            "UPDATE booking_holds SET status = 'Released', updated_at = $1 WHERE id = $2 AND status = 'Held'",
+            now, *entry.HoldID,
+        )
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    return entry, nil
+}
+
+// OfferVehicle gives a vehicle that just became free to the longest-waiting
+// user whose request it can serve, holding it for them for a limited time.
+// Only entries overlapping [freedFrom, freedTo) are considered. Returns nil
+// when nobody matches.
+func (r *WaitlistRepository) OfferVehicle(vehicleID int, freedFrom, freedTo time.Time) (*models.WaitlistEntry, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    var vehicleType, location, status string
+    err = tx.QueryRow(
+        "SELECT type, location, status FROM vehicles WHERE id = $1 FOR UPDATE",
+        vehicleID,
+    ).Scan(&vehicleType, &location, &status)
+    if err != nil {
+        return nil, err
+    }
+    if status != "Available" {
+        return nil, nil
+    }
+
+    now := time.Now()
+    rows, err := tx.Query(`
+        SELECT `+waitlistColumns+` FROM waitlist_entries
+        WHERE status = 'Waiting'
+        AND start_time < $2
+        AND end_time > $1
+        AND start_time > $3
+        AND (vehicle_type IS NULL OR vehicle_type = $4)
+        AND (zone IS NULL OR zone = $5)
+        ORDER BY created_at
+        FOR UPDATE SKIP LOCKED
+    `, freedFrom, freedTo, now, vehicleType, location)
+    if err != nil {
+        return nil, err
+    }
+
+    var candidates []models.WaitlistEntry
+    for rows.Next() {
+        entry, err := scanWaitlistEntry(rows)
+        if err != nil {
+            rows.Close()
+            return nil, err
+        }
+        candidates = append(candidates, *entry)
+    }
+    rows.Close()
+
+    for _, entry := range candidates {
+        hold, err := insertHold(tx, entry.UserID, vehicleID, entry.StartTime, entry.EndTime, waitlistOfferHold)
+        var conflictErr *ConflictError
+        if errors.As(err, &conflictErr) {
+            // The vehicle is busy for this user's window, try the next one
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+
+        expiresAt := hold.ExpiresAt
+        _, err = tx.Exec(`
+            UPDATE waitlist_entries
+            SET status = 'Offered', offered_vehicle_id = $1, offer_expires_at = $2, hold_id = $3, updated_at = $4
+            WHERE id = $5
+        `, vehicleID, expiresAt, hold.ID, now, entry.ID)
+        if err != nil {
+            return nil, err
+        }
+
+        if err := tx.Commit(); err != nil {
+            return nil, err
+        }
+
+        entry.Status = models.WaitlistOffered
+        entry.OfferedVehicleID = &vehicleID
+        entry.OfferExpiresAt = &expiresAt
+        entry.HoldID = &hold.ID
+        entry.UpdatedAt = now
+        publishVehicleChange(r.DB, r.Events, vehicleID, "waitlist_offer")
+        return &entry, nil
+    }
+
+    return nil, nil
+}
+
+// AcceptOffer turns a held offer into a reservation
+func (r *WaitlistRepository) AcceptOffer(id int, userID int) (*models.Reservation, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    entry, err := scanWaitlistEntry(tx.QueryRow(
+        "SELECT "+waitlistColumns+" FROM waitlist_entries WHERE id = $1 AND user_id = $2 FOR UPDATE",
+        id, userID,
+    ))
+    if err == sql.ErrNoRows {
+        return nil, ErrWaitlistEntryNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if entry.Status != models.WaitlistOffered || entry.HoldID == nil {
+        return nil, ErrNoActiveOffer
+    }
+
+    reservation, err := confirmHold(tx, *entry.HoldID, userID)
+    if err == ErrHoldNotActive {
+        return nil, ErrNoActiveOffer
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    _, err = tx.Exec(
+        "UPDATE waitlist_entries SET status = 'Accepted', reservation_id = $1, updated_at = $2 WHERE id = $3",
+        reservation.ID, now, id,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    publishVehicleChange(r.DB, r.Events, reservation.VehicleID, "reservation_created")
+    return reservation, nil
+}
+
+// ExpireOffers closes offers nobody took up in time and returns them so their
+// vehicles can be offered to the next user.
+func (r *WaitlistRepository) ExpireOffers() ([]models.WaitlistEntry, error) {
+    rows, err := r.DB.Query(`
+        UPDATE waitlist_entries
+        SET status = 'Expired', updated_at = $1
+        WHERE status = 'Offered' AND offer_expires_at <= $1
+        RETURNING `+waitlistColumns, time.Now())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var expired []models.WaitlistEntry
+    for rows.Next() {
+        entry, err := scanWaitlistEntry(rows)
+        if err != nil {
+            return nil, err
+        }
+        expired = append(expired, *entry)
+    }
+
+    return expired, nil
+}
+
+// ExpireStaleEntries drops waiting entries whose window has already started
+func (r *WaitlistRepository) ExpireStaleEntries() (int64, error) {
+    result, err := r.DB.Exec(
+        "UPDATE waitlist_entries SET status = 'Expired', updated_at = $1 WHERE status = 'Waiting' AND start_time <= $1",
+        time.Now(),
+    )
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
+
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+func scanWaitlistEntry(row rowScanner) (*models.WaitlistEntry, error) {
+    var entry models.WaitlistEntry
+    var vehicleType, zone sql.NullString
+    var offeredVehicleID, holdID, reservationID sql.NullInt64
+    var offerExpiresAt sql.NullTime
+
+    err := row.Scan(
+        &entry.ID, &entry.UserID, &entry.StartTime, &entry.EndTime, &vehicleType, &zone,
+        &entry.Status, &offeredVehicleID, &offerExpiresAt, &holdID, &reservationID,
+        &entry.CreatedAt, &entry.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if vehicleType.Valid {
+        entry.VehicleType = &vehicleType.String
+    }
+    if zone.Valid {
+        entry.Zone = &zone.String
+    }
+    if offeredVehicleID.Valid {
+        id := int(offeredVehicleID.Int64)
+        entry.OfferedVehicleID = &id
+    }
+    if offerExpiresAt.Valid {
+        entry.OfferExpiresAt = &offerExpiresAt.Time
+    }
+    if holdID.Valid {
+        id := int(holdID.Int64)
+        entry.HoldID = &id
+    }
+    if reservationID.Valid {
+        id := int(reservationID.Int64)
+        entry.ReservationID = &id
+    }
+
+    return &entry, nil
+}
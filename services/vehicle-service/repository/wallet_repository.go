@@ -0,0 +1,272 @@
+// Path: services/vehicle-service/repository/wallet_repository.go
+package repository
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "vehicle-service/models"
+)
+
+var ErrUnbalancedTransaction = errors.New("ledger entries do not sum to zero")
+
+// WalletRepository keeps users' prepaid balances on a double-entry ledger.
+// Money only ever moves between accounts, so every wallet movement has a
+// matching entry on one of the service's own accounts.
+type WalletRepository struct {
+    DB *sql.DB
+}
+
+func NewWalletRepository(db *sql.DB) *WalletRepository {
+    return &WalletRepository{DB: db}
+}
+
+type ledgerEntry struct {
+    accountID   int
+    amountCents int64
+}
+
+func (r *WalletRepository) GetWallet(userID int, limit int) (*models.Wallet, error) {
+    wallet := &models.Wallet{UserID: userID, Transactions: []models.WalletTransaction{}}
+
+    err := r.DB.QueryRow(`
+        SELECT COALESCE(SUM(e.amount_cents), 0)
+        FROM ledger_entries e
+        JOIN ledger_accounts a ON a.id = e.account_id
+        WHERE a.user_id = $1 AND a.kind = 'wallet'
+    `, userID).Scan(&wallet.BalanceCents)
+    if err != nil {
+        return nil, err
+    }
+
+    rows, err := r.DB.Query(`
+        SELECT t.id, t.kind, t.description, e.amount_cents, t.created_at
+        FROM ledger_entries e
+        JOIN ledger_accounts a ON a.id = e.account_id
+        JOIN ledger_transactions t ON t.id = e.transaction_id
+        WHERE a.user_id = $1 AND a.kind = 'wallet'
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT $2
+    `, userID, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var t models.WalletTransaction
+        if err := rows.Scan(&t.ID, &t.Kind, &t.Description, &t.AmountCents, &t.CreatedAt); err != nil {
+            return nil, err
+        }
+        wallet.Transactions = append(wallet.Transactions, t)
+    }
+
+    return wallet, nil
+}
+
+// TopUp credits money the user paid through the payment provider
+func (r *WalletRepository) TopUp(userID int, amountCents int64, paymentReference string) error {
+    return r.transfer(userID, models.AccountPaymentsClearing, amountCents, models.LedgerTopUp,
+        "Wallet top-up", paymentReference, nil)
+}
+
+// IssueCredit gives the user goodwill credit on behalf of a staff member
+func (r *WalletRepository) IssueCredit(userID int, amountCents int64, reason string, staffID int) error {
+    return r.transfer(userID, models.AccountGoodwill, amountCents, models.LedgerGoodwill,
+        reason, "", &staffID)
+}
+
+// Spend takes up to maxCents from the wallet towards a reservation and
+// returns how much was taken. The wallet never goes below zero. Spending is
+// once per reservation: if the wallet already paid towards it, that amount
+// is returned and nothing more is taken, so a settlement can be retried.
+func (r *WalletRepository) Spend(userID int, maxCents int64, reservationID int) (int64, error) {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    wallet, err := ledgerAccount(tx, &userID, models.AccountWallet)
+    if err != nil {
+        return 0, err
+    }
+
+    spent, err := spentOnReservation(tx, wallet, reservationID)
+    if err != nil {
+        return 0, err
+    }
+    if spent > 0 {
+        return spent, nil
+    }
+
+    var balance int64
+    err = tx.QueryRow("SELECT COALESCE(SUM(amount_cents), 0) FROM ledger_entries WHERE account_id = $1", wallet).Scan(&balance)
+    if err != nil {
+        return 0, err
+    }
+
+    amount := maxCents
+    if balance < amount {
+        amount = balance
+    }
+    if amount <= 0 {
+        return 0, nil
+    }
+
+    revenue, err := ledgerAccount(tx, nil, models.AccountRevenue)
+    if err != nil {
+        return 0, err
+    }
+
+    err = postTransaction(tx, models.LedgerSpend, fmt.Sprintf("Reservation #%d", reservationID),
+        reservationReference(reservationID), nil,
+        []ledgerEntry{{wallet, -amount}, {revenue, amount}})
+    if err != nil {
+        return 0, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return 0, err
+    }
+    return amount, nil
+}
+
+// ReverseSpend puts back whatever the wallet paid towards a reservation,
+// for when the rest of the payment couldn't be collected
+func (r *WalletRepository) ReverseSpend(userID int, reservationID int) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    wallet, err := ledgerAccount(tx, &userID, models.AccountWallet)
+    if err != nil {
+        return err
+    }
+
+    spent, err := spentOnReservation(tx, wallet, reservationID)
+    if err != nil {
+        return err
+    }
+    if spent <= 0 {
+        return nil
+    }
+
+    revenue, err := ledgerAccount(tx, nil, models.AccountRevenue)
+    if err != nil {
+        return err
+    }
+
+    err = postTransaction(tx, models.LedgerSpendReversal, fmt.Sprintf("Reservation #%d, payment not completed", reservationID),
+        reservationReference(reservationID), nil,
+        []ledgerEntry{{wallet, spent}, {revenue, -spent}})
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// spentOnReservation is what the wallet has paid towards the reservation
+// net of reversals. The wallet account must already be locked.
+func spentOnReservation(tx *sql.Tx, wallet int, reservationID int) (int64, error) {
+    var spent int64
+    err := tx.QueryRow(`
+        SELECT COALESCE(-SUM(e.amount_cents), 0)
+        FROM ledger_entries e
+        JOIN ledger_transactions t ON t.id = e.transaction_id
+        WHERE e.account_id = $1 AND t.reference = $2 AND t.kind IN ($3, $4)
+    `, wallet, reservationReference(reservationID), models.LedgerSpend, models.LedgerSpendReversal).Scan(&spent)
+    return spent, err
+}
+
+func reservationReference(reservationID int) string {
+    return fmt.Sprintf("reservation:%d", reservationID)
+}
+
+// transfer moves amountCents into the user's wallet from a system account
+func (r *WalletRepository) transfer(userID int, fromKind string, amountCents int64, kind string, description string, reference string, createdBy *int) error {
+    tx, err := r.DB.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    wallet, err := ledgerAccount(tx, &userID, models.AccountWallet)
+    if err != nil {
+        return err
+    }
+    from, err := ledgerAccount(tx, nil, fromKind)
+    if err != nil {
+        return err
+    }
+
+    err = postTransaction(tx, kind, description, reference, createdBy,
+        []ledgerEntry{{wallet, amountCents}, {from, -amountCents}})
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// ledgerAccount returns the account's id, creating user accounts on first use.
+// User accounts are locked so balance checks and postings are serialized;
+// the shared system accounts are not, as their balance is never checked.
+func ledgerAccount(tx *sql.Tx, userID *int, kind string) (int, error) {
+    var id int
+    if userID == nil {
+        err := tx.QueryRow("SELECT id FROM ledger_accounts WHERE user_id IS NULL AND kind = $1", kind).Scan(&id)
+        return id, err
+    }
+
+    _, err := tx.Exec(
+        "INSERT INTO ledger_accounts (user_id, kind) VALUES ($1, $2) ON CONFLICT (user_id, kind) DO NOTHING",
+        *userID, kind,
+    )
+    if err != nil {
+        return 0, err
+    }
+
+    err = tx.QueryRow(
+        "SELECT id FROM ledger_accounts WHERE user_id = $1 AND kind = $2 FOR UPDATE",
+        *userID, kind,
+    ).Scan(&id)
+    return id, err
+}
+
+func postTransaction(tx *sql.Tx, kind string, description string, reference string, createdBy *int, entries []ledgerEntry) error {
+    var sum int64
+    for _, e := range entries {
+        sum += e.amountCents
+    }
+    if sum != 0 {
+        return ErrUnbalancedTransaction
+    }
+
+    var transactionID int
+    err := tx.QueryRow(`
+        INSERT INTO ledger_transactions (kind, description, reference, created_by, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `, kind, description, reference, createdBy, time.Now()).Scan(&transactionID)
+    if err != nil {
+        return err
+    }
+
+    for _, e := range entries {
+        _, err := tx.Exec(
+            "INSERT INTO ledger_entries (transaction_id, account_id, amount_cents) VALUES ($1, $2, $3)",
+            transactionID, e.accountID, e.amountCents,
+        )
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
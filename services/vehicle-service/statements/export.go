@@ -0,0 +1,148 @@
+// Path: services/vehicle-service/statements/export.go
+package statements
+
+import (
+    "bytes"
+    "encoding/csv"
+    "fmt"
+    "strconv"
+
+    "vehicle-service/billing"
+    "vehicle-service/models"
+    "vehicle-service/pdf"
+)
+
+const tripTimeFormat = "02 Jan 15:04"
+
+// CSV lists one row per trip followed by the month's totals in each
+// currency, with amounts in minor units so spreadsheets can sum them
+// without parsing
+func CSV(s *models.Statement) []byte {
+    var buf bytes.Buffer
+    w := csv.NewWriter(&buf)
+
+    w.Write([]string{
+        "reservation_id", "invoice_id", "vehicle", "type", "start_time", "returned_at", "minutes",
+        "currency", "rental_cents", "tier_savings_cents", "promo_savings_cents", "total_cents",
+    })
+    for _, t := range s.Trips {
+        w.Write([]string{
+            strconv.Itoa(t.ReservationID),
+            strconv.Itoa(t.InvoiceID),
+            t.VehicleModel,
+            t.VehicleType,
+            t.StartTime.Format("2006-01-02T15:04:05"),
+            t.ReturnedAt.Format("2006-01-02T15:04:05"),
+            strconv.Itoa(t.Minutes),
+            t.Currency,
+            strconv.FormatInt(t.RentalCents, 10),
+            strconv.FormatInt(t.TierSavingsCents, 10),
+            strconv.FormatInt(t.PromoSavingsCents, 10),
+            strconv.FormatInt(t.TotalCents, 10),
+        })
+    }
+
+    w.Write([]string{})
+    w.Write([]string{"trips", strconv.Itoa(s.TripCount)})
+    w.Write([]string{"minutes", strconv.Itoa(s.Minutes)})
+
+    w.Write([]string{})
+    w.Write([]string{
+        "currency", "trips", "rental_cents", "tier_savings_cents", "promo_savings_cents", "fees_cents", "total_cents",
+    })
+    for _, t := range s.Totals {
+        w.Write([]string{
+            t.Currency,
+            strconv.Itoa(t.TripCount),
+            strconv.FormatInt(t.RentalCents, 10),
+            strconv.FormatInt(t.TierSavingsCents, 10),
+            strconv.FormatInt(t.PromoSavingsCents, 10),
+            strconv.FormatInt(t.FeesCents, 10),
+            strconv.FormatInt(t.TotalCents, 10),
+        })
+    }
+
+    w.Flush()
+    return buf.Bytes()
+}
+
+// PDF lays the statement out on A4: the month's summary first, then a table
+// of trips
+func PDF(s *models.Statement, email string) []byte {
+    const (
+        left   = 50.0
+        right  = pdf.PageWidth - 50
+        bottom = pdf.PageHeight - 60
+    )
+
+    doc := pdf.New()
+    y := 70.0
+
+    doc.Text(left, y, pdf.Bold, 20, "Monthly statement")
+    doc.Text(right-130, y, pdf.Bold, 11, "CNAD Car Sharing")
+    y += 30
+
+    doc.Text(left, y, pdf.Regular, 11, fmt.Sprintf("%s  -  %s", s.Month.Format("January 2006"), email))
+    y += 30
+
+    doc.Text(left, y, pdf.Regular, 10, "Trips")
+    doc.TextRight(right, y, 10, strconv.Itoa(s.TripCount))
+    y += 15
+    doc.Text(left, y, pdf.Regular, 10, "Time driven")
+    doc.TextRight(right, y, 10, formatMinutes(s.Minutes))
+    y += 20
+
+    // One summary per currency the user was billed in
+    for _, t := range s.Totals {
+        amount := func(label string, cents int64) {
+            doc.Text(left, y, pdf.Regular, 10, label)
+            doc.TextRight(right, y, 10, billing.FormatAmount(cents, t.Currency))
+            y += 15
+        }
+
+        doc.Text(left, y, pdf.Bold, 10, fmt.Sprintf("In %s (%d trips)", t.Currency, t.TripCount))
+        y += 15
+        amount("Rental", t.RentalCents)
+        amount("Saved with your membership", -t.TierSavingsCents)
+        amount("Saved with promotions", -t.PromoSavingsCents)
+        amount("Cancellation fees", t.FeesCents)
+        doc.Line(right-150, y-8, right, y-8)
+        y += 4
+        doc.Text(left, y, pdf.Bold, 11, "Total ("+t.Currency+")")
+        doc.TextRight(right, y, 11, billing.FormatAmount(t.TotalCents, t.Currency))
+        y += 25
+    }
+    y += 10
+
+    tableHeader := func() {
+        doc.Text(left, y, pdf.Bold, 10, "Trip")
+        doc.Text(left+50, y, pdf.Bold, 10, "Vehicle")
+        doc.Text(left+200, y, pdf.Bold, 10, "From")
+        doc.Text(left+300, y, pdf.Bold, 10, "Duration")
+        doc.Text(right-40, y, pdf.Bold, 10, "Total")
+        y += 6
+        doc.Line(left, y, right, y)
+        y += 16
+    }
+
+    tableHeader()
+    for _, t := range s.Trips {
+        if y > bottom {
+            doc.AddPage()
+            y = 70
+            tableHeader()
+        }
+        doc.Text(left, y, pdf.Regular, 10, fmt.Sprintf("#%d", t.ReservationID))
+        doc.Text(left+50, y, pdf.Regular, 10, t.VehicleModel)
+        doc.Text(left+200, y, pdf.Regular, 10, t.StartTime.Format(tripTimeFormat))
+        doc.Text(left+300, y, pdf.Regular, 10, formatMinutes(t.Minutes))
+        doc.TextRight(right, y, 10, billing.FormatAmount(t.TotalCents, t.Currency)+" "+t.Currency)
+        y += 15
+    }
+
+    return doc.Bytes()
+}
+
+func formatMinutes(minutes int) string {
+    return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,108 @@
+// Path: services/vehicle-service/statements/statements.go
+package statements
+
+import (
+    "log"
+    "sort"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/repository"
+)
+
+// MonthFormat is how months appear in URLs and file names, e.g. 2024-03
+const MonthFormat = "2006-01"
+
+// Service builds each user's monthly statement once the month is over
+type Service struct {
+    Repo     *repository.StatementRepository
+    UserRepo *repository.UserRepository
+}
+
+func NewService(sRepo *repository.StatementRepository, uRepo *repository.UserRepository) *Service {
+    return &Service{Repo: sRepo, UserRepo: uRepo}
+}
+
+// MonthStart returns the first instant of t's month
+func MonthStart(t time.Time) time.Time {
+    return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// BuildPreviousMonth stores statements for last month for every user who was
+// billed in it. Users that already have one are skipped, so the job can run
+// as often as it likes.
+func (s *Service) BuildPreviousMonth() error {
+    month := MonthStart(time.Now()).AddDate(0, -1, 0)
+
+    userIDs, err := s.Repo.UsersWithoutStatement(month)
+    if err != nil {
+        return err
+    }
+
+    for _, userID := range userIDs {
+        if _, err := s.Build(userID, month); err != nil {
+            log.Printf("Statements: failed to build %s for user %d: %v", month.Format(MonthFormat), userID, err)
+        }
+    }
+
+    return nil
+}
+
+// Build sums up the user's trips and fees for the month and stores the result
+func (s *Service) Build(userID int, month time.Time) (*models.Statement, error) {
+    trips, err := s.Repo.GetCompletedTrips(userID, month)
+    if err != nil {
+        return nil, err
+    }
+
+    fees, err := s.Repo.GetCancellationFees(userID, month)
+    if err != nil {
+        return nil, err
+    }
+
+    statement := &models.Statement{
+        UserID:    userID,
+        Month:     month,
+        TripCount: len(trips),
+        Trips:     trips,
+    }
+
+    // Amounts are only ever added up within one currency
+    byCurrency := make(map[string]*models.StatementTotal)
+    total := func(currency string) *models.StatementTotal {
+        t, ok := byCurrency[currency]
+        if !ok {
+            t = &models.StatementTotal{Currency: currency}
+            byCurrency[currency] = t
+        }
+        return t
+    }
+    for _, trip := range trips {
+        statement.Minutes += trip.Minutes
+        t := total(trip.Currency)
+        t.TripCount++
+        t.RentalCents += trip.RentalCents
+        t.TierSavingsCents += trip.TierSavingsCents
+        t.PromoSavingsCents += trip.PromoSavingsCents
+        t.TotalCents += trip.TotalCents
+    }
+    for currency, cents := range fees {
+        t := total(currency)
+        t.FeesCents += cents
+        t.TotalCents += cents
+    }
+
+    statement.Totals = []models.StatementTotal{}
+    for _, t := range byCurrency {
+        statement.Totals = append(statement.Totals, *t)
+    }
+    sort.Slice(statement.Totals, func(i, j int) bool {
+        return statement.Totals[i].Currency < statement.Totals[j].Currency
+    })
+
+    if err := s.Repo.CreateStatement(statement); err != nil {
+        return nil, err
+    }
+
+    return statement, nil
+}
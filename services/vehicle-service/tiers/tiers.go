@@ -0,0 +1,51 @@
+// Path: services/vehicle-service/tiers/tiers.go
+package tiers
+
+import (
+    "time"
+)
+
+const (
+    Basic   = "Basic"
+    Premium = "Premium"
+    VIP     = "VIP"
+)
+
+// Policy holds what a membership tier is allowed and what it pays
+type Policy struct {
+    Tier              string
+    MaxRentalDuration time.Duration
+    DiscountPercent   int
+}
+
+var policies = map[string]Policy{
+    Basic: {
+        Tier:              Basic,
+        MaxRentalDuration: 8 * time.Hour,
+        DiscountPercent:   0,
+    },
+    Premium: {
+        Tier:              Premium,
+        MaxRentalDuration: 24 * time.Hour,
+        DiscountPercent:   10,
+    },
+    VIP: {
+        Tier:              VIP,
+        MaxRentalDuration: 72 * time.Hour,
+        DiscountPercent:   20,
+    },
+}
+
+// For returns the policy for a tier. Unknown tiers get Basic.
+func For(tier string) Policy {
+    if p, ok := policies[tier]; ok {
+        return p
+    }
+    return policies[Basic]
+}
+
+// Valid reports whether tier is one of the known tiers
+func Valid(tier string) bool {
+    _, ok := policies[tier]
+    return ok
+}
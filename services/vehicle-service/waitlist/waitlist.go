@@ -0,0 +1,92 @@
+// Path: services/vehicle-service/waitlist/waitlist.go
+package waitlist
+
+import (
+    "fmt"
+    "log"
+    "time"
+
+    "vehicle-service/models"
+    "vehicle-service/notifications"
+    "vehicle-service/repository"
+)
+
+// Service hands freed vehicles to waitlisted users and tells them about it
+type Service struct {
+    Repo     *repository.WaitlistRepository
+    UserRepo *repository.UserRepository
+    Mailer   notifications.Mailer
+}
+
+func NewService(wRepo *repository.WaitlistRepository, uRepo *repository.UserRepository, mailer notifications.Mailer) *Service {
+    return &Service{Repo: wRepo, UserRepo: uRepo, Mailer: mailer}
+}
+
+// VehicleFreed is called whenever a vehicle's time becomes free, e.g. after a
+// cancellation or an early return. It runs in the background so the caller's
+// request isn't held up.
+func (s *Service) VehicleFreed(vehicleID int, from, to time.Time) {
+    go func() {
+        if err := s.offer(vehicleID, from, to); err != nil {
+            log.Printf("Waitlist: failed to offer vehicle %d: %v", vehicleID, err)
+        }
+    }()
+}
+
+// ExpireOffers passes every lapsed offer on to the next user in line
+func (s *Service) ExpireOffers() error {
+    expired, err := s.Repo.ExpireOffers()
+    if err != nil {
+        return err
+    }
+
+    for _, entry := range expired {
+        if entry.OfferedVehicleID == nil {
+            continue
+        }
+        if err := s.offer(*entry.OfferedVehicleID, entry.StartTime, entry.EndTime); err != nil {
+            log.Printf("Waitlist: failed to re-offer vehicle %d: %v", *entry.OfferedVehicleID, err)
+        }
+    }
+
+    _, err = s.Repo.ExpireStaleEntries()
+    return err
+}
+
+func (s *Service) offer(vehicleID int, from, to time.Time) error {
+    entry, err := s.Repo.OfferVehicle(vehicleID, from, to)
+    if err != nil || entry == nil {
+        return err
+    }
+
+    log.Printf("Waitlist: offered vehicle %d to user %d (entry %d)", vehicleID, entry.UserID, entry.ID)
+    s.notify(entry)
+    return nil
+}
+
+func (s *Service) notify(entry *models.WaitlistEntry) {
+    email, err := s.UserRepo.GetUserEmail(entry.UserID)
+    if err != nil {
+        log.Printf("Waitlist: failed to load user %d: %v", entry.UserID, err)
+        return
+    }
+
+    body := fmt.Sprintf(
+        "Good news: a vehicle is now available for your requested time.\n\n"+
+            "From: %s\nTo: %s\n\n"+
+            "We're holding it for you until %s. Accept the offer on waitlist entry #%d to book it.\n",
+        entry.StartTime.Format("Mon 02 Jan 2006 15:04 MST"),
+        entry.EndTime.Format("Mon 02 Jan 2006 15:04 MST"),
+        entry.OfferExpiresAt.Format("15:04 MST"),
+        entry.ID,
+    )
+
+    err = s.Mailer.Send(notifications.Message{
+        To:      email,
+        Subject: "A vehicle is available for you",
+        Body:    body,
+    })
+    if err != nil {
+        log.Printf("Waitlist: failed to email %s: %v", email, err)
+    }
+}